@@ -23,13 +23,15 @@ var (
 	chk       port.Chunker
 	bm25      *retriever.BM25Retriever
 	mmr       *retriever.MMRReranker
+	synonyms  *retriever.SynonymMap
 )
 
 func init() {
 	store = memstore.NewMemoryStore()
 	tokenizer = analyzer.NewTokenizer(true)
 	chk = chunker.NewLineChunker(256, 50, tokenizer)
-	bm25 = retriever.NewBM25Retriever(store, tokenizer, 1.2, 0.75, 0.3)
+	synonyms = retriever.NewSynonymMap(retriever.DefaultSynonymGroups, tokenizer, 0.5)
+	bm25 = retriever.NewBM25Retriever(store, tokenizer, retriever.BM25Options{K1: 1.2, B: 0.75, PathBoostWeight: 0.3, ProximityWeight: 0.3, Synonyms: synonyms})
 	mmr = retriever.NewMMRReranker(0.7, 0.8)
 }
 
@@ -57,7 +59,7 @@ func indexContent(this js.Value, args []js.Value) interface{} {
 		ID:      docID,
 		Path:    filename,
 		ModTime: time.Now(),
-		Lang:    "text",
+		Lang:    domain.DetectLanguage(filename),
 	}
 
 	chunks, err := chk.Chunk(doc, content)
@@ -66,12 +68,17 @@ func indexContent(this js.Value, args []js.Value) interface{} {
 	}
 
 	postings := make(map[string]map[string]int)
+	positions := make(map[string]map[string][]int)
 	totalTokens := 0
 
 	for _, chunk := range chunks {
 		tf := make(map[string]int)
-		for _, token := range chunk.Tokens {
+		for i, token := range chunk.Tokens {
 			tf[token]++
+			if positions[token] == nil {
+				positions[token] = make(map[string][]int)
+			}
+			positions[token][chunk.ID] = append(positions[token][chunk.ID], i)
 		}
 		for term, count := range tf {
 			if postings[term] == nil {
@@ -83,9 +90,10 @@ func indexContent(this js.Value, args []js.Value) interface{} {
 	}
 
 	err = store.BatchIndex([]port.IndexedFile{{
-		Doc:      doc,
-		Chunks:   chunks,
-		Postings: postings,
+		Doc:       doc,
+		Chunks:    chunks,
+		Postings:  postings,
+		Positions: positions,
 	}})
 	if err != nil {
 		return makeError("indexing failed: " + err.Error())
@@ -151,7 +159,7 @@ func queryContent(this js.Value, args []js.Value) interface{} {
 
 func clearIndex(this js.Value, args []js.Value) interface{} {
 	store = memstore.NewMemoryStore()
-	bm25 = retriever.NewBM25Retriever(store, tokenizer, 1.2, 0.75, 0.3)
+	bm25 = retriever.NewBM25Retriever(store, tokenizer, retriever.BM25Options{K1: 1.2, B: 0.75, PathBoostWeight: 0.3, ProximityWeight: 0.3, Synonyms: synonyms})
 	return makeResult(map[string]interface{}{
 		"success": true,
 	})
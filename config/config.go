@@ -5,54 +5,121 @@ import (
 	"path/filepath"
 
 	"gopkg.in/yaml.v3"
+	"rag/internal/domain"
 )
 
 type Config struct {
 	Index     IndexConfig     `yaml:"index"`
 	Retrieve  RetrieveConfig  `yaml:"retrieve"`
 	Pack      PackConfig      `yaml:"pack"`
+	Synonyms  SynonymsConfig  `yaml:"synonyms"`
 	Embedding EmbeddingConfig `yaml:"embedding"`
 	Logging   LoggingConfig   `yaml:"logging"`
 }
 
 type EmbeddingConfig struct {
-	Enabled   bool   `yaml:"enabled"`
-	Provider  string `yaml:"provider"`
-	Model     string `yaml:"model"`
-	APIKeyEnv string `yaml:"api_key_env"`
-	BaseURL   string `yaml:"base_url"`
-	Dimension int    `yaml:"dimension"`
-	BatchSize int    `yaml:"batch_size"`
+	Enabled   bool       `yaml:"enabled"`
+	Provider  string     `yaml:"provider"`
+	Model     string     `yaml:"model"`
+	APIKeyEnv string     `yaml:"api_key_env"`
+	BaseURL   string     `yaml:"base_url"`
+	Dimension int        `yaml:"dimension"`
+	BatchSize int        `yaml:"batch_size"`
+	Index     string     `yaml:"index"`
+	HNSW      HNSWConfig `yaml:"hnsw"`
+
+	Quantization  string `yaml:"quantization"`
+	RescoreFactor int    `yaml:"rescore_factor"`
+
+	CacheMaxEntries int `yaml:"cache_max_entries"`
+}
+
+type HNSWConfig struct {
+	M              int   `yaml:"m"`
+	EfConstruction int   `yaml:"ef_construction"`
+	EfSearch       int   `yaml:"ef_search"`
+	Seed           int64 `yaml:"seed"`
 }
 
 type IndexConfig struct {
-	Includes     []string `yaml:"includes"`
-	Excludes     []string `yaml:"excludes"`
-	Language     string   `yaml:"language"`
-	Stemming     bool     `yaml:"stemming"`
-	ChunkTokens  int      `yaml:"chunk_tokens"`
-	ChunkOverlap int      `yaml:"chunk_overlap"`
-	K1           float64  `yaml:"k1"`
-	B            float64  `yaml:"b"`
-	ASTChunking  bool     `yaml:"ast_chunking"`
+	Includes         []string        `yaml:"includes"`
+	Excludes         []string        `yaml:"excludes"`
+	Language         string          `yaml:"language"`
+	Stemming         bool            `yaml:"stemming"`
+	SplitIdentifiers bool            `yaml:"split_identifiers"`
+	SplitLanguages   map[string]bool `yaml:"split_languages"`
+	ChunkTokens      int             `yaml:"chunk_tokens"`
+	ChunkOverlap     int             `yaml:"chunk_overlap"`
+	K1               float64         `yaml:"k1"`
+	B                float64         `yaml:"b"`
+	ASTChunking      bool            `yaml:"ast_chunking"`
+	Positions        bool            `yaml:"positions"`
+	Fields           bool            `yaml:"fields"`
+	Symbols          bool            `yaml:"symbols"`
+	CallGraph        string          `yaml:"call_graph"`
 }
 
 type RetrieveConfig struct {
-	TopK              int     `yaml:"top_k"`
-	MMRLambda         float64 `yaml:"mmr_lambda"`
-	DedupJaccard      float64 `yaml:"dedup_jaccard"`
-	PathBoostWeight   float64 `yaml:"path_boost_weight"`
-	HybridEnabled     bool    `yaml:"hybrid_enabled"`
-	RRFK              int     `yaml:"rrf_k"`
-	BM25Weight        float64 `yaml:"bm25_weight"`
-	MinScoreThreshold float64 `yaml:"min_score_threshold"`
+	TopK              int                `yaml:"top_k"`
+	MMRLambda         float64            `yaml:"mmr_lambda"`
+	DedupJaccard      float64            `yaml:"dedup_jaccard"`
+	PathBoostWeight   float64            `yaml:"path_boost_weight"`
+	ProximityWeight   float64            `yaml:"proximity_weight"`
+	FieldWeights      FieldWeightsConfig `yaml:"field_weights"`
+	PRF               PRFConfig          `yaml:"prf"`
+	Fuzzy             FuzzyConfig        `yaml:"fuzzy"`
+	HybridEnabled     bool               `yaml:"hybrid_enabled"`
+	Fusion            string             `yaml:"fusion"`
+	RRFK              int                `yaml:"rrf_k"`
+	BM25Weight        float64            `yaml:"bm25_weight"`
+	MinScoreThreshold float64            `yaml:"min_score_threshold"`
+}
+
+type FieldWeightsConfig struct {
+	Path      float64 `yaml:"path"`
+	Symbol    float64 `yaml:"symbol"`
+	Signature float64 `yaml:"signature"`
+	Doc       float64 `yaml:"doc"`
+	Body      float64 `yaml:"body"`
+}
+
+type PRFConfig struct {
+	Enabled        bool    `yaml:"enabled"`
+	FeedbackDocs   int     `yaml:"feedback_docs"`
+	Terms          int     `yaml:"terms"`
+	OriginalWeight float64 `yaml:"original_weight"`
+}
+
+type FuzzyConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	MaxDistance int     `yaml:"max_distance"`
+	Penalty     float64 `yaml:"penalty"`
 }
 
 type PackConfig struct {
-	TokenBudget  int     `yaml:"token_budget"`
-	RecencyBoost float64 `yaml:"recency_boost"`
-	Summarize    bool    `yaml:"summarize"`
-	Output       string  `yaml:"output"`
+	TokenBudget  int             `yaml:"token_budget"`
+	Tokenizer    string          `yaml:"tokenizer"`
+	RecencyBoost float64         `yaml:"recency_boost"`
+	Summarize    bool            `yaml:"summarize"`
+	Output       string          `yaml:"output"`
+	Graph        PackGraphConfig `yaml:"graph"`
+}
+
+type PackGraphConfig struct {
+	Enabled    bool    `yaml:"enabled"`
+	Share      float64 `yaml:"share"`
+	Callees    bool    `yaml:"callees"`
+	Interfaces bool    `yaml:"interfaces"`
+	Callers    bool    `yaml:"callers"`
+	Imports    bool    `yaml:"imports"`
+}
+
+type SynonymsConfig struct {
+	Enabled  bool       `yaml:"enabled"`
+	Defaults bool       `yaml:"defaults"`
+	Weight   float64    `yaml:"weight"`
+	File     string     `yaml:"file"`
+	Groups   [][]string `yaml:"groups"`
 }
 
 type LoggingConfig struct {
@@ -62,24 +129,49 @@ type LoggingConfig struct {
 func DefaultConfig() *Config {
 	return &Config{
 		Index: IndexConfig{
-			Includes:     []string{"***.py", "**/*.js", "**/*.ts", "**/*.java", "**/*.c", "**/*.cpp", "**/*.h", "**/*.rs", "**/*.md", "**/*.txt", "**/*_test.go", "**/test_*.py", "**/*_test.py", "**/*.test.js", "**/*.test.ts", "**/*.spec.js", "**/*.spec.ts", "**/*Test.java"},
-			Excludes:     []string{"**/node_modulesvendor/**", "**/.git/**", "**/dist/**", "**/build/**", "**/__pycache__/**", "**/*.min.js"},
-			Language:     "auto",
-			Stemming:     true,
-			ChunkTokens:  512,
-			ChunkOverlap: 50,
-			K1:           1.2,
-			B:            0.75,
-			ASTChunking:  true,
+			Includes:         []string{"***.py", "**/*.js", "**/*.ts", "**/*.java", "**/*.c", "**/*.cpp", "**/*.h", "**/*.rs", "**/*.md", "**/*.txt", "**/*_test.go", "**/test_*.py", "**/*_test.py", "**/*.test.js", "**/*.test.ts", "**/*.spec.js", "**/*.spec.ts", "**/*Test.java"},
+			Excludes:         []string{"**/node_modulesvendor/**", "**/.git/**", "**/dist/**", "**/build/**", "**/__pycache__/**", "**/*.min.js"},
+			Language:         "auto",
+			Stemming:         true,
+			SplitIdentifiers: false,
+			ChunkTokens:      512,
+			ChunkOverlap:     50,
+			K1:               1.2,
+			B:                0.75,
+			ASTChunking:      true,
+			Positions:        false,
+			Fields:           false,
+			Symbols:          false,
+			CallGraph:        "syntax",
 		},
 		Retrieve: RetrieveConfig{
 			TopK:            20,
 			MMRLambda:       0.7,
 			DedupJaccard:    0.8,
 			PathBoostWeight: 0.3,
-			HybridEnabled:   false,
-			RRFK:            60,
-			BM25Weight:      0.5,
+			ProximityWeight: 0.3,
+			FieldWeights: FieldWeightsConfig{
+				Path:      1.5,
+				Symbol:    3.0,
+				Signature: 2.0,
+				Doc:       1.5,
+				Body:      1.0,
+			},
+			PRF: PRFConfig{
+				Enabled:        false,
+				FeedbackDocs:   10,
+				Terms:          10,
+				OriginalWeight: 0.5,
+			},
+			Fuzzy: FuzzyConfig{
+				Enabled:     false,
+				MaxDistance: 2,
+				Penalty:     0.5,
+			},
+			HybridEnabled: false,
+			Fusion:        "linear",
+			RRFK:          60,
+			BM25Weight:    0.5,
 		},
 		Embedding: EmbeddingConfig{
 			Enabled:   false,
@@ -88,12 +180,33 @@ func DefaultConfig() *Config {
 			APIKeyEnv: "OPENAI_API_KEY",
 			Dimension: 1536,
 			BatchSize: 100,
+			Index:     "hnsw",
+			HNSW: HNSWConfig{
+				M:              16,
+				EfConstruction: 200,
+				EfSearch:       64,
+			},
+			Quantization:  "none",
+			RescoreFactor: 4,
 		},
 		Pack: PackConfig{
 			TokenBudget:  4000,
+			Tokenizer:    "estimate",
 			RecencyBoost: 0.1,
 			Summarize:    false,
 			Output:       "json",
+			Graph: PackGraphConfig{
+				Share:      0.25,
+				Callees:    true,
+				Interfaces: true,
+				Callers:    true,
+				Imports:    true,
+			},
+		},
+		Synonyms: SynonymsConfig{
+			Enabled:  false,
+			Defaults: true,
+			Weight:   0.5,
 		},
 		Logging: LoggingConfig{
 			Level: "info",
@@ -142,6 +255,20 @@ func (c *Config) Save(path string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
+func (c *Config) BM25FieldWeights() map[string]float64 {
+	if !c.Index.Fields {
+		return nil
+	}
+	w := c.Retrieve.FieldWeights
+	return map[string]float64{
+		domain.FieldPath:      w.Path,
+		domain.FieldSymbol:    w.Symbol,
+		domain.FieldSignature: w.Signature,
+		domain.FieldDoc:       w.Doc,
+		domain.FieldBody:      w.Body,
+	}
+}
+
 func IndexDBPath(dir string) string {
 	return filepath.Join(dir, ".rag", "index.db")
 }
@@ -1049,8 +1049,33 @@ func main() {
 	}
 	defer st.Close()
 
-	tokenizer := analyzer.NewTokenizer(cfg.Index.Stemming)
-	bm25 := retriever.NewBM25Retriever(st, tokenizer, cfg.Index.K1, cfg.Index.B, cfg.Retrieve.PathBoostWeight)
+	tokenizer := analyzer.NewTokenizerWithOptions(analyzer.TokenizerOptions{
+		Stemming:         cfg.Index.Stemming,
+		Language:         cfg.Index.Language,
+		SplitIdentifiers: cfg.Index.SplitIdentifiers,
+		SplitLanguages:   cfg.Index.SplitLanguages,
+	})
+	var synonyms *retriever.SynonymMap
+	if cfg.Synonyms.Enabled {
+		groups := cfg.Synonyms.Groups
+		if cfg.Synonyms.Defaults {
+			groups = append(append([][]string{}, retriever.DefaultSynonymGroups...), groups...)
+		}
+		synonyms = retriever.NewSynonymMap(groups, tokenizer, cfg.Synonyms.Weight)
+	}
+	var fuzzy *retriever.FuzzyMatcher
+	if cfg.Retrieve.Fuzzy.Enabled {
+		fuzzy = retriever.NewFuzzyMatcher(st.AllTerms, cfg.Retrieve.Fuzzy.MaxDistance, cfg.Retrieve.Fuzzy.Penalty)
+	}
+	bm25 := retriever.NewBM25Retriever(st, tokenizer, retriever.BM25Options{
+		K1:              cfg.Index.K1,
+		B:               cfg.Index.B,
+		PathBoostWeight: cfg.Retrieve.PathBoostWeight,
+		ProximityWeight: cfg.Retrieve.ProximityWeight,
+		FieldWeights:    cfg.BM25FieldWeights(),
+		Synonyms:        synonyms,
+		Fuzzy:           fuzzy,
+	})
 	mmr := retriever.NewMMRReranker(cfg.Retrieve.MMRLambda, cfg.Retrieve.DedupJaccard)
 
 	var searchRetriever port.Retriever = bm25
@@ -1061,10 +1086,15 @@ func main() {
 				fmt.Printf("Warning: Hybrid search unavailable: %v (using BM25 only)\n", err)
 			}
 		} else {
-			searchRetriever = retriever.NewHybridRetriever(
+			hybrid, err := retriever.NewHybridRetriever(
 				bm25, vectorStore, embedder, st,
-				cfg.Retrieve.RRFK, cfg.Retrieve.BM25Weight,
+				cfg.Retrieve.Fusion, cfg.Retrieve.RRFK, cfg.Retrieve.BM25Weight,
 			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			searchRetriever = hybrid
 			if *verbose {
 				fmt.Printf("Hybrid search enabled (BM25 + vector)\n")
 			}
@@ -1073,7 +1103,21 @@ func main() {
 
 	retrieveUC := usecase.NewRetrieveUseCase(searchRetriever, mmr, cfg.Retrieve.MinScoreThreshold)
 
-	packUC := usecase.NewPackUseCase(st, tokenizer, cfg.Pack.RecencyBoost)
+	counter, err := analyzer.NewEncodingTokenizer(cfg.Pack.Tokenizer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading tokenizer: %v\n", err)
+		os.Exit(1)
+	}
+	var expander *usecase.ContextExpander
+	if graph := cfg.Pack.Graph; graph.Enabled {
+		expander = usecase.NewContextExpander(st, usecase.ContextExpanderOptions{
+			Imports:    graph.Imports,
+			Interfaces: graph.Interfaces,
+			Callees:    graph.Callees,
+			Callers:    graph.Callers,
+		})
+	}
+	packUC := usecase.NewPackUseCase(st, counter, cfg.Pack.RecencyBoost, expander, cfg.Pack.Graph.Share)
 
 	agent := NewAgenticRAG(llm, retrieveUC, packUC, st, AgenticRAGOptions{
 		IndexPath:   *indexPath,
@@ -0,0 +1,275 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"rag/internal/port"
+)
+
+const (
+	EncodingEstimate     = "estimate"
+	EncodingCL100K       = "cl100k"
+	EncodingO200K        = "o200k"
+	EncodingApproxCL100K = "approx-" + EncodingCL100K
+	EncodingApproxO200K  = "approx-" + EncodingO200K
+)
+
+//go:embed vocab/*.tiktoken.gz
+var embeddedVocabs embed.FS
+
+type officialEncoding struct {
+	url    string
+	sha256 string
+}
+
+// Published rank files, as checked by tiktoken.
+var officialEncodings = map[string]officialEncoding{
+	EncodingCL100K: {
+		url:    "https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken",
+		sha256: "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7",
+	},
+	EncodingO200K: {
+		url:    "https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken",
+		sha256: "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d",
+	},
+}
+
+var preTokenizers = map[string]*regexp.Regexp{
+	EncodingCL100K: regexp.MustCompile(`\A(?:(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+)`),
+	EncodingO200K:  regexp.MustCompile(`\A(?:[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+)`),
+}
+
+type BPETokenizer struct {
+	ranks       map[string]int
+	pattern     *regexp.Regexp
+	approximate bool
+}
+
+// cl100k and o200k load tiktoken's cached rank files; other files named after o200k use its pre-tokenizer.
+func NewBPETokenizer(encoding string) (*BPETokenizer, error) {
+	pattern := EncodingCL100K
+	var r io.Reader
+	switch encoding {
+	case EncodingCL100K, EncodingO200K:
+		path, ok := tiktokenCachePath(officialEncodings[encoding].url)
+		if !ok {
+			return nil, fmt.Errorf("tokenizer %q needs the published rank file: download %s and set pack.tokenizer to its path (approx-%s only approximates counts)", encoding, officialEncodings[encoding].url, encoding)
+		}
+		return NewBPETokenizer(path)
+	case EncodingApproxCL100K, EncodingApproxO200K:
+		data, err := embeddedVocabs.ReadFile("vocab/" + encoding + ".tiktoken.gz")
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		ranks, err := ParseTiktokenRanks(gz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s vocabulary: %w", encoding, err)
+		}
+		pattern = strings.TrimPrefix(encoding, "approx-")
+		return &BPETokenizer{ranks: ranks, pattern: preTokenizers[pattern], approximate: true}, nil
+	default:
+		data, err := os.ReadFile(encoding)
+		if err != nil {
+			return nil, fmt.Errorf("unknown tokenizer %q: %w", encoding, err)
+		}
+		if name, official := identifyEncoding(data); official {
+			pattern = name
+		} else if strings.Contains(filepath.Base(encoding), EncodingO200K) {
+			pattern = EncodingO200K
+		}
+		r = bytes.NewReader(data)
+	}
+
+	ranks, err := ParseTiktokenRanks(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s vocabulary: %w", encoding, err)
+	}
+	return &BPETokenizer{ranks: ranks, pattern: preTokenizers[pattern]}, nil
+}
+
+// Approximate reports whether counts come from an embedded approx-* vocabulary.
+func (t *BPETokenizer) Approximate() bool {
+	return t.approximate
+}
+
+func identifyEncoding(data []byte) (string, bool) {
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	for name, official := range officialEncodings {
+		if official.sha256 == digest {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// tiktokenCachePath mirrors tiktoken's cache layout: files named by the SHA-1 of their URL.
+func tiktokenCachePath(url string) (string, bool) {
+	dir, ok := os.LookupEnv("TIKTOKEN_CACHE_DIR")
+	if !ok {
+		dir, ok = os.LookupEnv("DATA_GYM_CACHE_DIR")
+	}
+	if !ok {
+		dir = filepath.Join(os.TempDir(), "data-gym-cache")
+	}
+	if dir == "" {
+		return "", false
+	}
+
+	key := sha1.Sum([]byte(url))
+	path := filepath.Join(dir, hex.EncodeToString(key[:]))
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+func ParseTiktokenRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		token, rank, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("malformed rank line %q", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, err
+		}
+		ranks[string(decoded)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) < 256 {
+		return nil, fmt.Errorf("vocabulary has %d tokens, expected at least 256", len(ranks))
+	}
+	return ranks, nil
+}
+
+// NewEncodingTokenizer returns the word-count estimate or a BPE tokenizer.
+func NewEncodingTokenizer(encoding string) (port.Tokenizer, error) {
+	if encoding == "" || encoding == EncodingEstimate {
+		return NewTokenizer(false), nil
+	}
+	return NewBPETokenizer(encoding)
+}
+
+func (t *BPETokenizer) Tokenize(text string) []string {
+	var tokens []string
+	for _, piece := range preTokenize(t.pattern, text) {
+		if _, exists := t.ranks[piece]; exists {
+			tokens = append(tokens, piece)
+			continue
+		}
+		starts := bytePairMerge(t.ranks, piece)
+		for i, start := range starts {
+			end := len(piece)
+			if i+1 < len(starts) {
+				end = starts[i+1]
+			}
+			tokens = append(tokens, piece[start:end])
+		}
+	}
+	return tokens
+}
+
+func (t *BPETokenizer) CountTokens(text string) int {
+	count := 0
+	for _, piece := range preTokenize(t.pattern, text) {
+		if _, exists := t.ranks[piece]; exists {
+			count++
+			continue
+		}
+		count += len(bytePairMerge(t.ranks, piece))
+	}
+	return count
+}
+
+func PreTokenize(encoding, text string) []string {
+	return preTokenize(preTokenizers[encoding], text)
+}
+
+// preTokenize emulates tiktoken's `\s+(?!\S)`, which Go's regexp cannot express.
+func preTokenize(pattern *regexp.Regexp, text string) []string {
+	var pieces []string
+	for len(text) > 0 {
+		loc := pattern.FindStringIndex(text)
+		end := 1
+		if loc != nil && loc[1] > 0 {
+			end = loc[1]
+		}
+		piece := text[:end]
+		if end < len(text) && isSpaceRun(piece) {
+			last, size := utf8.DecodeLastRuneInString(piece)
+			if last != '\r' && last != '\n' && len(piece) > size {
+				end -= size
+				piece = text[:end]
+			}
+		}
+		pieces = append(pieces, piece)
+		text = text[end:]
+	}
+	return pieces
+}
+
+func isSpaceRun(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// bytePairMerge returns the start offsets of the parts left after merging.
+func bytePairMerge(ranks map[string]int, piece string) []int {
+	starts := make([]int, len(piece)+1)
+	for i := range starts {
+		starts[i] = i
+	}
+
+	for len(starts) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i < len(starts)-2; i++ {
+			rank, exists := ranks[piece[starts[i]:starts[i+2]]]
+			if exists && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		starts = append(starts[:best+1], starts[best+2:]...)
+	}
+	return starts[:len(starts)-1]
+}
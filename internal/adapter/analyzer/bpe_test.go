@@ -0,0 +1,172 @@
+package analyzer
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreTokenize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"hello world", []string{"hello", " world"}},
+		{"x  := 12345", []string{"x", " ", " :=", " ", "123", "45"}},
+		{"if err != nil {\n\t\treturn err\n\t}", []string{"if", " err", " !=", " nil", " {\n", "\t", "\treturn", " err", "\n", "\t", "}"}},
+		{"don't", []string{"don", "'t"}},
+		{"trailing   ", []string{"trailing", "   "}},
+	}
+
+	for _, tt := range tests {
+		if pieces := PreTokenize(EncodingCL100K, tt.input); !reflect.DeepEqual(pieces, tt.expected) {
+			t.Errorf("PreTokenize(%q) = %q, want %q", tt.input, pieces, tt.expected)
+		}
+	}
+
+	if pieces := PreTokenize(EncodingO200K, "HTTPServer path/to"); !reflect.DeepEqual(pieces, []string{"HTTPServer", " path", "/to"}) {
+		t.Errorf("unexpected o200k pieces: %q", pieces)
+	}
+}
+
+func TestBytePairMerge(t *testing.T) {
+	ranks := map[string]int{"ab": 256, "bc": 257, "abc": 258, "cd": 259}
+
+	tests := []struct {
+		piece    string
+		expected []int
+	}{
+		{"abcd", []int{0, 3}},
+		{"abc", []int{0}},
+		{"xyz", []int{0, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		if starts := bytePairMerge(ranks, tt.piece); !reflect.DeepEqual(starts, tt.expected) {
+			t.Errorf("bytePairMerge(%q) = %v, want %v", tt.piece, starts, tt.expected)
+		}
+	}
+}
+
+func TestBPETokenizer_Embedded(t *testing.T) {
+	code := "func (s *Store) Get(id string) (*Doc, error) {\n\treturn s.docs[id], nil\n}\n"
+	texts := []string{"", "hello world", code, "HTTPServer listens on :8080", "日本語のテキスト"}
+
+	for _, encoding := range []string{EncodingApproxCL100K, EncodingApproxO200K} {
+		tokenizer, err := NewBPETokenizer(encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tokenizer.Approximate() {
+			t.Errorf("%s: expected the embedded vocabulary to be marked approximate", encoding)
+		}
+		for _, text := range texts {
+			pieces := len(PreTokenize(strings.TrimPrefix(encoding, "approx-"), text))
+			n := tokenizer.CountTokens(text)
+			if n < pieces || n > len(text) {
+				t.Errorf("%s: CountTokens(%q) = %d, want between %d pieces and %d bytes", encoding, text, n, pieces, len(text))
+			}
+			tokens := tokenizer.Tokenize(text)
+			if len(tokens) != n || strings.Join(tokens, "") != text {
+				t.Errorf("%s: Tokenize(%q) = %q, want %d tokens covering the text", encoding, text, tokens, n)
+			}
+		}
+	}
+}
+
+func TestBPETokenizer_NamedEncodingsUseTiktokenCache(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TIKTOKEN_CACHE_DIR", dir)
+
+	if _, err := NewBPETokenizer(EncodingCL100K); err == nil {
+		t.Fatalf("expected %s without a cached rank file to be rejected", EncodingCL100K)
+	}
+
+	var sb strings.Builder
+	for i := 0; i < 256; i++ {
+		sb.WriteString(encodeRankLine(string([]byte{byte(i)}), i))
+	}
+	sb.WriteString(encodeRankLine("ab", 256))
+	key := sha1.Sum([]byte(officialEncodings[EncodingCL100K].url))
+	if err := os.WriteFile(filepath.Join(dir, hex.EncodeToString(key[:])), []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenizer, err := NewBPETokenizer(EncodingCL100K)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokenizer.Approximate() || tokenizer.CountTokens("abab") != 2 {
+		t.Errorf("expected the cached rank file to be loaded as an exact encoding")
+	}
+}
+
+// Reference IDs from tiktoken; skipped unless the published rank files are cached.
+func TestBPETokenizer_ReferenceEncodings(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		expected []int
+	}{
+		{EncodingCL100K, "hello world", []int{15339, 1917}},
+		{EncodingCL100K, "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{EncodingO200K, "hello world", []int{24912, 2375}},
+		{EncodingO200K, "tiktoken is great!", []int{83, 8251, 2488, 382, 2212, 0}},
+	}
+
+	for _, tt := range tests {
+		tokenizer, err := NewBPETokenizer(tt.encoding)
+		if err != nil {
+			t.Skipf("%s rank file not cached: %v", tt.encoding, err)
+		}
+		var ids []int
+		for _, token := range tokenizer.Tokenize(tt.text) {
+			ids = append(ids, tokenizer.ranks[token])
+		}
+		if !reflect.DeepEqual(ids, tt.expected) {
+			t.Errorf("%s: encode(%q) = %v, want %v", tt.encoding, tt.text, ids, tt.expected)
+		}
+	}
+}
+
+func TestNewEncodingTokenizer(t *testing.T) {
+	tokenizer, err := NewEncodingTokenizer(EncodingEstimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tokenizer.(*Tokenizer); !ok {
+		t.Errorf("expected word estimate for %q, got %T", EncodingEstimate, tokenizer)
+	}
+
+	path := filepath.Join(t.TempDir(), "custom.tiktoken")
+	var sb strings.Builder
+	for i := 0; i < 256; i++ {
+		sb.WriteString(encodeRankLine(string([]byte{byte(i)}), i))
+	}
+	sb.WriteString(encodeRankLine("ab", 256))
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenizer, err = NewEncodingTokenizer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := tokenizer.CountTokens("abab"); n != 2 {
+		t.Errorf("expected custom ranks to merge pairs, got %d tokens", n)
+	}
+
+	if _, err := NewEncodingTokenizer("no-such-encoding"); err == nil {
+		t.Error("expected error for unknown tokenizer")
+	}
+}
+
+func encodeRankLine(token string, rank int) string {
+	return fmt.Sprintf("%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+}
@@ -237,7 +237,7 @@ func (e *CommentExtractor) ExtractCommentTokens(content string, lang string, tok
 	tokenFreq := make(map[string]int)
 
 	for _, comment := range comments {
-		tokens := tokenizer.Tokenize(comment.Text)
+		tokens := tokenizer.TokenizeLang(comment.Text, lang)
 		for _, token := range tokens {
 			tokenFreq[token]++
 		}
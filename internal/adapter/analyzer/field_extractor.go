@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"strings"
+
+	"rag/internal/domain"
+)
+
+type FieldExtractor struct {
+	symbols   *SymbolExtractor
+	comments  *CommentExtractor
+	tokenizer *Tokenizer
+}
+
+func NewFieldExtractor(tokenizer *Tokenizer) *FieldExtractor {
+	return &FieldExtractor{
+		symbols:   NewSymbolExtractor(),
+		comments:  NewCommentExtractor(),
+		tokenizer: tokenizer,
+	}
+}
+
+func (e *FieldExtractor) ExtractFields(doc domain.Document, content string, chunks []domain.Chunk) {
+	pathTokens := e.tokenizer.Tokenize(doc.Path)
+
+	symbols, _ := e.symbols.ExtractSymbols(doc.ID, content, doc.Lang)
+	var comments []CommentBlock
+	if len(symbols) > 0 {
+		comments = e.comments.Extract(content, doc.Lang)
+	}
+
+	for i := range chunks {
+		fields := make(map[string][]string)
+		if len(pathTokens) > 0 {
+			fields[domain.FieldPath] = pathTokens
+		}
+
+		var names, signatures, docs []string
+		for _, sym := range symbols {
+			if sym.Type == "variable" || sym.Line < chunks[i].StartLine || sym.Line > chunks[i].EndLine {
+				continue
+			}
+			names = append(names, sym.Name)
+			if sym.Signature != "" {
+				signatures = append(signatures, sym.Signature)
+			}
+			for _, c := range comments {
+				if c.EndLine == sym.Line-1 || c.StartLine == sym.Line+1 {
+					docs = append(docs, c.Text)
+				}
+			}
+		}
+
+		e.setField(fields, domain.FieldSymbol, names, doc)
+		e.setField(fields, domain.FieldSignature, signatures, doc)
+		e.setField(fields, domain.FieldDoc, docs, doc)
+
+		chunks[i].Fields = fields
+	}
+}
+
+func (e *FieldExtractor) setField(fields map[string][]string, field string, parts []string, doc domain.Document) {
+	if len(parts) == 0 {
+		return
+	}
+	if tokens := e.tokenizer.TokenizeDoc(strings.Join(parts, "\n"), doc); len(tokens) > 0 {
+		fields[field] = tokens
+	}
+}
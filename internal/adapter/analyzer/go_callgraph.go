@@ -0,0 +1,376 @@
+package analyzer
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rag/internal/domain"
+)
+
+// GoCallGraph resolves calls with go/types without invoking the go command.
+type GoCallGraph struct {
+	fset     *token.FileSet
+	std      types.ImporterFrom
+	modules  map[string]goModule
+	packages map[string]*types.Package
+	loading  map[string]bool
+}
+
+func NewGoCallGraph() *GoCallGraph {
+	return &GoCallGraph{}
+}
+
+// AnalyzeCalls re-analyzes the packages of changed files and their importers.
+func (g *GoCallGraph) AnalyzeCalls(docIDs map[string]string, changed []string) (map[string][]domain.CallGraphEntry, error) {
+	g.fset = token.NewFileSet()
+	g.std = importer.ForCompiler(g.fset, "source", nil).(types.ImporterFrom)
+	g.modules = make(map[string]goModule)
+	g.packages = make(map[string]*types.Package)
+	g.loading = make(map[string]bool)
+
+	ids := make(map[string]string, len(docIDs))
+	dirs := make(map[string]bool)
+	for path, docID := range docIDs {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		ids[abs] = docID
+		dirs[filepath.Dir(abs)] = true
+	}
+
+	changedDirs := make(map[string]bool)
+	for _, path := range changed {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		changedDirs[filepath.Dir(abs)] = true
+	}
+	affected := g.affectedDirs(dirs, changedDirs)
+
+	sortedDirs := make([]string, 0, len(affected))
+	for dir := range affected {
+		if dirs[dir] {
+			sortedDirs = append(sortedDirs, dir)
+		}
+	}
+	sort.Strings(sortedDirs)
+
+	entries := make(map[string][]domain.CallGraphEntry)
+	for path, docID := range ids {
+		if affected[filepath.Dir(path)] {
+			entries[docID] = nil
+		}
+	}
+	for _, dir := range sortedDirs {
+		g.analyzeDir(dir, ids, entries)
+	}
+	return entries, nil
+}
+
+// affectedDirs adds every indexed directory importing a changed one, transitively.
+func (g *GoCallGraph) affectedDirs(dirs, changed map[string]bool) map[string]bool {
+	importers := make(map[string][]string)
+	for dir := range dirs {
+		pkg, err := build.Default.ImportDir(dir, 0)
+		if err != nil && pkg == nil {
+			continue
+		}
+		for _, imports := range [][]string{pkg.Imports, pkg.TestImports, pkg.XTestImports} {
+			for _, path := range imports {
+				importers[path] = append(importers[path], dir)
+			}
+		}
+	}
+
+	affected := make(map[string]bool)
+	var queue []string
+	for dir := range changed {
+		queue = append(queue, dir)
+	}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		if affected[dir] {
+			continue
+		}
+		affected[dir] = true
+		queue = append(queue, importers[g.importPath(dir)]...)
+	}
+	return affected
+}
+
+func (g *GoCallGraph) analyzeDir(dir string, ids map[string]string, entries map[string][]domain.CallGraphEntry) {
+	pkg, err := build.Default.ImportDir(dir, 0)
+	if err != nil && pkg == nil {
+		return
+	}
+
+	// External tests (package foo_test) form a package of their own.
+	units := [][]string{
+		append(append(append([]string(nil), pkg.GoFiles...), pkg.CgoFiles...), pkg.TestGoFiles...),
+		pkg.XTestGoFiles,
+	}
+	for i, names := range units {
+		var indexed bool
+		for _, name := range names {
+			if _, ok := ids[filepath.Join(dir, name)]; ok {
+				indexed = true
+			}
+		}
+		if !indexed {
+			continue
+		}
+
+		files := g.parseFiles(dir, names)
+		info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+		conf := types.Config{
+			Importer:    g,
+			FakeImportC: true,
+			Error:       func(error) {},
+		}
+		path := g.importPath(dir)
+		if i == 1 {
+			path += "_test"
+		}
+		conf.Check(path, g.fset, files, info)
+
+		for _, file := range files {
+			filename := g.fset.Position(file.Pos()).Filename
+			if docID, ok := ids[filename]; ok {
+				entries[docID] = g.fileCalls(docID, file, info, ids)
+			}
+		}
+	}
+}
+
+func (g *GoCallGraph) fileCalls(docID string, file *ast.File, info *types.Info, ids map[string]string) []domain.CallGraphEntry {
+	var entries []domain.CallGraphEntry
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = formatReceiver(fn.Recv.List[0].Type) + "." + name
+		}
+		callerID := generateSymbolID(docID, name, g.fset.Position(fn.Pos()).Line)
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			callee := calledFunc(call.Fun, info)
+			if callee == nil {
+				return true
+			}
+
+			entry := domain.CallGraphEntry{
+				CallerID: callerID,
+				Line:     g.fset.Position(call.Pos()).Line,
+			}
+			pos := g.fset.Position(callee.Pos())
+			calleeDocID, indexed := ids[pos.Filename]
+			if recvName, ok := receiverName(callee); indexed && ok {
+				calleeName := callee.Name()
+				if callee.Type().(*types.Signature).Recv() != nil {
+					calleeName = recvName + "." + calleeName
+				}
+				entry.CalleeID = generateSymbolID(calleeDocID, calleeName, pos.Line)
+			} else {
+				entry.CalleeID = callee.FullName()
+				entry.External = true
+			}
+			entries = append(entries, entry)
+			return true
+		})
+	}
+	return entries
+}
+
+// calledFunc returns nil for builtins, conversions and calls of function values.
+func calledFunc(fun ast.Expr, info *types.Info) *types.Func {
+	for {
+		switch e := fun.(type) {
+		case *ast.ParenExpr:
+			fun = e.X
+			continue
+		case *ast.IndexExpr:
+			fun = e.X
+			continue
+		case *ast.IndexListExpr:
+			fun = e.X
+			continue
+		case *ast.Ident:
+			fn, _ := info.Uses[e].(*types.Func)
+			return originFunc(fn)
+		case *ast.SelectorExpr:
+			fn, _ := info.Uses[e.Sel].(*types.Func)
+			return originFunc(fn)
+		}
+		return nil
+	}
+}
+
+func originFunc(fn *types.Func) *types.Func {
+	if fn == nil {
+		return nil
+	}
+	return fn.Origin()
+}
+
+// Interface methods report false: they have no symbol of their own.
+func receiverName(fn *types.Func) (string, bool) {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return "", true
+	}
+	t := recv.Type()
+	prefix := ""
+	if ptr, ok := t.(*types.Pointer); ok {
+		t, prefix = ptr.Elem(), "*"
+	}
+	named, ok := t.(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return "", false
+	}
+	if named.TypeParams().Len() > 0 {
+		return "", true
+	}
+	return prefix + named.Obj().Name(), true
+}
+
+// ImportFrom implements types.ImporterFrom.
+func (g *GoCallGraph) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if pkgDir, ok := g.moduleDir(path, dir); ok {
+		return g.importDir(path, pkgDir)
+	}
+	if first, _, _ := strings.Cut(path, "/"); !strings.Contains(first, ".") {
+		return g.std.ImportFrom(path, dir, mode)
+	}
+	return nil, fmt.Errorf("package %s is outside the indexed module", path)
+}
+
+func (g *GoCallGraph) Import(path string) (*types.Package, error) {
+	return g.ImportFrom(path, "", 0)
+}
+
+func (g *GoCallGraph) importDir(path, dir string) (*types.Package, error) {
+	if pkg, ok := g.packages[dir]; ok {
+		return pkg, nil
+	}
+	if g.loading[dir] {
+		return nil, fmt.Errorf("import cycle through %s", path)
+	}
+	g.loading[dir] = true
+	defer delete(g.loading, dir)
+
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil && bp == nil {
+		return nil, err
+	}
+	conf := types.Config{
+		Importer:         g,
+		FakeImportC:      true,
+		IgnoreFuncBodies: true,
+		Error:            func(error) {},
+	}
+	files := g.parseFiles(dir, append(append([]string(nil), bp.GoFiles...), bp.CgoFiles...))
+	pkg, _ := conf.Check(path, g.fset, files, nil)
+	if pkg == nil {
+		return nil, fmt.Errorf("failed to type-check %s", path)
+	}
+	g.packages[dir] = pkg
+	return pkg, nil
+}
+
+func (g *GoCallGraph) parseFiles(dir string, names []string) []*ast.File {
+	files := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		file, _ := parser.ParseFile(g.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func (g *GoCallGraph) moduleDir(path, srcDir string) (string, bool) {
+	root, modPath := g.module(srcDir)
+	if modPath == "" {
+		return "", false
+	}
+	if path == modPath {
+		return root, true
+	}
+	if rest, ok := strings.CutPrefix(path, modPath+"/"); ok {
+		return filepath.Join(root, filepath.FromSlash(rest)), true
+	}
+	return "", false
+}
+
+func (g *GoCallGraph) importPath(dir string) string {
+	root, modPath := g.module(dir)
+	if modPath == "" {
+		return filepath.Base(dir)
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return modPath
+	}
+	return modPath + "/" + filepath.ToSlash(rel)
+}
+
+type goModule struct {
+	root string
+	path string
+}
+
+// module reads the module directive of the nearest go.mod.
+func (g *GoCallGraph) module(dir string) (root, modPath string) {
+	if dir == "" {
+		return "", ""
+	}
+	if m, ok := g.modules[dir]; ok {
+		return m.root, m.path
+	}
+
+	var m goModule
+	if modPath := readModulePath(filepath.Join(dir, "go.mod")); modPath != "" {
+		m = goModule{root: dir, path: modPath}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		m.root, m.path = g.module(parent)
+	}
+	g.modules[dir] = m
+	return m.root, m.path
+}
+
+func readModulePath(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rag/internal/domain"
+)
+
+func TestGoCallGraph_AnalyzeCalls(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/demo\n\ngo 1.22\n",
+		"store/store.go": `package store
+
+type Store struct{}
+
+func Open() *Store { return &Store{} }
+
+func (s *Store) Close() error { return nil }
+`,
+		"store/file.go": `package store
+
+type File struct{}
+
+func (f File) Close() error { return nil }
+`,
+		"app/app.go": `package app
+
+import (
+	"fmt"
+	"io"
+
+	"example.com/demo/store"
+)
+
+type App struct {
+	*store.Store
+}
+
+func Run(c io.Closer) {
+	s := store.Open()
+	defer s.Close()
+	var f store.File
+	f.Close()
+	App{s}.Close()
+	c.Close()
+	fmt.Println(helper())
+}
+`,
+		"app/helper.go": `package app
+
+func helper() string { return "ok" }
+`,
+	}
+
+	docIDs := make(map[string]string)
+	symbols := make(map[string]domain.Symbol)
+	extractor := NewSymbolExtractor()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) != ".go" {
+			continue
+		}
+		docIDs[path] = name
+		extracted, err := extractor.ExtractSymbols(name, content, "go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sym := range extracted {
+			symbols[sym.ID] = sym
+		}
+	}
+
+	var changed []string
+	for path := range docIDs {
+		changed = append(changed, path)
+	}
+	entries, err := NewGoCallGraph().AnalyzeCalls(docIDs, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, entry := range entries["app/app.go"] {
+		if symbols[entry.CallerID].Name != "Run" {
+			t.Errorf("expected calls attributed to Run, got caller %q", entry.CallerID)
+		}
+		if entry.External {
+			got = append(got, entry.CalleeID)
+			continue
+		}
+		callee, ok := symbols[entry.CalleeID]
+		if !ok {
+			t.Errorf("callee %q at line %d does not resolve to a symbol", entry.CalleeID, entry.Line)
+			continue
+		}
+		got = append(got, callee.DocID+":"+callee.Name)
+	}
+
+	want := []string{
+		"store/store.go:Open",
+		"store/store.go:*Store.Close",
+		"store/file.go:File.Close",
+		"store/store.go:*Store.Close",
+		"(io.Closer).Close",
+		"fmt.Println",
+		"app/helper.go:helper",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("call %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if len(entries["store/store.go"]) != 0 {
+		t.Errorf("expected no calls from store.go, got %+v", entries["store/store.go"])
+	}
+
+	entries, err = NewGoCallGraph().AnalyzeCalls(docIDs, []string{filepath.Join(dir, "store/file.go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entries["app/app.go"]; !ok || len(entries) != len(docIDs) {
+		t.Errorf("expected a store change to re-analyze its importers, got %d files", len(entries))
+	}
+
+	entries, err = NewGoCallGraph().AnalyzeCalls(docIDs, []string{filepath.Join(dir, "app/helper.go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entries["store/store.go"]; ok || len(entries) != 2 {
+		t.Errorf("expected an app change to leave store alone, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,195 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	LanguageAuto    = "auto"
+	LanguageEnglish = "english"
+	LanguageGerman  = "german"
+	LanguageFrench  = "french"
+	LanguageSpanish = "spanish"
+	LanguageRussian = "russian"
+)
+
+var languageAliases = map[string]string{
+	"":   LanguageAuto,
+	"en": LanguageEnglish,
+	"de": LanguageGerman,
+	"fr": LanguageFrench,
+	"es": LanguageSpanish,
+	"ru": LanguageRussian,
+}
+
+type languageAnalyzer struct {
+	stemmer   Stemmer
+	stopwords map[string]struct{}
+}
+
+func NormalizeLanguage(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, exists := languageAliases[name]; exists {
+		name = alias
+	}
+	if name != LanguageAuto {
+		if _, exists := languageStopwords[name]; !exists {
+			return "", fmt.Errorf("unsupported language %q (supported: auto, english, german, french, spanish, russian)", name)
+		}
+	}
+	return name, nil
+}
+
+func newStemmer(lang string) Stemmer {
+	switch lang {
+	case LanguageGerman:
+		return NewGermanStemmer()
+	case LanguageFrench:
+		return NewFrenchStemmer()
+	case LanguageSpanish:
+		return NewSpanishStemmer()
+	case LanguageRussian:
+		return NewRussianStemmer()
+	default:
+		return NewPorterStemmer()
+	}
+}
+
+// Files in other languages are code and always use the English analyzer.
+var proseLanguages = map[string]bool{
+	"markdown": true,
+	"text":     true,
+	"unknown":  true,
+}
+
+var languageCues = map[string]string{
+	LanguageGerman:  "äöüß",
+	LanguageFrench:  "çœàâèêëîïôûù",
+	LanguageSpanish: "ñ¿¡áíóú",
+}
+
+// DetectTextLanguage scores stopwords and language-specific letters, defaulting to English.
+func DetectTextLanguage(text string) string {
+	scores := make(map[string]int, len(languageCues))
+	for _, r := range strings.ToLower(text) {
+		for lang, cues := range languageCues {
+			if strings.ContainsRune(cues, r) {
+				scores[lang] += 2
+			}
+		}
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for _, lang := range []string{LanguageEnglish, LanguageGerman, LanguageFrench, LanguageSpanish} {
+			if _, isStop := stopwordSets[lang][word]; isStop {
+				scores[lang]++
+			}
+		}
+	}
+
+	best := LanguageEnglish
+	for _, lang := range []string{LanguageGerman, LanguageFrench, LanguageSpanish} {
+		if scores[lang] >= 2 && scores[lang] > scores[best] {
+			best = lang
+		}
+	}
+	return best
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isCyrillic(word string) bool {
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// A single-character run is emitted as is.
+func cjkBigrams(run []rune) []string {
+	if len(run) == 1 {
+		return []string{string(run)}
+	}
+	grams := make([]string, 0, len(run)-1)
+	for i := 0; i+1 < len(run); i++ {
+		grams = append(grams, string(run[i:i+2]))
+	}
+	return grams
+}
+
+var stopwordSets = func() map[string]map[string]struct{} {
+	sets := make(map[string]map[string]struct{}, len(languageStopwords))
+	for lang, words := range languageStopwords {
+		set := make(map[string]struct{}, len(words))
+		for _, w := range words {
+			set[w] = struct{}{}
+		}
+		sets[lang] = set
+	}
+	return sets
+}()
+
+var languageStopwords = map[string][]string{
+	LanguageEnglish: {
+		"a", "an", "and", "are", "as", "at", "be", "by", "for",
+		"from", "has", "he", "in", "is", "it", "its", "of", "on",
+		"that", "the", "to", "was", "were", "will", "with", "this",
+		"have", "had", "but", "not", "you", "your", "we", "our",
+		"they", "their", "she", "her", "his", "if", "or", "so",
+		"no", "can", "do", "does", "did", "been", "being", "would",
+		"could", "should", "may", "might", "must", "shall", "which",
+		"who", "whom", "what", "when", "where", "why", "how", "all",
+		"each", "every", "both", "few", "more", "most", "other",
+		"some", "such", "than", "too", "very", "just", "also",
+	},
+	LanguageGerman: {
+		"aber", "alle", "allem", "allen", "aller", "als", "also", "am", "an", "ander", "andere",
+		"auch", "auf", "aus", "bei", "bin", "bis", "bist", "da", "damit", "dann", "das", "dass",
+		"daß", "dem", "den", "denn", "der", "des", "dich", "die", "dies", "diese", "diesem",
+		"diesen", "dieser", "dieses", "dir", "doch", "dort", "du", "durch", "ein", "eine",
+		"einem", "einen", "einer", "eines", "er", "es", "euch", "für", "gegen", "hat", "hatte",
+		"hier", "ich", "ihm", "ihn", "ihr", "ihre", "im", "in", "ist", "jede", "jeder", "jetzt",
+		"kann", "kein", "keine", "man", "mich", "mir", "mit", "muss", "nach", "nicht", "nichts",
+		"noch", "nur", "ob", "oder", "ohne", "sehr", "sein", "seine", "sich", "sie", "sind",
+		"so", "soll", "über", "um", "und", "uns", "unter", "vom", "von", "vor", "wann", "war",
+		"was", "weil", "welche", "wenn", "wer", "werden", "wie", "wieder", "wir", "wird",
+		"wo", "zu", "zum", "zur", "zwischen",
+	},
+	LanguageFrench: {
+		"au", "aux", "avec", "ce", "ces", "cette", "dans", "de", "des", "du", "elle", "en",
+		"et", "eux", "il", "ils", "je", "la", "le", "les", "leur", "lui", "ma", "mais", "me",
+		"même", "mes", "moi", "mon", "ne", "nos", "notre", "nous", "on", "ou", "où", "par",
+		"pas", "pour", "qu", "que", "qui", "sa", "se", "ses", "son", "sont", "sur", "ta", "te",
+		"tes", "toi", "ton", "tu", "un", "une", "vos", "votre", "vous", "est", "été", "être",
+		"avoir", "ont", "fait", "comme", "si", "sans", "plus", "aussi", "entre", "tout",
+		"tous", "cela", "ceci", "donc", "quand", "peut", "doit",
+	},
+	LanguageSpanish: {
+		"de", "la", "que", "el", "en", "y", "a", "los", "del", "se", "las", "por", "un",
+		"para", "con", "no", "una", "su", "al", "lo", "como", "más", "pero", "sus", "le",
+		"ya", "o", "este", "sí", "porque", "esta", "entre", "cuando", "muy", "sin", "sobre",
+		"también", "me", "hasta", "hay", "donde", "quien", "desde", "todo", "nos", "durante",
+		"todos", "uno", "les", "ni", "contra", "otros", "ese", "eso", "ante", "ellos", "e",
+		"esto", "mí", "antes", "algunos", "qué", "unos", "yo", "otro", "otras", "otra", "él",
+		"tanto", "esa", "estos", "mucho", "cual", "poco", "ella", "estar", "es", "son", "ser",
+		"puede", "cada",
+	},
+	LanguageRussian: {
+		"и", "в", "во", "не", "что", "он", "на", "я", "с", "со", "как", "а", "то", "все",
+		"она", "так", "его", "но", "да", "ты", "к", "у", "же", "вы", "за", "бы", "по", "только",
+		"ее", "мне", "было", "вот", "от", "меня", "еще", "нет", "о", "из", "ему", "теперь",
+		"когда", "даже", "ну", "ли", "если", "уже", "или", "ни", "быть", "был", "него", "до",
+		"вас", "нибудь", "уж", "вам", "ведь", "там", "потом", "себя", "ничего", "ей", "может",
+		"они", "тут", "где", "есть", "надо", "ней", "для", "мы", "тебя", "их", "чем", "была",
+		"сам", "чтоб", "без", "будто", "чего", "раз", "тоже", "себе", "под", "будет", "ж",
+		"тогда", "кто", "этот", "того", "потому", "этого", "какой", "ним", "здесь", "этом",
+		"при", "это", "эти", "также",
+	},
+}
@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type Stemmer interface {
+	Stem(word string) string
+}
+
+type snowballWord struct {
+	runes   []rune
+	isVowel func(rune) bool
+	r1, r2  int
+	rv      int // Romance languages only
+}
+
+func newSnowballWord(word string, vowels string) *snowballWord {
+	w := &snowballWord{
+		runes: []rune(word),
+		isVowel: func(r rune) bool {
+			return strings.ContainsRune(vowels, r)
+		},
+	}
+	w.r1 = w.regionAfter(0)
+	w.r2 = w.regionAfter(w.r1)
+	w.rv = len(w.runes)
+	return w
+}
+
+// regionAfter returns the index after the first non-vowel following a vowel.
+func (w *snowballWord) regionAfter(from int) int {
+	for i := from + 1; i < len(w.runes); i++ {
+		if !w.isVowel(w.runes[i]) && w.isVowel(w.runes[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w.runes)
+}
+
+func (w *snowballWord) String() string {
+	return string(w.runes)
+}
+
+func (w *snowballWord) hasSuffix(suffix string) bool {
+	n := utf8.RuneCountInString(suffix)
+	return n <= len(w.runes) && string(w.runes[len(w.runes)-n:]) == suffix
+}
+
+// longestSuffix returns the longest of the candidates the word ends with.
+func (w *snowballWord) longestSuffix(suffixes ...string) string {
+	best, bestLen := "", 0
+	for _, s := range suffixes {
+		if n := utf8.RuneCountInString(s); n > bestLen && w.hasSuffix(s) {
+			best, bestLen = s, n
+		}
+	}
+	return best
+}
+
+func (w *snowballWord) suffixStart(suffix string) int {
+	return len(w.runes) - utf8.RuneCountInString(suffix)
+}
+
+func (w *snowballWord) inR1(suffix string) bool { return w.suffixStart(suffix) >= w.r1 }
+func (w *snowballWord) inR2(suffix string) bool { return w.suffixStart(suffix) >= w.r2 }
+func (w *snowballWord) inRV(suffix string) bool { return w.suffixStart(suffix) >= w.rv }
+
+func (w *snowballWord) trim(suffix string) {
+	w.runes = w.runes[:w.suffixStart(suffix)]
+}
+
+func (w *snowballWord) replace(suffix, with string) {
+	w.trim(suffix)
+	w.runes = append(w.runes, []rune(with)...)
+}
+
+func (w *snowballWord) trimInR2(suffix string) {
+	if w.hasSuffix(suffix) && w.inR2(suffix) {
+		w.trim(suffix)
+	}
+}
+
+// precededBy reports whether the word, with suffix removed, ends with s.
+func (w *snowballWord) precededBy(suffix, s string) bool {
+	end := w.suffixStart(suffix)
+	n := utf8.RuneCountInString(s)
+	return end-n >= 0 && string(w.runes[end-n:end]) == s
+}
+
+func (w *snowballWord) precededByAny(suffix string, candidates ...string) bool {
+	for _, c := range candidates {
+		if w.precededBy(suffix, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *snowballWord) last() rune {
+	if len(w.runes) == 0 {
+		return 0
+	}
+	return w.runes[len(w.runes)-1]
+}
+
+// romanceRV computes RV as defined for Spanish, Portuguese and Italian.
+func (w *snowballWord) romanceRV() int {
+	r := w.runes
+	if len(r) < 2 {
+		return len(r)
+	}
+	if !w.isVowel(r[1]) {
+		for i := 2; i < len(r); i++ {
+			if w.isVowel(r[i]) {
+				return i + 1
+			}
+		}
+		return len(r)
+	}
+	if w.isVowel(r[0]) {
+		for i := 2; i < len(r); i++ {
+			if !w.isVowel(r[i]) {
+				return i + 1
+			}
+		}
+		return len(r)
+	}
+	return min(3, len(r))
+}
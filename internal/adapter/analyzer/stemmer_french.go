@@ -0,0 +1,278 @@
+package analyzer
+
+import "strings"
+
+type FrenchStemmer struct{}
+
+func NewFrenchStemmer() *FrenchStemmer {
+	return &FrenchStemmer{}
+}
+
+const frenchVowels = "aeiouyâàëéêèïîôûù"
+
+func (s *FrenchStemmer) Stem(word string) string {
+	runes := []rune(strings.ToLower(word))
+	isVowel := func(i int) bool {
+		return i >= 0 && i < len(runes) && strings.ContainsRune(frenchVowels, runes[i])
+	}
+	for i := range runes {
+		switch {
+		case (runes[i] == 'u' || runes[i] == 'i') && isVowel(i-1) && isVowel(i+1):
+			runes[i] = runes[i] - 'a' + 'A'
+		case runes[i] == 'y' && (isVowel(i-1) || isVowel(i+1)):
+			runes[i] = 'Y'
+		case runes[i] == 'u' && i > 0 && runes[i-1] == 'q':
+			runes[i] = 'U'
+		}
+	}
+
+	w := newSnowballWord(string(runes), frenchVowels)
+	w.rv = frenchRV(w)
+
+	if frenchStandardSuffix(w) || frenchIVerbSuffix(w) || frenchVerbSuffix(w) {
+		switch w.last() {
+		case 'Y':
+			w.replace("Y", "i")
+		case 'ç':
+			w.replace("ç", "c")
+		}
+	} else {
+		frenchResidualSuffix(w)
+	}
+
+	if w.longestSuffix("enn", "onn", "ett", "ell", "eill") != "" {
+		w.runes = w.runes[:len(w.runes)-1]
+	}
+
+	i := len(w.runes) - 1
+	for i >= 0 && !w.isVowel(w.runes[i]) {
+		i--
+	}
+	if i >= 0 && i < len(w.runes)-1 && (w.runes[i] == 'é' || w.runes[i] == 'è') {
+		w.runes[i] = 'e'
+	}
+
+	return strings.NewReplacer("I", "i", "U", "u", "Y", "y").Replace(w.String())
+}
+
+func frenchRV(w *snowballWord) int {
+	r := w.runes
+	if len(r) >= 2 && w.isVowel(r[0]) && w.isVowel(r[1]) {
+		return min(3, len(r))
+	}
+	if prefix := string(r[:min(3, len(r))]); prefix == "par" || prefix == "col" || prefix == "tap" {
+		return 3
+	}
+	for i := 1; i < len(r); i++ {
+		if w.isVowel(r[i]) {
+			return i + 1
+		}
+	}
+	return len(r)
+}
+
+func frenchStandardSuffix(w *snowballWord) bool {
+	suffix := w.longestSuffix(
+		"ance", "iqUe", "isme", "able", "iste", "eux", "ances", "iqUes", "ismes", "ables", "istes",
+		"atrice", "ateur", "ation", "atrices", "ateurs", "ations", "logie", "logies",
+		"usion", "ution", "usions", "utions", "ence", "ences", "ement", "ements", "ité", "ités",
+		"if", "ive", "ifs", "ives", "eaux", "aux", "euse", "euses", "issement", "issements",
+		"amment", "emment", "ment", "ments",
+	)
+
+	switch suffix {
+	case "":
+		return false
+	case "ance", "iqUe", "isme", "able", "iste", "eux", "ances", "iqUes", "ismes", "ables", "istes":
+		if !w.inR2(suffix) {
+			return false
+		}
+		w.trim(suffix)
+	case "atrice", "ateur", "ation", "atrices", "ateurs", "ations":
+		if !w.inR2(suffix) {
+			return false
+		}
+		w.trim(suffix)
+		frenchTrimOrReplace(w, "ic", "iqU")
+	case "logie", "logies":
+		if !w.inR2(suffix) {
+			return false
+		}
+		w.replace(suffix, "log")
+	case "usion", "ution", "usions", "utions":
+		if !w.inR2(suffix) {
+			return false
+		}
+		w.replace(suffix, "u")
+	case "ence", "ences":
+		if !w.inR2(suffix) {
+			return false
+		}
+		w.replace(suffix, "ent")
+	case "ement", "ements":
+		if !w.inRV(suffix) {
+			return false
+		}
+		w.trim(suffix)
+		switch rest := w.longestSuffix("iv", "eus", "abl", "iqU", "ièr", "Ièr"); rest {
+		case "iv":
+			if w.inR2(rest) {
+				w.trim(rest)
+				w.trimInR2("at")
+			}
+		case "eus":
+			if w.inR2(rest) {
+				w.trim(rest)
+			} else if w.inR1(rest) {
+				w.replace(rest, "eux")
+			}
+		case "abl", "iqU":
+			w.trimInR2(rest)
+		case "ièr", "Ièr":
+			if w.inRV(rest) {
+				w.replace(rest, "i")
+			}
+		}
+	case "ité", "ités":
+		if !w.inR2(suffix) {
+			return false
+		}
+		w.trim(suffix)
+		switch rest := w.longestSuffix("abil", "ic", "iv"); rest {
+		case "abil":
+			frenchTrimOrReplace(w, rest, "abl")
+		case "ic":
+			frenchTrimOrReplace(w, rest, "iqU")
+		case "iv":
+			w.trimInR2(rest)
+		}
+	case "if", "ive", "ifs", "ives":
+		if !w.inR2(suffix) {
+			return false
+		}
+		w.trim(suffix)
+		if w.hasSuffix("at") && w.inR2("at") {
+			w.trim("at")
+			frenchTrimOrReplace(w, "ic", "iqU")
+		}
+	case "eaux":
+		w.replace(suffix, "eau")
+	case "aux":
+		if !w.inR1(suffix) {
+			return false
+		}
+		w.replace(suffix, "al")
+	case "euse", "euses":
+		if w.inR2(suffix) {
+			w.trim(suffix)
+		} else if w.inR1(suffix) {
+			w.replace(suffix, "eux")
+		} else {
+			return false
+		}
+	case "issement", "issements":
+		if !w.inR1(suffix) || w.precededByAny(suffix, strings.Split(frenchVowels, "")...) {
+			return false
+		}
+		w.trim(suffix)
+	case "amment":
+		if w.inRV(suffix) {
+			w.replace(suffix, "ant")
+		}
+		return false
+	case "emment":
+		if w.inRV(suffix) {
+			w.replace(suffix, "ent")
+		}
+		return false
+	case "ment", "ments":
+		if start := w.suffixStart(suffix); start-1 >= w.rv && w.isVowel(w.runes[start-1]) {
+			w.trim(suffix)
+		}
+		return false
+	}
+	return true
+}
+
+func frenchTrimOrReplace(w *snowballWord, suffix, with string) {
+	if !w.hasSuffix(suffix) {
+		return
+	}
+	if w.inR2(suffix) {
+		w.trim(suffix)
+	} else {
+		w.replace(suffix, with)
+	}
+}
+
+func frenchIVerbSuffix(w *snowballWord) bool {
+	suffix := w.longestSuffix(
+		"îmes", "ît", "îtes", "i", "ie", "ies", "ir", "ira", "irai", "iraIent", "irais", "irait", "iras",
+		"irent", "irez", "iriez", "irions", "irons", "iront", "is", "issaIent", "issais", "issait",
+		"issant", "issante", "issantes", "issants", "isse", "issent", "isses", "issez", "issiez",
+		"issions", "issons", "it",
+	)
+	if suffix == "" || !w.inRV(suffix) {
+		return false
+	}
+	start := w.suffixStart(suffix)
+	if start-1 < w.rv || w.isVowel(w.runes[start-1]) {
+		return false
+	}
+	w.trim(suffix)
+	return true
+}
+
+func frenchVerbSuffix(w *snowballWord) bool {
+	suffix := w.longestSuffix(
+		"ions", "é", "ée", "ées", "és", "èrent", "er", "era", "erai", "eraIent", "erais", "erait",
+		"eras", "erez", "eriez", "erions", "erons", "eront", "ez", "iez",
+		"âmes", "ât", "âtes", "a", "ai", "aIent", "ais", "ait", "ant", "ante", "antes", "ants", "as",
+		"asse", "assent", "asses", "assiez", "assions",
+	)
+	if suffix == "" || !w.inRV(suffix) {
+		return false
+	}
+
+	switch suffix {
+	case "ions":
+		if !w.inR2(suffix) {
+			return false
+		}
+		w.trim(suffix)
+	case "âmes", "ât", "âtes", "a", "ai", "aIent", "ais", "ait", "ant", "ante", "antes", "ants", "as",
+		"asse", "assent", "asses", "assiez", "assions":
+		w.trim(suffix)
+		if w.hasSuffix("e") && w.inRV("e") {
+			w.trim("e")
+		}
+	default:
+		w.trim(suffix)
+	}
+	return true
+}
+
+func frenchResidualSuffix(w *snowballWord) {
+	if w.hasSuffix("s") && !w.precededByAny("s", "a", "i", "o", "u", "è", "s") {
+		w.trim("s")
+	}
+
+	suffix := w.longestSuffix("ion", "ier", "ière", "Ier", "Ière", "e", "ë")
+	if suffix == "" || !w.inRV(suffix) {
+		return
+	}
+	switch suffix {
+	case "ion":
+		if start := w.suffixStart(suffix); w.inR2(suffix) && start-1 >= w.rv && w.precededByAny(suffix, "s", "t") {
+			w.trim(suffix)
+		}
+	case "ier", "ière", "Ier", "Ière":
+		w.replace(suffix, "i")
+	case "e":
+		w.trim(suffix)
+	case "ë":
+		if w.precededBy(suffix, "gu") {
+			w.trim(suffix)
+		}
+	}
+}
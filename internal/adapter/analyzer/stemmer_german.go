@@ -0,0 +1,102 @@
+package analyzer
+
+import "strings"
+
+type GermanStemmer struct{}
+
+func NewGermanStemmer() *GermanStemmer {
+	return &GermanStemmer{}
+}
+
+const germanVowels = "aeiouyäöü"
+
+func (s *GermanStemmer) Stem(word string) string {
+	word = strings.ReplaceAll(strings.ToLower(word), "ß", "ss")
+
+	runes := []rune(word)
+	for i := 1; i+1 < len(runes); i++ {
+		if !strings.ContainsRune(germanVowels, runes[i-1]) || !strings.ContainsRune(germanVowels, runes[i+1]) {
+			continue
+		}
+		switch runes[i] {
+		case 'u':
+			runes[i] = 'U'
+		case 'y':
+			runes[i] = 'Y'
+		}
+	}
+
+	w := newSnowballWord(string(runes), germanVowels)
+	if w.r1 < 3 {
+		w.r1 = min(3, len(w.runes))
+	}
+
+	germanStep1(w)
+	germanStep2(w)
+	germanStep3(w)
+
+	return strings.NewReplacer("U", "u", "Y", "y", "ä", "a", "ö", "o", "ü", "u").Replace(w.String())
+}
+
+func germanStep1(w *snowballWord) {
+	suffix := w.longestSuffix("em", "ern", "er", "e", "en", "es", "s")
+	if suffix == "" || !w.inR1(suffix) {
+		return
+	}
+	switch suffix {
+	case "em", "ern", "er":
+		w.trim(suffix)
+	case "e", "en", "es":
+		w.trim(suffix)
+		if w.hasSuffix("niss") {
+			w.trim("s")
+		}
+	case "s":
+		if w.precededByAny(suffix, "b", "d", "f", "g", "h", "k", "l", "m", "n", "r", "t") {
+			w.trim(suffix)
+		}
+	}
+}
+
+func germanStep2(w *snowballWord) {
+	suffix := w.longestSuffix("en", "er", "est", "st")
+	if suffix == "" || !w.inR1(suffix) {
+		return
+	}
+	switch suffix {
+	case "en", "er", "est":
+		w.trim(suffix)
+	case "st":
+		if w.suffixStart(suffix) >= 4 && w.precededByAny(suffix, "b", "d", "f", "g", "h", "k", "l", "m", "n", "t") {
+			w.trim(suffix)
+		}
+	}
+}
+
+func germanStep3(w *snowballWord) {
+	suffix := w.longestSuffix("end", "ung", "ig", "ik", "isch", "lich", "heit", "keit")
+	if suffix == "" || !w.inR2(suffix) {
+		return
+	}
+	switch suffix {
+	case "end", "ung":
+		w.trim(suffix)
+		if w.hasSuffix("ig") && w.inR2("ig") && !w.precededBy("ig", "e") {
+			w.trim("ig")
+		}
+	case "ig", "ik", "isch":
+		if !w.precededBy(suffix, "e") {
+			w.trim(suffix)
+		}
+	case "lich", "heit":
+		w.trim(suffix)
+		if rest := w.longestSuffix("er", "en"); rest != "" && w.inR1(rest) {
+			w.trim(rest)
+		}
+	case "keit":
+		w.trim(suffix)
+		if rest := w.longestSuffix("lich", "ig"); rest != "" && w.inR2(rest) {
+			w.trim(rest)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package analyzer
+
+import "strings"
+
+type RussianStemmer struct{}
+
+func NewRussianStemmer() *RussianStemmer {
+	return &RussianStemmer{}
+}
+
+const russianVowels = "аеиоуыэюя"
+
+var (
+	russianGerund1     = []string{"в", "вши", "вшись"}
+	russianGerund2     = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+	russianAdjective   = []string{"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом", "его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+	russianParticiple1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	russianParticiple2 = []string{"ивш", "ывш", "ующ"}
+	russianVerb1       = []string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"}
+	russianVerb2       = []string{"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю"}
+	russianNoun        = []string{"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й", "иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я"}
+)
+
+func (s *RussianStemmer) Stem(word string) string {
+	w := newSnowballWord(strings.ReplaceAll(strings.ToLower(word), "ё", "е"), russianVowels)
+	w.rv = len(w.runes)
+	for i, r := range w.runes {
+		if w.isVowel(r) {
+			w.rv = i + 1
+			break
+		}
+	}
+
+	if !russianRemoveGrouped(w, russianGerund1, russianGerund2) {
+		russianRemove(w, "ся", "сь")
+		if russianRemove(w, russianAdjective...) {
+			russianRemoveGrouped(w, russianParticiple1, russianParticiple2)
+		} else if !russianRemoveGrouped(w, russianVerb1, russianVerb2) {
+			russianRemove(w, russianNoun...)
+		}
+	}
+
+	russianRemove(w, "и")
+
+	if suffix := w.longestSuffix("ост", "ость"); suffix != "" && w.inR2(suffix) {
+		w.trim(suffix)
+	}
+
+	switch suffix := w.longestSuffix("ейш", "ейше", "нн", "ь"); suffix {
+	case "ейш", "ейше":
+		if w.inRV(suffix) {
+			w.trim(suffix)
+			if w.hasSuffix("нн") && w.inRV("нн") {
+				w.trim("н")
+			}
+		}
+	case "нн":
+		if w.inRV(suffix) {
+			w.trim("н")
+		}
+	case "ь":
+		if w.inRV(suffix) {
+			w.trim(suffix)
+		}
+	}
+
+	return w.String()
+}
+
+func russianRemove(w *snowballWord, suffixes ...string) bool {
+	suffix := w.longestSuffix(suffixes...)
+	if suffix == "" || !w.inRV(suffix) {
+		return false
+	}
+	w.trim(suffix)
+	return true
+}
+
+// First-group endings only count after а or я inside RV.
+func russianRemoveGrouped(w *snowballWord, group1, group2 []string) bool {
+	suffix := w.longestSuffix(append(append([]string{}, group1...), group2...)...)
+	if suffix == "" || !w.inRV(suffix) {
+		return false
+	}
+	for _, g := range group1 {
+		if g == suffix && (w.suffixStart(suffix)-1 < w.rv || !w.precededByAny(suffix, "а", "я")) {
+			return false
+		}
+	}
+	w.trim(suffix)
+	return true
+}
@@ -0,0 +1,155 @@
+package analyzer
+
+import "strings"
+
+type SpanishStemmer struct{}
+
+func NewSpanishStemmer() *SpanishStemmer {
+	return &SpanishStemmer{}
+}
+
+const spanishVowels = "aeiouáéíóúü"
+
+var spanishVerbSuffixes = []string{
+	"arían", "arías", "arán", "arás", "aríais", "aría", "aréis", "aríamos", "aremos", "ará", "aré",
+	"erían", "erías", "erán", "erás", "eríais", "ería", "eréis", "eríamos", "eremos", "erá", "eré",
+	"irían", "irías", "irán", "irás", "iríais", "iría", "iréis", "iríamos", "iremos", "irá", "iré",
+	"aba", "ada", "ida", "ía", "ara", "iera", "ad", "ed", "id", "ase", "iese", "aste", "iste", "an",
+	"aban", "ían", "aran", "ieran", "asen", "iesen", "aron", "ieron", "ado", "ido", "ando", "iendo",
+	"ió", "ar", "er", "ir", "as", "abas", "adas", "idas", "ías", "aras", "ieras", "ases", "ieses",
+	"ís", "áis", "abais", "íais", "arais", "ierais", "aseis", "ieseis", "asteis", "isteis", "ados",
+	"idos", "amos", "ábamos", "íamos", "imos", "áramos", "iéramos", "iésemos", "ásemos",
+	"en", "es", "éis", "emos",
+}
+
+func (s *SpanishStemmer) Stem(word string) string {
+	w := newSnowballWord(strings.ToLower(word), spanishVowels)
+	w.rv = w.romanceRV()
+
+	spanishAttachedPronoun(w)
+	if !spanishStandardSuffix(w) && !spanishYVerbSuffix(w) {
+		spanishVerbSuffix(w)
+	}
+	spanishResidualSuffix(w)
+
+	return strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u").Replace(w.String())
+}
+
+func spanishAttachedPronoun(w *snowballWord) {
+	pronoun := w.longestSuffix("me", "se", "sela", "selo", "selas", "selos", "la", "le", "lo", "las", "les", "los", "nos")
+	if pronoun == "" {
+		return
+	}
+
+	stem := &snowballWord{runes: w.runes[:w.suffixStart(pronoun)], isVowel: w.isVowel}
+	ending := stem.longestSuffix("iéndo", "ándo", "ár", "ér", "ír", "ando", "iendo", "ar", "er", "ir", "yendo")
+	if ending == "" || stem.suffixStart(ending) < w.rv {
+		return
+	}
+	if ending == "yendo" && !stem.precededBy(ending, "u") {
+		return
+	}
+
+	w.trim(pronoun)
+	switch ending {
+	case "iéndo":
+		w.replace(ending, "iendo")
+	case "ándo":
+		w.replace(ending, "ando")
+	case "ár", "ér", "ír":
+		w.replace(ending, strings.NewReplacer("á", "a", "é", "e", "í", "i").Replace(ending))
+	}
+}
+
+func spanishStandardSuffix(w *snowballWord) bool {
+	suffix := w.longestSuffix(
+		"anza", "anzas", "ico", "ica", "icos", "icas", "ismo", "ismos", "able", "ables", "ible", "ibles",
+		"ista", "istas", "oso", "osa", "osos", "osas", "amiento", "amientos", "imiento", "imientos",
+		"adora", "ador", "ación", "adoras", "adores", "aciones", "ante", "antes", "ancia", "ancias",
+		"logía", "logías", "ución", "uciones", "encia", "encias", "amente", "mente",
+		"idad", "idades", "iva", "ivo", "ivas", "ivos",
+	)
+	if suffix == "" {
+		return false
+	}
+
+	switch suffix {
+	case "amente":
+		if !w.inR1(suffix) {
+			return false
+		}
+		w.trim(suffix)
+		if rest := w.longestSuffix("iv", "os", "ic", "ad"); rest != "" && w.inR2(rest) {
+			w.trim(rest)
+			if rest == "iv" && w.hasSuffix("at") && w.inR2("at") {
+				w.trim("at")
+			}
+		}
+		return true
+	}
+
+	if !w.inR2(suffix) {
+		return false
+	}
+	switch suffix {
+	case "adora", "ador", "ación", "adoras", "adores", "aciones", "ante", "antes", "ancia", "ancias":
+		w.trim(suffix)
+		w.trimInR2("ic")
+	case "logía", "logías":
+		w.replace(suffix, "log")
+	case "ución", "uciones":
+		w.replace(suffix, "u")
+	case "encia", "encias":
+		w.replace(suffix, "ente")
+	case "mente":
+		w.trim(suffix)
+		if rest := w.longestSuffix("ante", "able", "ible"); rest != "" {
+			w.trimInR2(rest)
+		}
+	case "idad", "idades":
+		w.trim(suffix)
+		if rest := w.longestSuffix("abil", "ic", "iv"); rest != "" {
+			w.trimInR2(rest)
+		}
+	case "iva", "ivo", "ivas", "ivos":
+		w.trim(suffix)
+		w.trimInR2("at")
+	default:
+		w.trim(suffix)
+	}
+	return true
+}
+
+func spanishYVerbSuffix(w *snowballWord) bool {
+	suffix := w.longestSuffix("ya", "ye", "yan", "yen", "yeron", "yendo", "yo", "yó", "yas", "yes", "yais", "yamos")
+	if suffix == "" || !w.inRV(suffix) || !w.precededBy(suffix, "u") {
+		return false
+	}
+	w.trim(suffix)
+	return true
+}
+
+func spanishVerbSuffix(w *snowballWord) {
+	suffix := w.longestSuffix(spanishVerbSuffixes...)
+	if suffix == "" || !w.inRV(suffix) {
+		return
+	}
+	w.trim(suffix)
+	switch suffix {
+	case "en", "es", "éis", "emos":
+		if w.hasSuffix("gu") {
+			w.trim("u")
+		}
+	}
+}
+
+func spanishResidualSuffix(w *snowballWord) {
+	suffix := w.longestSuffix("os", "a", "o", "á", "í", "ó", "e", "é")
+	if suffix == "" || !w.inRV(suffix) {
+		return
+	}
+	w.trim(suffix)
+	if (suffix == "e" || suffix == "é") && w.hasSuffix("gu") && w.inRV("u") {
+		w.trim("u")
+	}
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import "testing"
+
+func TestSnowballStemmers(t *testing.T) {
+	tests := []struct {
+		stemmer Stemmer
+		input   string
+		want    string
+	}{
+		{NewGermanStemmer(), "aufeinanderfolgenden", "aufeinanderfolg"},
+		{NewGermanStemmer(), "häuser", "haus"},
+		{NewGermanStemmer(), "katzen", "katz"},
+		{NewGermanStemmer(), "abgeschlossenen", "abgeschloss"},
+		{NewGermanStemmer(), "möglichkeiten", "moglich"},
+		{NewGermanStemmer(), "verbindungen", "verbind"},
+		{NewGermanStemmer(), "straße", "strass"},
+		{NewSpanishStemmer(), "abandonada", "abandon"},
+		{NewSpanishStemmer(), "acción", "accion"},
+		{NewSpanishStemmer(), "aceptación", "acept"},
+		{NewSpanishStemmer(), "actualmente", "actual"},
+		{NewSpanishStemmer(), "corriendo", "corr"},
+		{NewSpanishStemmer(), "conexiones", "conexion"},
+		{NewFrenchStemmer(), "continuation", "continu"},
+		{NewFrenchStemmer(), "continuelle", "continuel"},
+		{NewFrenchStemmer(), "abandonné", "abandon"},
+		{NewFrenchStemmer(), "acceptation", "accept"},
+		{NewFrenchStemmer(), "généralement", "général"},
+		{NewFrenchStemmer(), "connexions", "connex"},
+		{NewRussianStemmer(), "вавилонской", "вавилонск"},
+		{NewRussianStemmer(), "важнейшие", "важн"},
+		{NewRussianStemmer(), "бегущий", "бегущ"},
+		{NewRussianStemmer(), "авиации", "авиац"},
+		{NewRussianStemmer(), "абсолютно", "абсолютн"},
+		{NewRussianStemmer(), "подключения", "подключен"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.stemmer.Stem(tt.input); got != tt.want {
+			t.Errorf("%T.Stem(%q) = %q, want %q", tt.stemmer, tt.input, got, tt.want)
+		}
+	}
+}
@@ -28,6 +28,16 @@ func (e *SymbolExtractor) ExtractSymbols(docID, content, lang string) ([]domain.
 	}
 }
 
+// Callees outside the file keep their call-site name.
+func (e *SymbolExtractor) ExtractCalls(docID, content, lang string, symbols []domain.Symbol) ([]domain.CallGraphEntry, error) {
+	if lang != "go" {
+		return nil, nil
+	}
+	builder := NewCallGraphBuilder()
+	builder.RegisterSymbols(symbols)
+	return builder.BuildCallGraph(docID, content)
+}
+
 func (e *SymbolExtractor) extractGoSymbols(docID, content string) ([]domain.Symbol, error) {
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
@@ -1,49 +1,192 @@
 package analyzer
 
 import (
+	"sort"
 	"strings"
 	"unicode"
+
+	"rag/internal/domain"
 )
 
 type Tokenizer struct {
-	stemmer   *PorterStemmer
-	stopwords map[string]struct{}
-	useStem   bool
+	analyzers        map[string]*languageAnalyzer
+	language         string
+	queryLanguages   []string
+	splitIdentifiers bool
+	splitLanguages   map[string]bool
+}
+
+type TokenizerOptions struct {
+	Stemming         bool
+	Language         string
+	QueryLanguages   []string
+	SplitIdentifiers bool
+	SplitLanguages   map[string]bool
+}
+
+var kebabLanguages = map[string]bool{
+	"css":   true,
+	"html":  true,
+	"xml":   true,
+	"yaml":  true,
+	"shell": true,
 }
 
 func NewTokenizer(useStemming bool) *Tokenizer {
-	var stemmer *PorterStemmer
-	if useStemming {
-		stemmer = NewPorterStemmer()
+	return NewTokenizerWithOptions(TokenizerOptions{Stemming: useStemming, SplitIdentifiers: true})
+}
+
+func NewTokenizerWithOptions(opts TokenizerOptions) *Tokenizer {
+	language, err := NormalizeLanguage(opts.Language)
+	if err != nil {
+		language = LanguageAuto
+	}
+	analyzers := make(map[string]*languageAnalyzer, len(stopwordSets))
+	for lang, stopwords := range stopwordSets {
+		a := &languageAnalyzer{stopwords: stopwords}
+		if opts.Stemming {
+			a.stemmer = newStemmer(lang)
+		}
+		analyzers[lang] = a
 	}
 	return &Tokenizer{
-		stemmer:   stemmer,
-		stopwords: defaultStopwords(),
-		useStem:   useStemming,
+		analyzers:        analyzers,
+		language:         language,
+		queryLanguages:   queryLanguages(language, opts.QueryLanguages),
+		splitIdentifiers: opts.SplitIdentifiers,
+		splitLanguages:   opts.SplitLanguages,
+	}
+}
+
+// queryLanguages puts the configured language, or English, before the other indexed ones.
+func queryLanguages(configured string, indexed []string) []string {
+	primary := configured
+	if primary == LanguageAuto {
+		primary = LanguageEnglish
+	}
+	langs := []string{primary}
+	var rest []string
+	for _, lang := range indexed {
+		if _, supported := languageStopwords[lang]; supported && lang != primary {
+			rest = append(rest, lang)
+		}
+	}
+	sort.Strings(rest)
+	for i, lang := range rest {
+		if i == 0 || lang != rest[i-1] {
+			langs = append(langs, lang)
+		}
 	}
+	return langs
 }
 
+// Tokenize analyzes queries and paths with the primary query language.
 func (t *Tokenizer) Tokenize(text string) []string {
-	words := splitWords(text)
-	tokens := make([]string, 0, len(words))
+	return t.tokenize(text, "", t.queryLanguages[0])
+}
 
-	for _, word := range words {
-		word = strings.ToLower(word)
-		if len(word) < 2 {
-			continue
+// TokenizeVariants returns the distinct analyses of text in every query language, primary first.
+func (t *Tokenizer) TokenizeVariants(text string) [][]string {
+	variants := make([][]string, 0, len(t.queryLanguages))
+	seen := make(map[string]bool, len(t.queryLanguages))
+	for _, lang := range t.queryLanguages {
+		tokens := t.tokenize(text, "", lang)
+		if key := strings.Join(tokens, "\x00"); !seen[key] {
+			seen[key] = true
+			variants = append(variants, tokens)
 		}
-		if _, isStop := t.stopwords[word]; isStop {
-			continue
+	}
+	return variants
+}
+
+// DocumentLanguage picks the language a file is analyzed with, once per file.
+func (t *Tokenizer) DocumentLanguage(lang, content string) string {
+	if !proseLanguages[lang] {
+		return LanguageEnglish
+	}
+	if t.language != LanguageAuto {
+		return t.language
+	}
+	return DetectTextLanguage(content)
+}
+
+func (t *Tokenizer) TokenizeLang(text, lang string) []string {
+	return t.TokenizeDoc(text, domain.Document{Lang: lang})
+}
+
+// TokenizeDoc analyzes code in English and prose in the document's language.
+func (t *Tokenizer) TokenizeDoc(text string, doc domain.Document) []string {
+	textLang := LanguageEnglish
+	if proseLanguages[doc.Lang] {
+		switch {
+		case t.language != LanguageAuto:
+			textLang = t.language
+		case doc.TextLang != "":
+			textLang = doc.TextLang
 		}
-		if t.useStem && t.stemmer != nil {
-			word = t.stemmer.Stem(word)
+	}
+	return t.tokenize(text, doc.Lang, textLang)
+}
+
+// Cyrillic prose words use the Russian analyzer and CJK runs become character bigrams.
+func (t *Tokenizer) tokenize(text, lang, textLang string) []string {
+	split := t.splitIdentifiers
+	if override, exists := t.splitLanguages[lang]; exists {
+		split = override
+	}
+
+	var words []string
+	if split && kebabLanguages[lang] {
+		words = scanWords(text, true)
+	} else {
+		words = splitWords(text)
+	}
+	tokens := make([]string, 0, len(words))
+	analyzer, ok := t.analyzers[textLang]
+	if !ok {
+		analyzer = t.analyzers[LanguageEnglish]
+	}
+	prose := lang == "" || proseLanguages[lang]
+
+	for _, word := range words {
+		for _, segment := range splitCJK(word) {
+			if isCJK([]rune(segment)[0]) {
+				tokens = append(tokens, cjkBigrams([]rune(segment))...)
+				continue
+			}
+
+			a := analyzer
+			if prose && isCyrillic(segment) {
+				a = t.analyzers[LanguageRussian]
+			}
+			if split {
+				if parts := splitIdentifier(segment); len(parts) > 1 {
+					for _, part := range parts {
+						tokens = a.appendToken(tokens, part)
+					}
+				}
+			}
+			tokens = a.appendToken(tokens, segment)
 		}
-		tokens = append(tokens, word)
 	}
 
 	return tokens
 }
 
+func (a *languageAnalyzer) appendToken(tokens []string, word string) []string {
+	word = strings.ToLower(word)
+	if len(word) < 2 {
+		return tokens
+	}
+	if _, isStop := a.stopwords[word]; isStop {
+		return tokens
+	}
+	if a.stemmer != nil {
+		word = a.stemmer.Stem(word)
+	}
+	return append(tokens, word)
+}
+
 func (t *Tokenizer) CountTokens(text string) int {
 
 	words := splitWords(text)
@@ -55,11 +198,18 @@ func (t *Tokenizer) CountTokens(text string) int {
 }
 
 func splitWords(text string) []string {
+	return scanWords(text, false)
+}
+
+func scanWords(text string, hyphenated bool) []string {
 	var words []string
 	var current strings.Builder
 
-	for _, r := range text {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+	runes := []rune(text)
+	for i, r := range runes {
+		if isWordRune(r) {
+			current.WriteRune(r)
+		} else if hyphenated && r == '-' && current.Len() > 0 && i+1 < len(runes) && isWordRune(runes[i+1]) {
 			current.WriteRune(r)
 		} else {
 			if current.Len() > 0 {
@@ -75,22 +225,41 @@ func splitWords(text string) []string {
 	return words
 }
 
-func defaultStopwords() map[string]struct{} {
-	stops := []string{
-		"a", "an", "and", "are", "as", "at", "be", "by", "for",
-		"from", "has", "he", "in", "is", "it", "its", "of", "on",
-		"that", "the", "to", "was", "were", "will", "with", "this",
-		"have", "had", "but", "not", "you", "your", "we", "our",
-		"they", "their", "she", "her", "his", "if", "or", "so",
-		"no", "can", "do", "does", "did", "been", "being", "would",
-		"could", "should", "may", "might", "must", "shall", "which",
-		"who", "whom", "what", "when", "where", "why", "how", "all",
-		"each", "every", "both", "few", "more", "most", "other",
-		"some", "such", "than", "too", "very", "just", "also",
-	}
-	m := make(map[string]struct{}, len(stops))
-	for _, s := range stops {
-		m[s] = struct{}{}
-	}
-	return m
+func splitCJK(word string) []string {
+	var segments []string
+	runes := []rune(word)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || isCJK(runes[i]) != isCJK(runes[start]) {
+			segments = append(segments, string(runes[start:i]))
+			start = i
+		}
+	}
+	return segments
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func splitIdentifier(word string) []string {
+	var parts []string
+	for _, segment := range strings.FieldsFunc(word, func(r rune) bool {
+		return r == '_' || r == '-'
+	}) {
+		runes := []rune(segment)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			boundary := unicode.IsLower(prev) && unicode.IsUpper(cur) ||
+				unicode.IsDigit(prev) != unicode.IsDigit(cur) ||
+				unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if boundary {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		parts = append(parts, string(runes[start:]))
+	}
+	return parts
 }
@@ -1,7 +1,10 @@
 package analyzer
 
 import (
+	"reflect"
 	"testing"
+
+	"rag/internal/domain"
 )
 
 func TestTokenizer_Tokenize_WithStemming(t *testing.T) {
@@ -111,3 +114,133 @@ func TestSplitWords(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"getUserByID", []string{"get", "User", "By", "ID"}},
+		{"UserRepository", []string{"User", "Repository"}},
+		{"user_repository", []string{"user", "repository"}},
+		{"user-repository", []string{"user", "repository"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"parseJSON2XML", []string{"parse", "JSON", "2", "XML"}},
+		{"base64Encode", []string{"base", "64", "Encode"}},
+		{"__init__", []string{"init"}},
+		{"plain", []string{"plain"}},
+	}
+
+	for _, tt := range tests {
+		if parts := splitIdentifier(tt.input); !reflect.DeepEqual(parts, tt.expected) {
+			t.Errorf("splitIdentifier(%q) = %v, want %v", tt.input, parts, tt.expected)
+		}
+	}
+}
+
+func TestTokenizer_IdentifierSplitting(t *testing.T) {
+	tok := NewTokenizer(false)
+
+	tests := []struct {
+		input    string
+		lang     string
+		expected []string
+	}{
+		{"UserRepository", "go", []string{"user", "repository", "userrepository"}},
+		{"getUserByID", "", []string{"get", "user", "id", "getuserbyid"}},
+		{"user_repository", "python", []string{"user", "repository", "user_repository"}},
+		{"HTTPServer", "go", []string{"http", "server", "httpserver"}},
+		{"sha256sum", "", []string{"sha", "256", "sum", "sha256sum"}},
+		{".nav-bar-item", "css", []string{"nav", "bar", "item", "nav-bar-item"}},
+		{"nav-bar", "go", []string{"nav", "bar"}},
+	}
+
+	for _, tt := range tests {
+		if tokens := tok.TokenizeLang(tt.input, tt.lang); !reflect.DeepEqual(tokens, tt.expected) {
+			t.Errorf("TokenizeLang(%q, %q) = %v, want %v", tt.input, tt.lang, tokens, tt.expected)
+		}
+	}
+
+	query := tok.Tokenize("user repository")
+	indexed := tok.TokenizeLang("type UserRepository struct", "go")
+	for _, q := range query {
+		found := false
+		for _, token := range indexed {
+			found = found || token == q
+		}
+		if !found {
+			t.Errorf("expected query token %q in indexed tokens %v", q, indexed)
+		}
+	}
+}
+
+func TestTokenizer_IdentifierSplittingPerLanguage(t *testing.T) {
+	tok := NewTokenizerWithOptions(TokenizerOptions{
+		SplitIdentifiers: true,
+		SplitLanguages:   map[string]bool{"markdown": false},
+	})
+	if tokens := tok.TokenizeLang("UserRepository", "markdown"); !reflect.DeepEqual(tokens, []string{"userrepository"}) {
+		t.Errorf("expected splitting disabled for markdown, got %v", tokens)
+	}
+	if tokens := tok.TokenizeLang("UserRepository", "go"); len(tokens) != 3 {
+		t.Errorf("expected splitting enabled for go, got %v", tokens)
+	}
+
+	tok = NewTokenizerWithOptions(TokenizerOptions{
+		SplitLanguages: map[string]bool{"java": true},
+	})
+	if tokens := tok.Tokenize("UserRepository"); len(tokens) != 1 {
+		t.Errorf("expected splitting disabled by default, got %v", tokens)
+	}
+	if tokens := tok.TokenizeLang("UserRepository", "java"); len(tokens) != 3 {
+		t.Errorf("expected splitting enabled for java, got %v", tokens)
+	}
+}
+
+func TestTokenizer_Languages(t *testing.T) {
+	german := NewTokenizerWithOptions(TokenizerOptions{Stemming: true, Language: "de"})
+	if tokens := german.Tokenize("die Verbindungen der Häuser"); !reflect.DeepEqual(tokens, []string{"verbind", "haus"}) {
+		t.Errorf("expected German stopwords and stems, got %v", tokens)
+	}
+
+	if tokens := german.TokenizeLang("func con(de string) { el := la }", "go"); !reflect.DeepEqual(tokens, []string{"func", "con", "de", "string", "el", "la"}) {
+		t.Errorf("expected code to keep English analysis, got %v", tokens)
+	}
+
+	auto := NewTokenizer(true)
+	text := "die Verbindungen für das Häuser"
+	if lang := auto.DocumentLanguage("markdown", text); lang != LanguageGerman {
+		t.Errorf("expected German to be detected for markdown, got %q", lang)
+	}
+	if lang := auto.DocumentLanguage("go", text); lang != LanguageEnglish {
+		t.Errorf("expected code to be analyzed in English, got %q", lang)
+	}
+	if tokens := auto.TokenizeDoc(text, domain.Document{Lang: "markdown", TextLang: LanguageGerman}); !reflect.DeepEqual(tokens, []string{"verbind", "haus"}) {
+		t.Errorf("expected the document language to be used, got %v", tokens)
+	}
+	if tokens := auto.Tokenize(text); reflect.DeepEqual(tokens, []string{"verbind", "haus"}) {
+		t.Errorf("expected queries not to guess their language, got %v", tokens)
+	}
+
+	multi := NewTokenizerWithOptions(TokenizerOptions{Stemming: true, Language: LanguageAuto, QueryLanguages: []string{LanguageGerman, LanguageEnglish}})
+	variants := multi.TokenizeVariants("Verbindungen")
+	if len(variants) != 2 || !reflect.DeepEqual(variants[1], []string{"verbind"}) {
+		t.Errorf("expected English and German query variants, got %v", variants)
+	}
+	if tokens := auto.Tokenize("running подключения"); !reflect.DeepEqual(tokens, []string{"run", "подключен"}) {
+		t.Errorf("expected Cyrillic words to use the Russian stemmer, got %v", tokens)
+	}
+	if tokens := auto.Tokenize("日本語のテキスト"); !reflect.DeepEqual(tokens, []string{"日本", "本語", "語の", "のテ", "テキ", "キス", "スト"}) {
+		t.Errorf("expected CJK bigrams, got %v", tokens)
+	}
+	if tokens := auto.Tokenize("parse日本"); !reflect.DeepEqual(tokens, []string{"pars", "日本"}) {
+		t.Errorf("expected mixed script word to be segmented, got %v", tokens)
+	}
+
+	if _, err := NormalizeLanguage("klingon"); err == nil {
+		t.Error("expected error for unsupported language")
+	}
+	if lang, err := NormalizeLanguage("FR"); err != nil || lang != LanguageFrench {
+		t.Errorf("NormalizeLanguage(FR) = %q, %v", lang, err)
+	}
+}
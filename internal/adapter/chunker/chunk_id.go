@@ -0,0 +1,37 @@
+package chunker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"rag/internal/domain"
+)
+
+func generateContentChunkID(docID, unitKey, text string) string {
+	data := fmt.Sprintf("%s:%s:%s", docID, unitKey, normalizeChunkContent(text))
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:8])
+}
+
+func normalizeChunkContent(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func dedupeChunkIDs(chunks []domain.Chunk) {
+	seen := make(map[string]int, len(chunks))
+	for i := range chunks {
+		id := chunks[i].ID
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			hash := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", id, n)))
+			chunks[i].ID = hex.EncodeToString(hash[:8])
+		}
+	}
+}
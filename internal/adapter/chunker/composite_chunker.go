@@ -1,10 +1,6 @@
 package chunker
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
-
 	"rag/internal/adapter/analyzer"
 	"rag/internal/domain"
 )
@@ -76,11 +72,12 @@ func (c *CompositeChunker) unitsToChunks(doc domain.Document, units []CodeUnit,
 		}
 	}
 
+	dedupeChunkIDs(chunks)
 	return chunks, nil
 }
 
 func (c *CompositeChunker) createChunk(doc domain.Document, unit CodeUnit) domain.Chunk {
-	tokens := c.tokenizer.Tokenize(unit.Content)
+	tokens := c.tokenizer.TokenizeDoc(unit.Content, doc)
 
 	text := unit.Content
 	if unit.DocString != "" && len(unit.DocString) < 500 {
@@ -89,7 +86,7 @@ func (c *CompositeChunker) createChunk(doc domain.Document, unit CodeUnit) domai
 	}
 
 	return domain.Chunk{
-		ID:        generateASTChunkID(doc.ID, unit.Type, unit.Name, unit.StartLine),
+		ID:        generateContentChunkID(doc.ID, unit.Type+":"+unit.Name, text),
 		DocID:     doc.ID,
 		StartLine: unit.StartLine,
 		EndLine:   unit.EndLine,
@@ -131,7 +128,6 @@ func (c *CompositeChunker) splitLargeUnit(doc domain.Document, unit CodeUnit) []
 
 	bodyLines := lines[startIdx:]
 	currentStart := 0
-	chunkNum := 0
 
 	for currentStart < len(bodyLines) {
 
@@ -171,7 +167,7 @@ func (c *CompositeChunker) splitLargeUnit(doc domain.Document, unit CodeUnit) []
 			chunkContent += "}"
 		}
 
-		tokens := c.tokenizer.Tokenize(chunkContent)
+		tokens := c.tokenizer.TokenizeDoc(chunkContent, doc)
 
 		actualStartLine := unit.StartLine + startIdx + currentStart
 		actualEndLine := unit.StartLine + startIdx + currentEnd - 1
@@ -180,7 +176,7 @@ func (c *CompositeChunker) splitLargeUnit(doc domain.Document, unit CodeUnit) []
 		}
 
 		chunk := domain.Chunk{
-			ID:        generateASTChunkID(doc.ID, unit.Type, unit.Name, actualStartLine) + fmt.Sprintf("_%d", chunkNum),
+			ID:        generateContentChunkID(doc.ID, unit.Type+":"+unit.Name, chunkContent),
 			DocID:     doc.ID,
 			StartLine: actualStartLine,
 			EndLine:   actualEndLine,
@@ -195,7 +191,6 @@ func (c *CompositeChunker) splitLargeUnit(doc domain.Document, unit CodeUnit) []
 			nextStart = currentStart + 1
 		}
 		currentStart = nextStart
-		chunkNum++
 	}
 
 	return chunks
@@ -217,12 +212,6 @@ func (c *CompositeChunker) calculateOverlapForBody(lines []string, start, end in
 	return overlapLines
 }
 
-func generateASTChunkID(docID, unitType, unitName string, startLine int) string {
-	data := fmt.Sprintf("%s:%s:%s:%d", docID, unitType, unitName, startLine)
-	hash := sha256.Sum256([]byte(data))
-	return hex.EncodeToString(hash[:8])
-}
-
 func splitIntoLines(content string) []string {
 	var lines []string
 	start := 0
@@ -0,0 +1,64 @@
+package chunker
+
+import (
+	"testing"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/domain"
+)
+
+func TestCompositeChunker_StableIDsAcrossLineShifts(t *testing.T) {
+	tokenizer := analyzer.NewTokenizer(false)
+	chk := NewCompositeChunker(512, 0, tokenizer, true)
+	doc := domain.Document{ID: "doc1", Path: "/test/file.go", Lang: "go"}
+
+	original := "package main\n\nfunc Open() error {\n\treturn nil\n}\n\nfunc Close() error {\n\treturn nil\n}\n"
+	shifted := "// Package main does things.\n" + original
+
+	before, err := chk.Chunk(doc, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := chk.Chunk(doc, shifted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	beforeIDs := make(map[string]domain.Chunk)
+	for _, c := range before {
+		beforeIDs[c.ID] = c
+	}
+
+	reused := 0
+	for _, c := range after {
+		if prev, ok := beforeIDs[c.ID]; ok {
+			reused++
+			if c.StartLine != prev.StartLine+1 {
+				t.Errorf("expected line range to shift for %s: %d -> %d", c.ID, prev.StartLine, c.StartLine)
+			}
+		}
+	}
+	if reused != 2 {
+		t.Errorf("expected both function chunks to keep their IDs, reused %d", reused)
+	}
+}
+
+func TestDedupeChunkIDs(t *testing.T) {
+	tokenizer := analyzer.NewTokenizer(false)
+	chk := NewCompositeChunker(512, 0, tokenizer, true)
+	doc := domain.Document{ID: "doc1", Path: "/test/file.go", Lang: "go"}
+
+	content := "package main\n\nvar x = 1\n\nvar x = 1\n"
+	chunks, err := chk.Chunk(doc, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]bool)
+	for _, c := range chunks {
+		if ids[c.ID] {
+			t.Errorf("duplicate chunk ID: %s", c.ID)
+		}
+		ids[c.ID] = true
+	}
+}
@@ -1,9 +1,6 @@
 package chunker
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
 	"strings"
 
 	"rag/internal/adapter/analyzer"
@@ -64,10 +61,10 @@ func (c *LineChunker) Chunk(doc domain.Document, content string) ([]domain.Chunk
 		}
 
 		text := chunkText.String()
-		tokens := c.tokenizer.Tokenize(text)
+		tokens := c.tokenizer.TokenizeDoc(text, doc)
 
 		chunk := domain.Chunk{
-			ID:        generateChunkID(doc.ID, startLine, endLine),
+			ID:        generateContentChunkID(doc.ID, "lines", text),
 			DocID:     doc.ID,
 			StartLine: startLine + 1,
 			EndLine:   endLine,
@@ -88,6 +85,7 @@ func (c *LineChunker) Chunk(doc domain.Document, content string) ([]domain.Chunk
 		startLine = newStart
 	}
 
+	dedupeChunkIDs(chunks)
 	return chunks, nil
 }
 
@@ -106,9 +104,3 @@ func (c *LineChunker) calculateOverlapLines(lines []string, start, end int) int
 
 	return overlapLines
 }
-
-func generateChunkID(docID string, startLine, endLine int) string {
-	data := fmt.Sprintf("%s:%d-%d", docID, startLine, endLine)
-	hash := sha256.Sum256([]byte(data))
-	return hex.EncodeToString(hash[:8])
-}
@@ -14,6 +14,7 @@ type MemoryStore struct {
 	chunks    map[string]domain.Chunk
 	docChunks map[string][]string
 	postings  map[string][]domain.Posting
+	fields    map[string][]domain.Posting
 	stats     domain.Stats
 }
 
@@ -23,6 +24,7 @@ func NewMemoryStore() *MemoryStore {
 		chunks:    make(map[string]domain.Chunk),
 		docChunks: make(map[string][]string),
 		postings:  make(map[string][]domain.Posting),
+		fields:    make(map[string][]domain.Posting),
 	}
 }
 
@@ -65,6 +67,7 @@ func (s *MemoryStore) PutChunk(chunk domain.Chunk) error {
 	defer s.mu.Unlock()
 	s.chunks[chunk.ID] = chunk
 	s.docChunks[chunk.DocID] = append(s.docChunks[chunk.DocID], chunk.ID)
+	s.addFieldPostings(chunk)
 	return nil
 }
 
@@ -96,6 +99,7 @@ func (s *MemoryStore) DeleteChunksByDoc(docID string) error {
 	defer s.mu.Unlock()
 	chunkIDs := s.docChunks[docID]
 	for _, id := range chunkIDs {
+		s.removeFieldPostings(s.chunks[id])
 		delete(s.chunks, id)
 	}
 	delete(s.docChunks, docID)
@@ -118,6 +122,47 @@ func (s *MemoryStore) GetPostings(term string) ([]domain.Posting, error) {
 	return s.postings[term], nil
 }
 
+func (s *MemoryStore) GetFieldPostings(field, term string) ([]domain.Posting, error) {
+	if field == domain.FieldBody {
+		return s.GetPostings(term)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fields[field+"\x00"+term], nil
+}
+
+func (s *MemoryStore) addFieldPostings(chunk domain.Chunk) {
+	for field, tokens := range chunk.Fields {
+		tf := make(map[string]int)
+		for _, token := range tokens {
+			tf[token]++
+		}
+		for term, count := range tf {
+			key := field + "\x00" + term
+			s.fields[key] = append(s.fields[key], domain.Posting{ChunkID: chunk.ID, TF: count})
+		}
+	}
+}
+
+func (s *MemoryStore) removeFieldPostings(chunk domain.Chunk) {
+	for field, tokens := range chunk.Fields {
+		for _, term := range tokens {
+			key := field + "\x00" + term
+			filtered := make([]domain.Posting, 0, len(s.fields[key]))
+			for _, p := range s.fields[key] {
+				if p.ChunkID != chunk.ID {
+					filtered = append(filtered, p)
+				}
+			}
+			if len(filtered) == 0 {
+				delete(s.fields, key)
+			} else {
+				s.fields[key] = filtered
+			}
+		}
+	}
+}
+
 func (s *MemoryStore) DeletePostings(chunkID string, terms []string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -137,6 +182,25 @@ func (s *MemoryStore) DeletePostings(chunkID string, terms []string) error {
 	return nil
 }
 
+func (s *MemoryStore) GetChunkNorms(chunkIDs []string) (map[string]domain.ChunkNorm, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	norms := make(map[string]domain.ChunkNorm, len(chunkIDs))
+	for _, id := range chunkIDs {
+		if chunk, ok := s.chunks[id]; ok {
+			norm := domain.ChunkNorm{DocID: chunk.DocID, Length: len(chunk.Tokens)}
+			for field, tokens := range chunk.Fields {
+				if norm.FieldLengths == nil {
+					norm.FieldLengths = make(map[string]int)
+				}
+				norm.FieldLengths[field] = len(tokens)
+			}
+			norms[id] = norm
+		}
+	}
+	return norms, nil
+}
+
 func (s *MemoryStore) GetStats() (domain.Stats, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -157,16 +221,40 @@ func (s *MemoryStore) BatchIndex(files []port.IndexedFile) error {
 	for _, file := range files {
 		s.docs[file.Doc.ID] = file.Doc
 
+		for _, chunk := range file.Removed {
+			for _, term := range chunk.Tokens {
+				filtered := make([]domain.Posting, 0, len(s.postings[term]))
+				for _, p := range s.postings[term] {
+					if p.ChunkID != chunk.ID {
+						filtered = append(filtered, p)
+					}
+				}
+				if len(filtered) == 0 {
+					delete(s.postings, term)
+				} else {
+					s.postings[term] = filtered
+				}
+			}
+			s.removeFieldPostings(chunk)
+			delete(s.chunks, chunk.ID)
+		}
+
+		chunkIDs := make([]string, 0, len(file.Chunks))
 		for _, chunk := range file.Chunks {
+			if _, kept := file.Kept[chunk.ID]; !kept {
+				s.addFieldPostings(chunk)
+			}
 			s.chunks[chunk.ID] = chunk
-			s.docChunks[chunk.DocID] = append(s.docChunks[chunk.DocID], chunk.ID)
+			chunkIDs = append(chunkIDs, chunk.ID)
 		}
+		s.docChunks[file.Doc.ID] = chunkIDs
 
 		for term, chunkPostings := range file.Postings {
 			for chunkID, tf := range chunkPostings {
 				s.postings[term] = append(s.postings[term], domain.Posting{
-					ChunkID: chunkID,
-					TF:      tf,
+					ChunkID:   chunkID,
+					TF:        tf,
+					Positions: file.Positions[term][chunkID],
 				})
 			}
 		}
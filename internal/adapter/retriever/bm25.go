@@ -5,31 +5,57 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"unicode"
 
 	"rag/internal/domain"
 	"rag/internal/port"
 )
 
+const maxSurfaceChunks = 8
+
 type BM25Retriever struct {
 	store           port.IndexStore
 	tokenizer       port.Tokenizer
 	k1              float64
 	b               float64
 	pathBoostWeight float64
+	proximityWeight float64
+	fieldWeights    map[string]float64
+	synonyms        *SynonymMap
+	fuzzy           *FuzzyMatcher
+}
+
+type BM25Options struct {
+	K1              float64
+	B               float64
+	PathBoostWeight float64
+	ProximityWeight float64
+	FieldWeights    map[string]float64
+	Synonyms        *SynonymMap
+	Fuzzy           *FuzzyMatcher
 }
 
-func NewBM25Retriever(store port.IndexStore, tokenizer port.Tokenizer, k1, b, pathBoostWeight float64) *BM25Retriever {
+func NewBM25Retriever(store port.IndexStore, tokenizer port.Tokenizer, opts BM25Options) *BM25Retriever {
 	return &BM25Retriever{
 		store:           store,
 		tokenizer:       tokenizer,
-		k1:              k1,
-		b:               b,
-		pathBoostWeight: pathBoostWeight,
+		k1:              opts.K1,
+		b:               opts.B,
+		pathBoostWeight: opts.PathBoostWeight,
+		proximityWeight: opts.ProximityWeight,
+		fieldWeights:    opts.FieldWeights,
+		synonyms:        opts.Synonyms,
+		fuzzy:           opts.Fuzzy,
 	}
 }
 
 func (r *BM25Retriever) Search(query string, k int) ([]domain.ScoredChunk, error) {
-	queryTokens := r.tokenizer.Tokenize(query)
+	parsed, err := ParseQuery(query, r.tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	queryTokens := parsed.ScoringTokens()
 	if len(queryTokens) == 0 {
 		return nil, nil
 	}
@@ -43,83 +69,580 @@ func (r *BM25Retriever) Search(query string, k int) ([]domain.ScoredChunk, error
 		return nil, nil
 	}
 
+	// Repeated query tokens count once; TermWeights already carries boosts.
 	queryTokenSet := make(map[string]struct{}, len(queryTokens))
+	uniqueTokens := make([]string, 0, len(queryTokens))
 	for _, t := range queryTokens {
-		queryTokenSet[t] = struct{}{}
+		if _, seen := queryTokenSet[t]; !seen {
+			queryTokenSet[t] = struct{}{}
+			uniqueTokens = append(uniqueTokens, t)
+		}
 	}
-
-	chunkScores := make(map[string]float64)
-	chunkLengths := make(map[string]int)
-	chunkDocIDs := make(map[string]string)
-
-	for _, term := range queryTokens {
+	queryTokens = uniqueTokens
+	termWeights := parsed.TermWeights()
+	expansions := r.synonyms.Expand(queryTokens, termWeights)
+	scoringTokens := append(append(append([]string{}, queryTokens...), parsed.VariantTokens()...), expansions...)
+
+	termPostings := make(map[string][]domain.Posting, len(scoringTokens))
+	candidateSet := make(map[string]struct{})
+	for _, term := range scoringTokens {
+		if _, done := termPostings[term]; done {
+			continue
+		}
 		postings, err := r.store.GetPostings(term)
 		if err != nil {
 			continue
 		}
+		termPostings[term] = postings
+		for _, posting := range postings {
+			candidateSet[posting.ChunkID] = struct{}{}
+		}
+	}
 
-		n := float64(len(postings))
-		N := float64(stats.TotalChunks)
-		idf := math.Log((N-n+0.5)/(n+0.5) + 1)
-
+	for _, term := range queryTokens {
+		if len(termPostings[term]) > 0 {
+			continue
+		}
+		correction, distance, postings := r.correct(term)
+		if _, exists := termWeights[correction]; correction == "" || exists {
+			continue
+		}
+		termWeights[correction] = termWeights[term] * r.fuzzy.Weight(distance)
+		termPostings[correction] = postings
+		scoringTokens = append(scoringTokens, correction)
 		for _, posting := range postings {
+			candidateSet[posting.ChunkID] = struct{}{}
+		}
+	}
 
-			if _, exists := chunkLengths[posting.ChunkID]; !exists {
-				chunk, err := r.store.GetChunk(posting.ChunkID)
+	for _, term := range parsed.ExcludedTokens() {
+		if _, done := termPostings[term]; done {
+			continue
+		}
+		postings, err := r.store.GetPostings(term)
+		if err != nil {
+			continue
+		}
+		termPostings[term] = postings
+	}
+
+	fieldPostings := make(map[string]map[string]map[string]domain.Posting)
+	if len(r.fieldWeights) > 0 {
+		for _, field := range domain.ChunkFields {
+			if r.fieldWeights[field] <= 0 {
+				continue
+			}
+			byTerm := make(map[string][]domain.Posting, len(termPostings))
+			for _, term := range scoringTokens {
+				if _, done := byTerm[term]; done {
+					continue
+				}
+				postings, err := r.store.GetFieldPostings(field, term)
 				if err != nil {
 					continue
 				}
-				chunkLengths[posting.ChunkID] = len(chunk.Tokens)
-				chunkDocIDs[posting.ChunkID] = chunk.DocID
+				byTerm[term] = postings
+				for _, posting := range postings {
+					candidateSet[posting.ChunkID] = struct{}{}
+				}
 			}
+			fieldPostings[field] = indexPostings(byTerm)
+		}
+	}
 
-			dl := float64(chunkLengths[posting.ChunkID])
-			avgDl := stats.AvgChunkLen
-			tf := float64(posting.TF)
+	if len(candidateSet) == 0 {
+		return nil, nil
+	}
 
-			score := idf * (tf * (r.k1 + 1)) / (tf + r.k1*(1-r.b+r.b*dl/avgDl))
-			chunkScores[posting.ChunkID] += score
-		}
+	candidateIDs := make([]string, 0, len(candidateSet))
+	for id := range candidateSet {
+		candidateIDs = append(candidateIDs, id)
 	}
 
-	docPathBoosts := make(map[string]float64)
+	norms, err := r.store.GetChunkNorms(candidateIDs)
+	if err != nil {
+		return nil, err
+	}
 
-	results := make([]domain.ScoredChunk, 0, len(chunkScores))
-	for chunkID, score := range chunkScores {
-		chunk, err := r.store.GetChunk(chunkID)
-		if err != nil {
+	chunkPostings := indexPostings(termPostings)
+
+	if parsed.HasConstraints() {
+		r.applyConstraints(parsed, chunkPostings, fieldPostings, norms)
+	}
+
+	chunkScores := make(map[string]float64)
+	termIDFs := make(map[string]float64, len(termPostings))
+	N := float64(stats.TotalChunks)
+	avgDl := stats.AvgChunkLen
+
+	var termFields map[string]map[string]map[string]int
+	if len(fieldPostings) > 0 {
+		termFields = fieldTFs(scoringTokens, chunkPostings, fieldPostings)
+	}
+
+	for _, term := range scoringTokens {
+		if termFields != nil {
+			n := float64(len(termFields[term]))
+			idf := math.Log((N-n+0.5)/(n+0.5) + 1)
+			termIDFs[term] = idf
+			for chunkID, tfs := range termFields[term] {
+				if norm, exists := norms[chunkID]; exists {
+					chunkScores[chunkID] += termWeights[term] * r.fieldScore(idf, tfs, norm, stats)
+				}
+			}
 			continue
 		}
 
+		postings := termPostings[term]
+
+		n := float64(len(postings))
+		idf := math.Log((N-n+0.5)/(n+0.5) + 1)
+		termIDFs[term] = idf
+
+		for _, posting := range postings {
+			norm, exists := norms[posting.ChunkID]
+			if !exists {
+				continue
+			}
+
+			chunkScores[posting.ChunkID] += termWeights[term] * r.termScore(idf, posting.TF, norm.Length, avgDl)
+		}
+	}
+
+	type scoredID struct {
+		id    string
+		score float64
+	}
+
+	docPathBoosts := make(map[string]float64)
+	boostFactors := make(map[string]float64)
+	proximities := make(map[string]float64)
+	spans := make(map[string]int)
+
+	scored := make([]scoredID, 0, len(chunkScores))
+	for chunkID, score := range chunkScores {
 		finalScore := score
-		if r.pathBoostWeight > 0 {
-			docID := chunkDocIDs[chunkID]
+		if r.proximityWeight > 0 {
+			if span, matched := minimalSpan(chunkID, queryTokenSet, chunkPostings); matched > 1 {
+				spans[chunkID] = span
+				proximities[chunkID] = 1 + r.proximityWeight*float64(matched-1)/float64(span-1)
+				finalScore *= proximities[chunkID]
+			}
+		}
+		if r.pathBoostWeight > 0 && termFields == nil {
+			docID := norms[chunkID].DocID
 			pathBoost, exists := docPathBoosts[docID]
 			if !exists {
 				doc, err := r.store.GetDoc(docID)
 				if err == nil {
 					pathBoost = r.calculatePathBoost(doc.Path, queryTokenSet)
-					docPathBoosts[docID] = pathBoost
 				}
+				docPathBoosts[docID] = pathBoost
 			}
-			finalScore = score * (1 + pathBoost*r.pathBoostWeight)
+			boostFactors[chunkID] = 1 + pathBoost*r.pathBoostWeight
+			finalScore *= boostFactors[chunkID]
 		}
+		scored = append(scored, scoredID{id: chunkID, score: finalScore})
+	}
 
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].id < scored[j].id
+	})
+
+	results := make([]domain.ScoredChunk, 0, min(k, len(scored)))
+	for _, sc := range scored {
+		if len(results) >= k {
+			break
+		}
+		chunk, err := r.store.GetChunk(sc.id)
+		if err != nil {
+			continue
+		}
+		pathBoost := 1.0
+		if factor, exists := boostFactors[sc.id]; exists {
+			pathBoost = factor
+		}
 		results = append(results, domain.ScoredChunk{
 			Chunk: chunk,
-			Score: finalScore,
+			Score: sc.score,
+			Explanation: &domain.Explanation{
+				BM25:      chunkScores[sc.id],
+				PathBoost: pathBoost,
+				Proximity: proximities[sc.id],
+				Span:      spans[sc.id],
+			},
+		})
+	}
+
+	if termFields != nil {
+		r.explainFields(results, scoringTokens, termWeights, termFields, termIDFs, norms, stats)
+	} else {
+		r.explainTerms(results, scoringTokens, termWeights, termPostings, termIDFs, norms, avgDl)
+	}
+
+	return results, nil
+}
+
+// correct prefers the closest candidate with the most postings.
+func (r *BM25Retriever) correct(term string) (string, int, []domain.Posting) {
+	if r.fuzzy == nil {
+		return "", 0, nil
+	}
+	candidates, distance := r.fuzzy.Corrections(term)
+	best, bestPostings := "", []domain.Posting(nil)
+	for _, candidate := range candidates {
+		postings, err := r.store.GetPostings(candidate)
+		if err == nil && len(postings) > len(bestPostings) {
+			best, bestPostings = candidate, postings
+		}
+	}
+	return best, distance, bestPostings
+}
+
+// DidYouMean suggests a corrected query when none of its terms are in the index.
+func (r *BM25Retriever) DidYouMean(query string) (string, bool) {
+	if r.fuzzy == nil {
+		return "", false
+	}
+
+	words := splitQuery(query)
+	changed := false
+	for i, word := range words {
+		prefix, body := "", word
+		if strings.HasPrefix(body, "-") {
+			continue
+		}
+		if strings.HasPrefix(body, "+") {
+			prefix, body = "+", body[1:]
+		}
+		if _, ok, _ := parseFilter(body); ok || body == "OR" {
+			continue
+		}
+
+		tokens := r.tokenizer.Tokenize(strings.Trim(body, `"`))
+		for _, token := range tokens {
+			if postings, err := r.store.GetPostings(token); err == nil && len(postings) > 0 {
+				return "", false
+			}
+		}
+		if len(tokens) != 1 || strings.ContainsAny(body, `"^`) {
+			continue
+		}
+		correction, _, postings := r.correct(tokens[0])
+		if surface := r.surfaceForm(correction, postings); surface != "" {
+			words[i] = prefix + surface
+			changed = true
+		}
+	}
+
+	if !changed {
+		return "", false
+	}
+	return strings.Join(words, " "), true
+}
+
+// surfaceForm finds an indexed word that tokenizes to term, so suggestions are not stems.
+func (r *BM25Retriever) surfaceForm(term string, postings []domain.Posting) string {
+	if term == "" {
+		return ""
+	}
+	for i, posting := range postings {
+		if i == maxSurfaceChunks {
+			break
+		}
+		chunk, err := r.store.GetChunk(posting.ChunkID)
+		if err != nil {
+			continue
+		}
+		words := strings.FieldsFunc(chunk.Text, func(c rune) bool {
+			return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_'
 		})
+		for _, word := range words {
+			word = strings.ToLower(word)
+			if tokens := r.tokenizer.Tokenize(word); len(tokens) == 1 && tokens[0] == term {
+				return word
+			}
+		}
+	}
+	return ""
+}
+
+func (r *BM25Retriever) applyConstraints(
+	parsed *ParsedQuery,
+	chunkPostings map[string]map[string]domain.Posting,
+	fieldPostings map[string]map[string]map[string]domain.Posting,
+	norms map[string]domain.ChunkNorm,
+) {
+	docMatches := make(map[string]bool)
+	for chunkID, norm := range norms {
+		if len(parsed.Filters) > 0 {
+			matched, exists := docMatches[norm.DocID]
+			if !exists {
+				doc, err := r.store.GetDoc(norm.DocID)
+				matched = err == nil && parsed.MatchDoc(doc)
+				docMatches[norm.DocID] = matched
+			}
+			if !matched {
+				delete(norms, chunkID)
+				continue
+			}
+		}
+
+		var tokens []string
+		hydrated := false
+		ok := parsed.MatchTerms(
+			func(term string) bool {
+				if _, exists := chunkPostings[term][chunkID]; exists {
+					return true
+				}
+				for _, postings := range fieldPostings {
+					if _, exists := postings[term][chunkID]; exists {
+						return true
+					}
+				}
+				return false
+			},
+			func(phrase []string) bool {
+				if lists, positional := phrasePositions(chunkID, phrase, chunkPostings); positional {
+					return containsPositionalPhrase(lists)
+				}
+				if !hydrated {
+					hydrated = true
+					if chunk, err := r.store.GetChunk(chunkID); err == nil {
+						tokens = chunk.Tokens
+					}
+				}
+				return containsPhrase(tokens, phrase)
+			},
+		)
+		if !ok {
+			delete(norms, chunkID)
+		}
+	}
+}
+
+func indexPostings(termPostings map[string][]domain.Posting) map[string]map[string]domain.Posting {
+	indexed := make(map[string]map[string]domain.Posting, len(termPostings))
+	for term, postings := range termPostings {
+		byChunk := make(map[string]domain.Posting, len(postings))
+		for _, posting := range postings {
+			byChunk[posting.ChunkID] = posting
+		}
+		indexed[term] = byChunk
+	}
+	return indexed
+}
+
+func phrasePositions(chunkID string, phrase []string, chunkPostings map[string]map[string]domain.Posting) ([][]int, bool) {
+	lists := make([][]int, len(phrase))
+	for i, term := range phrase {
+		posting := chunkPostings[term][chunkID]
+		if len(posting.Positions) == 0 {
+			return nil, false
+		}
+		lists[i] = posting.Positions
+	}
+	return lists, true
+}
+
+func containsPositionalPhrase(lists [][]int) bool {
+	for _, start := range lists[0] {
+		match := true
+		for offset, positions := range lists[1:] {
+			want := start + offset + 1
+			idx := sort.SearchInts(positions, want)
+			if idx == len(positions) || positions[idx] != want {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func minimalSpan(chunkID string, terms map[string]struct{}, chunkPostings map[string]map[string]domain.Posting) (int, int) {
+	type occurrence struct {
+		pos  int
+		term int
+	}
+
+	var occurrences []occurrence
+	matched := 0
+	for term := range terms {
+		posting := chunkPostings[term][chunkID]
+		if len(posting.Positions) == 0 {
+			continue
+		}
+		for _, pos := range posting.Positions {
+			occurrences = append(occurrences, occurrence{pos: pos, term: matched})
+		}
+		matched++
+	}
+	if matched < 2 {
+		return 0, matched
 	}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].pos < occurrences[j].pos
 	})
 
-	if len(results) > k {
-		results = results[:k]
+	counts := make([]int, matched)
+	covered := 0
+	best := math.MaxInt
+	left := 0
+	for _, occ := range occurrences {
+		if counts[occ.term] == 0 {
+			covered++
+		}
+		counts[occ.term]++
+
+		for covered == matched {
+			if span := occ.pos - occurrences[left].pos + 1; span < best {
+				best = span
+			}
+			counts[occurrences[left].term]--
+			if counts[occurrences[left].term] == 0 {
+				covered--
+			}
+			left++
+		}
 	}
 
-	return results, nil
+	return best, matched
+}
+
+func (r *BM25Retriever) termScore(idf float64, tf, length int, avgDl float64) float64 {
+	dl := float64(length)
+	tfFloat := float64(tf)
+	return idf * (tfFloat * (r.k1 + 1)) / (tfFloat + r.k1*(1-r.b+r.b*dl/avgDl))
+}
+
+func fieldTFs(
+	queryTokens []string,
+	chunkPostings map[string]map[string]domain.Posting,
+	fieldPostings map[string]map[string]map[string]domain.Posting,
+) map[string]map[string]map[string]int {
+	termFields := make(map[string]map[string]map[string]int, len(queryTokens))
+	add := func(term, field string, postings map[string]domain.Posting) {
+		for chunkID, posting := range postings {
+			if termFields[term][chunkID] == nil {
+				termFields[term][chunkID] = make(map[string]int)
+			}
+			termFields[term][chunkID][field] = posting.TF
+		}
+	}
+	for _, term := range queryTokens {
+		if termFields[term] != nil {
+			continue
+		}
+		termFields[term] = make(map[string]map[string]int)
+		add(term, domain.FieldBody, chunkPostings[term])
+		for field, postings := range fieldPostings {
+			add(term, field, postings[term])
+		}
+	}
+	return termFields
+}
+
+func (r *BM25Retriever) fieldScore(idf float64, tfs map[string]int, norm domain.ChunkNorm, stats domain.Stats) float64 {
+	weighted := 0.0
+	for field, tf := range tfs {
+		weight := r.fieldWeights[field]
+		length, avg := float64(norm.FieldLengths[field]), stats.AvgFieldLen[field]
+		if field == domain.FieldBody {
+			length, avg = float64(norm.Length), stats.AvgChunkLen
+		}
+		if weight <= 0 || avg <= 0 {
+			continue
+		}
+		weighted += weight * float64(tf) / (1 - r.b + r.b*length/avg)
+	}
+	return idf * weighted * (r.k1 + 1) / (weighted + r.k1)
+}
+
+func (r *BM25Retriever) explainFields(
+	results []domain.ScoredChunk,
+	queryTokens []string,
+	termWeights map[string]float64,
+	termFields map[string]map[string]map[string]int,
+	termIDFs map[string]float64,
+	norms map[string]domain.ChunkNorm,
+	stats domain.Stats,
+) {
+	for _, term := range queryTokens {
+		for _, result := range results {
+			tfs, matched := termFields[term][result.Chunk.ID]
+			if !matched {
+				continue
+			}
+			result.Explanation.Terms = append(result.Explanation.Terms, domain.TermContribution{
+				Term:   term,
+				TF:     tfs[domain.FieldBody],
+				IDF:    termIDFs[term],
+				Score:  termWeights[term] * r.fieldScore(termIDFs[term], tfs, norms[result.Chunk.ID], stats),
+				Weight: reportedWeight(termWeights[term]),
+				Fields: tfs,
+			})
+		}
+	}
+}
+
+func (r *BM25Retriever) explainTerms(
+	results []domain.ScoredChunk,
+	queryTokens []string,
+	termWeights map[string]float64,
+	termPostings map[string][]domain.Posting,
+	termIDFs map[string]float64,
+	norms map[string]domain.ChunkNorm,
+	avgDl float64,
+) {
+	byID := make(map[string]*domain.Explanation, len(results))
+	for _, result := range results {
+		byID[result.Chunk.ID] = result.Explanation
+	}
+
+	for _, term := range queryTokens {
+		idf := termIDFs[term]
+		for _, posting := range termPostings[term] {
+			exp, selected := byID[posting.ChunkID]
+			if !selected {
+				continue
+			}
+			contribution := termWeights[term] * r.termScore(idf, posting.TF, norms[posting.ChunkID].Length, avgDl)
+
+			merged := false
+			for i := range exp.Terms {
+				if exp.Terms[i].Term == term {
+					exp.Terms[i].Score += contribution
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				exp.Terms = append(exp.Terms, domain.TermContribution{
+					Term:   term,
+					TF:     posting.TF,
+					IDF:    idf,
+					Score:  contribution,
+					Weight: reportedWeight(termWeights[term]),
+				})
+			}
+		}
+	}
+}
+
+// Plain query terms report zero so explanations omit their weight.
+func reportedWeight(weight float64) float64 {
+	if weight == 1 {
+		return 0
+	}
+	return weight
 }
 
 func (r *BM25Retriever) calculatePathBoost(path string, queryTokenSet map[string]struct{}) float64 {
@@ -1,12 +1,15 @@
 package retriever
 
 import (
+	"math"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"rag/internal/adapter/analyzer"
 	"rag/internal/adapter/store"
 	"rag/internal/domain"
+	"rag/internal/port"
 )
 
 func TestBM25Scoring(t *testing.T) {
@@ -84,7 +87,7 @@ func TestBM25Scoring(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	retriever := NewBM25Retriever(st, tokenizer, 1.2, 0.75, 0)
+	retriever := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
 
 	results, err := retriever.Search("authentication", 10)
 	if err != nil {
@@ -118,6 +121,27 @@ func TestBM25Scoring(t *testing.T) {
 	if results[0].Chunk.ID != "chunk2" {
 		t.Errorf("expected chunk2 to be top result for 'database', got %s", results[0].Chunk.ID)
 	}
+
+	results, err = retriever.Search("authentication oauth", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		exp := r.Explanation
+		if exp == nil || len(exp.Terms) == 0 {
+			t.Fatalf("expected term contributions for %s", r.Chunk.ID)
+		}
+		sum := 0.0
+		for _, term := range exp.Terms {
+			sum += term.Score
+		}
+		if math.Abs(sum-exp.BM25) > 1e-9 || math.Abs(exp.BM25*exp.PathBoost-r.Score) > 1e-9 {
+			t.Errorf("explanation for %s does not add up: terms=%f bm25=%f boost=%f score=%f", r.Chunk.ID, sum, exp.BM25, exp.PathBoost, r.Score)
+		}
+	}
+	if results[0].Chunk.ID != "chunk3" || len(results[0].Explanation.Terms) != 2 {
+		t.Errorf("expected chunk3 to match both terms, got %s with %+v", results[0].Chunk.ID, results[0].Explanation.Terms)
+	}
 }
 
 func TestBM25EmptyQuery(t *testing.T) {
@@ -134,7 +158,7 @@ func TestBM25EmptyQuery(t *testing.T) {
 	defer st.Close()
 
 	tokenizer := analyzer.NewTokenizer(true)
-	retriever := NewBM25Retriever(st, tokenizer, 1.2, 0.75, 0)
+	retriever := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
 
 	results, err := retriever.Search("", 10)
 	if err != nil {
@@ -174,7 +198,7 @@ func TestBM25NoMatches(t *testing.T) {
 	st.PutPosting("world", "chunk1", 1)
 	st.UpdateStats(domain.Stats{TotalDocs: 1, TotalChunks: 1, AvgChunkLen: 2})
 
-	retriever := NewBM25Retriever(st, tokenizer, 1.2, 0.75, 0)
+	retriever := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
 
 	results, err := retriever.Search("zzzznonexistent", 10)
 	if err != nil {
@@ -185,3 +209,174 @@ func TestBM25NoMatches(t *testing.T) {
 		t.Errorf("expected no results for non-matching query, got %d", len(results))
 	}
 }
+
+func TestBM25Proximity(t *testing.T) {
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	tokenizer := analyzer.NewTokenizer(false)
+	texts := map[string]string{
+		"adjacent": "connection pool alpha beta gamma delta epsilon zeta",
+		"distant":  "connection alpha beta gamma delta epsilon zeta pool",
+	}
+
+	file := port.IndexedFile{
+		Doc:       domain.Document{ID: "doc1", Path: "/repo/a.txt"},
+		Postings:  make(map[string]map[string]int),
+		Positions: make(map[string]map[string][]int),
+	}
+	for id, text := range texts {
+		tokens := tokenizer.Tokenize(text)
+		file.Chunks = append(file.Chunks, domain.Chunk{ID: id, DocID: "doc1", Tokens: tokens, Text: text})
+		for i, token := range tokens {
+			if file.Postings[token] == nil {
+				file.Postings[token] = make(map[string]int)
+				file.Positions[token] = make(map[string][]int)
+			}
+			file.Postings[token][id]++
+			file.Positions[token][id] = append(file.Positions[token][id], i)
+		}
+	}
+	if err := st.BatchIndex([]port.IndexedFile{file}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateStats(domain.Stats{TotalDocs: 1, TotalChunks: 2, AvgChunkLen: 8}); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
+	results, err := plain.Search("connection pool", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Score != results[1].Score {
+		t.Fatalf("expected equal scores without proximity, got %+v", results)
+	}
+
+	proximity := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75, ProximityWeight: 0.5})
+	results, err = proximity.Search("connection pool", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Chunk.ID != "adjacent" {
+		t.Fatalf("expected adjacent terms to rank first, got %v", resultIDs(results))
+	}
+	if exp := results[0].Explanation; exp.Span != 2 || math.Abs(exp.Proximity-1.5) > 1e-9 {
+		t.Errorf("expected span 2 with full proximity bonus, got %+v", exp)
+	}
+	if exp := results[1].Explanation; exp.Span != 8 || math.Abs(exp.Proximity-(1+0.5/7)) > 1e-9 {
+		t.Errorf("expected span 8 for distant terms, got %+v", exp)
+	}
+
+	results, err = proximity.Search(`"connection pool"`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(results); len(ids) != 1 || ids[0] != "adjacent" {
+		t.Errorf("expected positional phrase match on adjacent chunk only, got %v", ids)
+	}
+}
+
+func TestBM25FieldWeighting(t *testing.T) {
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	tokenizer := analyzer.NewTokenizer(false)
+	extractor := analyzer.NewFieldExtractor(tokenizer)
+	sources := map[string]string{
+		"/repo/config/loader.go": "package config\n\n// Reload re-reads the settings file.\nfunc Reload(path string) error {\n\treturn nil\n}\n",
+		"/repo/cmd/main.go":      "package main\n\nfunc main() {\n\t// reload on signal, reload on timer, reload again\n\trun()\n}\n",
+	}
+
+	var files []port.IndexedFile
+	fieldTotals := make(map[string]int)
+	totalTokens := 0
+	for path, content := range sources {
+		doc := domain.Document{ID: path, Path: path, Lang: "go"}
+		chunks := []domain.Chunk{{ID: path + "#0", DocID: path, StartLine: 1, EndLine: 7, Text: content, Tokens: tokenizer.Tokenize(content)}}
+		extractor.ExtractFields(doc, content, chunks)
+
+		file := port.IndexedFile{Doc: doc, Chunks: chunks, Postings: make(map[string]map[string]int)}
+		for _, token := range chunks[0].Tokens {
+			if file.Postings[token] == nil {
+				file.Postings[token] = make(map[string]int)
+			}
+			file.Postings[token][chunks[0].ID]++
+		}
+		totalTokens += len(chunks[0].Tokens)
+		for field, tokens := range chunks[0].Fields {
+			fieldTotals[field] += len(tokens)
+		}
+		files = append(files, file)
+	}
+	if err := st.BatchIndex(files); err != nil {
+		t.Fatal(err)
+	}
+
+	avgFieldLen := make(map[string]float64)
+	for field, total := range fieldTotals {
+		avgFieldLen[field] = float64(total) / 2
+	}
+	if err := st.UpdateStats(domain.Stats{TotalDocs: 2, TotalChunks: 2, AvgChunkLen: float64(totalTokens) / 2, AvgFieldLen: avgFieldLen}); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
+	results, err := plain.Search("reload", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Chunk.DocID != "/repo/cmd/main.go" {
+		t.Fatalf("expected repeated body mentions to win without fields, got %v", resultIDs(results))
+	}
+
+	weights := map[string]float64{
+		domain.FieldPath:      1.5,
+		domain.FieldSymbol:    3.0,
+		domain.FieldSignature: 2.0,
+		domain.FieldDoc:       1.5,
+		domain.FieldBody:      1.0,
+	}
+	fielded := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75, FieldWeights: weights})
+	results, err = fielded.Search("reload", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Chunk.DocID != "/repo/config/loader.go" {
+		t.Fatalf("expected symbol definition to rank first, got %v", resultIDs(results))
+	}
+
+	exp := results[0].Explanation
+	sum := 0.0
+	for _, term := range exp.Terms {
+		sum += term.Score
+		if term.Fields[domain.FieldSymbol] == 0 {
+			t.Errorf("expected %q to match the symbol field, got %+v", term.Term, term.Fields)
+		}
+	}
+	if math.Abs(sum-exp.BM25) > 1e-9 || math.Abs(exp.BM25-results[0].Score) > 1e-9 {
+		t.Errorf("field explanation does not add up: terms=%f bm25=%f score=%f", sum, exp.BM25, results[0].Score)
+	}
+
+	for _, r := range []*BM25Retriever{plain, fielded} {
+		once, _ := r.Search("reload", 2)
+		twice, _ := r.Search("reload reload", 2)
+		if len(once) != len(twice) || once[0].Score != twice[0].Score {
+			t.Errorf("expected a repeated query token to score once, got %v and %v", once[0].Score, twice[0].Score)
+		}
+	}
+
+	results, err = fielded.Search("+loader reload", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(results); len(ids) != 1 || results[0].Chunk.DocID != "/repo/config/loader.go" {
+		t.Errorf("expected required term to match through the path field, got %v", ids)
+	}
+}
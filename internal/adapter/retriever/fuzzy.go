@@ -0,0 +1,133 @@
+package retriever
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type FuzzyMatcher struct {
+	load        func() ([]string, error)
+	once        sync.Once
+	terms       []string
+	maxDistance int
+	penalty     float64
+}
+
+func NewFuzzyMatcher(load func() ([]string, error), maxDistance int, penalty float64) *FuzzyMatcher {
+	if maxDistance <= 0 {
+		maxDistance = 2
+	}
+	if penalty <= 0 || penalty > 1 {
+		penalty = 0.5
+	}
+	return &FuzzyMatcher{load: load, maxDistance: maxDistance, penalty: penalty}
+}
+
+func (m *FuzzyMatcher) vocabulary() []string {
+	m.once.Do(func() {
+		terms, err := m.load()
+		if err != nil {
+			return
+		}
+		m.terms = append([]string(nil), terms...)
+		sort.Strings(m.terms)
+	})
+	return m.terms
+}
+
+// Weight is the score multiplier for a correction at the given distance.
+func (m *FuzzyMatcher) Weight(distance int) float64 {
+	return math.Pow(m.penalty, float64(distance))
+}
+
+// Corrections allows one edit per four characters of term, up to maxDistance.
+func (m *FuzzyMatcher) Corrections(term string) ([]string, int) {
+	matches, distance, _ := m.corrections(term)
+	return matches, distance
+}
+
+// corrections walks the sorted vocabulary as a trie and counts the rows it computes.
+func (m *FuzzyMatcher) corrections(term string) ([]string, int, int) {
+	target := []rune(term)
+	allowed := min(m.maxDistance, len(target)/4)
+	if allowed == 0 {
+		return nil, 0, 0
+	}
+
+	terms := m.vocabulary()
+	rows := [][]int{make([]int, len(target)+1)}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	var matches []string
+	best := allowed
+	computed := 0
+	var prev []rune
+	for i := 0; i < len(terms); {
+		word := []rune(terms[i])
+		p := 0
+		for p < len(prev) && p < len(word) && prev[p] == word[p] {
+			p++
+		}
+		rows = rows[:p+1]
+
+		dead := false
+		for k := p; k < len(word); k++ {
+			row := levenshteinRow(rows[k], target, word[k])
+			rows = append(rows, row)
+			computed++
+			if minInt(row) > best {
+				prefix := string(word[:k+1])
+				next := i + 1 + sort.Search(len(terms)-i-1, func(x int) bool {
+					return !strings.HasPrefix(terms[i+1+x], prefix)
+				})
+				rows = rows[:k+1]
+				prev = word[:k]
+				i = next
+				dead = true
+				break
+			}
+		}
+		if dead {
+			continue
+		}
+
+		if d := rows[len(word)][len(target)]; d > 0 && d <= best {
+			if d < best {
+				best, matches = d, nil
+			}
+			matches = append(matches, terms[i])
+		}
+		prev = word
+		i++
+	}
+
+	if len(matches) == 0 {
+		return nil, 0, computed
+	}
+	return matches, best, computed
+}
+
+func levenshteinRow(prev []int, target []rune, r rune) []int {
+	row := make([]int, len(prev))
+	row[0] = prev[0] + 1
+	for j := 1; j < len(row); j++ {
+		cost := 1
+		if target[j-1] == r {
+			cost = 0
+		}
+		row[j] = min(min(prev[j]+1, row[j-1]+1), prev[j-1]+cost)
+	}
+	return row
+}
+
+func minInt(values []int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		m = min(m, v)
+	}
+	return m
+}
@@ -0,0 +1,182 @@
+package retriever
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/adapter/store"
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+func TestFuzzyMatcher_Corrections(t *testing.T) {
+	vocabulary := []string{
+		"connect", "connection", "connector", "collection", "correction", "conn",
+		"handler", "handle", "handled", "request", "requests", "response", "retry",
+	}
+	matcher := NewFuzzyMatcher(func() ([]string, error) { return vocabulary, nil }, 2, 0.5)
+
+	tests := []struct {
+		term     string
+		want     []string
+		distance int
+	}{
+		{"conection", []string{"connection"}, 1},
+		{"handlr", []string{"handle", "handler"}, 1},
+		{"reqeusts", []string{"requests"}, 2},
+		{"retyr", nil, 0},
+		{"cnn", nil, 0},
+		{"zzzzzzzz", nil, 0},
+	}
+	for _, tt := range tests {
+		got, distance := matcher.Corrections(tt.term)
+		if !reflect.DeepEqual(got, tt.want) || distance != tt.distance {
+			t.Errorf("Corrections(%q) = %v, %d; want %v, %d", tt.term, got, distance, tt.want, tt.distance)
+		}
+	}
+
+	for _, term := range []string{"conector", "colection", "respons", "handlers"} {
+		got, distance := matcher.Corrections(term)
+		want, wantDistance := bruteForceCorrections(vocabulary, term, min(2, len(term)/4))
+		if !reflect.DeepEqual(got, want) || distance != wantDistance {
+			t.Errorf("Corrections(%q) = %v, %d; brute force %v, %d", term, got, distance, want, wantDistance)
+		}
+	}
+}
+
+func TestFuzzyMatcher_PrunesDeadPrefixes(t *testing.T) {
+	vocabulary := []string{"connection"}
+	for i := 0; i < 1000; i++ {
+		vocabulary = append(vocabulary, fmt.Sprintf("xyz%04d", i))
+	}
+	matcher := NewFuzzyMatcher(func() ([]string, error) { return vocabulary, nil }, 2, 0.5)
+
+	matches, distance, computed := matcher.corrections("conection")
+	if !reflect.DeepEqual(matches, []string{"connection"}) || distance != 1 {
+		t.Fatalf("corrections = %v, %d", matches, distance)
+	}
+	if computed > len("connection")+3 {
+		t.Errorf("computed %d rows, expected the xyz subtree to be skipped", computed)
+	}
+}
+
+func bruteForceCorrections(vocabulary []string, term string, allowed int) ([]string, int) {
+	var matches []string
+	best := allowed
+	for _, word := range vocabulary {
+		row := make([]int, len([]rune(term))+1)
+		for j := range row {
+			row[j] = j
+		}
+		for _, r := range word {
+			row = levenshteinRow(row, []rune(term), r)
+		}
+		d := row[len(row)-1]
+		if d == 0 || d > best {
+			continue
+		}
+		if d < best {
+			best, matches = d, nil
+		}
+		matches = append(matches, word)
+	}
+	if len(matches) == 0 {
+		return nil, 0
+	}
+	sort.Strings(matches)
+	return matches, best
+}
+
+func TestBM25Fuzzy(t *testing.T) {
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	tokenizer := analyzer.NewTokenizer(false)
+	texts := map[string]string{
+		"pool":   "connection pool with retry handling",
+		"render": "render dashboard charts",
+	}
+
+	file := port.IndexedFile{
+		Doc:      domain.Document{ID: "doc1", Path: "/repo/a.txt"},
+		Postings: make(map[string]map[string]int),
+	}
+	for id, text := range texts {
+		tokens := tokenizer.Tokenize(text)
+		file.Chunks = append(file.Chunks, domain.Chunk{ID: id, DocID: "doc1", Tokens: tokens, Text: text})
+		for _, token := range tokens {
+			if file.Postings[token] == nil {
+				file.Postings[token] = make(map[string]int)
+			}
+			file.Postings[token][id]++
+		}
+	}
+	if err := st.BatchIndex([]port.IndexedFile{file}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateStats(domain.Stats{TotalDocs: 1, TotalChunks: 2, AvgChunkLen: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	exact := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
+	if results, _ := exact.Search("conection", 10); len(results) != 0 {
+		t.Fatalf("expected no results without fuzzy matching, got %v", resultIDs(results))
+	}
+
+	fuzzy := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75, Fuzzy: NewFuzzyMatcher(st.AllTerms, 2, 0.5)})
+	results, err := fuzzy.Search("conection", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(results); !reflect.DeepEqual(ids, []string{"pool"}) {
+		t.Fatalf("expected corrected term to match, got %v", ids)
+	}
+	if terms := results[0].Explanation.Terms; len(terms) != 1 || terms[0].Term != "connection" || terms[0].Weight != 0.5 {
+		t.Errorf("expected penalized correction, got %+v", terms)
+	}
+
+	if suggestion, ok := fuzzy.DidYouMean("conection +retri lang:go"); !ok || suggestion != "connection +retry lang:go" {
+		t.Errorf("DidYouMean = %q, %v", suggestion, ok)
+	}
+	if _, ok := fuzzy.DidYouMean("conection pool"); ok {
+		t.Error("expected no suggestion when a query term has postings")
+	}
+}
+
+func TestBM25DidYouMean_SuggestsSurfaceForms(t *testing.T) {
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	tokenizer := analyzer.NewTokenizer(true)
+	text := "The retriever ranks connections"
+	tokens := tokenizer.Tokenize(text)
+	file := port.IndexedFile{
+		Doc:      domain.Document{ID: "doc1", Path: "/repo/a.txt"},
+		Chunks:   []domain.Chunk{{ID: "c1", DocID: "doc1", Tokens: tokens, Text: text}},
+		Postings: make(map[string]map[string]int),
+	}
+	for _, token := range tokens {
+		if file.Postings[token] == nil {
+			file.Postings[token] = make(map[string]int)
+		}
+		file.Postings[token]["c1"]++
+	}
+	if err := st.BatchIndex([]port.IndexedFile{file}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75, Fuzzy: NewFuzzyMatcher(st.AllTerms, 2, 0.5)})
+	if suggestion, ok := r.DidYouMean("retrever conections"); !ok || suggestion != "retriever connections" {
+		t.Errorf("DidYouMean = %q, %v; want surface forms", suggestion, ok)
+	}
+}
@@ -1,17 +1,26 @@
 package retriever
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 
 	"rag/internal/domain"
 	"rag/internal/port"
 )
 
+const (
+	FusionRRF        = "rrf"
+	FusionLinear     = "linear"
+	FusionBM25Subset = "bm25-subset"
+)
+
 type HybridRetriever struct {
 	bm25        *BM25Retriever
 	vectorStore port.VectorStore
 	embedder    port.Embedder
 	chunkStore  port.IndexStore
+	fusion      string
 	rrfK        int
 	bm25Weight  float64
 }
@@ -21,9 +30,17 @@ func NewHybridRetriever(
 	vectorStore port.VectorStore,
 	embedder port.Embedder,
 	chunkStore port.IndexStore,
+	fusion string,
 	rrfK int,
 	bm25Weight float64,
-) *HybridRetriever {
+) (*HybridRetriever, error) {
+	switch fusion {
+	case "":
+		fusion = FusionLinear
+	case FusionRRF, FusionLinear, FusionBM25Subset:
+	default:
+		return nil, fmt.Errorf("retrieve.fusion: unknown method %q (want rrf, linear or bm25-subset)", fusion)
+	}
 	if rrfK <= 0 {
 		rrfK = 60
 	}
@@ -36,9 +53,10 @@ func NewHybridRetriever(
 		vectorStore: vectorStore,
 		embedder:    embedder,
 		chunkStore:  chunkStore,
+		fusion:      fusion,
 		rrfK:        rrfK,
 		bm25Weight:  bm25Weight,
-	}
+	}, nil
 }
 
 func (r *HybridRetriever) Search(query string, k int) ([]domain.ScoredChunk, error) {
@@ -51,12 +69,49 @@ func (r *HybridRetriever) Search(query string, k int) ([]domain.ScoredChunk, err
 		candidateK = 50
 	}
 
+	parsed, err := ParseQuery(query, r.bm25.tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.fusion == FusionBM25Subset {
+		return r.subsetSearch(query, parsed, k, candidateK)
+	}
+
+	bm25Results, err := r.bm25.Search(query, candidateK)
+	if err != nil {
+		bm25Results = nil
+	}
+
+	vectorResults, err := r.vectorSearch(parsed, candidateK)
+	if err != nil || len(vectorResults) == 0 {
+		return bm25Results[:min(k, len(bm25Results))], nil
+	}
+	if len(bm25Results) == 0 {
+		return vectorResults[:min(k, len(vectorResults))], nil
+	}
+
+	var fused []domain.ScoredChunk
+	if r.fusion == FusionLinear {
+		fused = r.linearFusion(bm25Results, vectorResults)
+	} else {
+		fused = r.rrfFusion(bm25Results, vectorResults)
+	}
+
+	if len(fused) > k {
+		fused = fused[:k]
+	}
+
+	return fused, nil
+}
+
+func (r *HybridRetriever) subsetSearch(query string, parsed *ParsedQuery, k, candidateK int) ([]domain.ScoredChunk, error) {
 	bm25Results, err := r.bm25.Search(query, candidateK)
 	if err != nil || len(bm25Results) == 0 {
-		return r.vectorOnlySearch(query, k)
+		return r.vectorOnlySearch(parsed, k)
 	}
 
-	queryEmbedding, err := r.embedder.Embed([]string{query})
+	queryEmbedding, err := r.embedder.Embed([]string{parsed.Text()})
 	if err != nil || len(queryEmbedding) == 0 {
 		return bm25Results[:min(k, len(bm25Results))], nil
 	}
@@ -85,9 +140,13 @@ func (r *HybridRetriever) Search(query string, k int) ([]domain.ScoredChunk, err
 	return reranked, nil
 }
 
-func (r *HybridRetriever) vectorSearch(query string, k int) ([]domain.ScoredChunk, error) {
+func (r *HybridRetriever) vectorSearch(parsed *ParsedQuery, k int) ([]domain.ScoredChunk, error) {
+	text := parsed.Text()
+	if text == "" {
+		return nil, nil
+	}
 
-	embeddings, err := r.embedder.Embed([]string{query})
+	embeddings, err := r.embedder.Embed([]string{text})
 	if err != nil {
 		return nil, err
 	}
@@ -107,16 +166,116 @@ func (r *HybridRetriever) vectorSearch(query string, k int) ([]domain.ScoredChun
 			continue
 		}
 		chunks = append(chunks, domain.ScoredChunk{
-			Chunk: chunk,
-			Score: result.Score,
+			Chunk:       chunk,
+			Score:       result.Score,
+			Explanation: &domain.Explanation{Vector: result.Score},
 		})
 	}
 
-	return chunks, nil
+	return parsed.FilterChunks(r.chunkStore, chunks), nil
 }
 
-func (r *HybridRetriever) vectorOnlySearch(query string, k int) ([]domain.ScoredChunk, error) {
-	return r.vectorSearch(query, k)
+func (r *HybridRetriever) vectorOnlySearch(parsed *ParsedQuery, k int) ([]domain.ScoredChunk, error) {
+	fetchK := k
+	if parsed.HasConstraints() {
+		fetchK = k * 10
+	}
+	results, err := r.vectorSearch(parsed, fetchK)
+	if err != nil {
+		return nil, err
+	}
+	return results[:min(k, len(results))], nil
+}
+
+func (r *HybridRetriever) rrfFusion(bm25Results, vectorResults []domain.ScoredChunk) []domain.ScoredChunk {
+	fused := make(map[string]*domain.ScoredChunk)
+	entry := func(result domain.ScoredChunk) *domain.ScoredChunk {
+		if sc, exists := fused[result.Chunk.ID]; exists {
+			return sc
+		}
+		sc := &domain.ScoredChunk{Chunk: result.Chunk, Explanation: result.Explanation.Clone()}
+		fused[result.Chunk.ID] = sc
+		return sc
+	}
+
+	for rank, result := range bm25Results {
+		sc := entry(result)
+		sc.Score += 1.0 / float64(r.rrfK+rank+1)
+		sc.Explanation.BM25Rank = rank + 1
+	}
+	for rank, result := range vectorResults {
+		sc := entry(result)
+		sc.Score += 1.0 / float64(r.rrfK+rank+1)
+		sc.Explanation.VectorRank = rank + 1
+		sc.Explanation.Vector = result.Score
+	}
+
+	results := make([]domain.ScoredChunk, 0, len(fused))
+	for _, sc := range fused {
+		sc.Explanation.Fusion = r.rrfFormula(sc.Explanation, sc.Score)
+		results = append(results, *sc)
+	}
+
+	sortByScore(results)
+	return results
+}
+
+func (r *HybridRetriever) rrfFormula(exp *domain.Explanation, score float64) string {
+	var terms []string
+	if exp.BM25Rank > 0 {
+		terms = append(terms, fmt.Sprintf("1/(%d+%d) [bm25]", r.rrfK, exp.BM25Rank))
+	}
+	if exp.VectorRank > 0 {
+		terms = append(terms, fmt.Sprintf("1/(%d+%d) [vector]", r.rrfK, exp.VectorRank))
+	}
+	return fmt.Sprintf("rrf: %s = %.4f", strings.Join(terms, " + "), score)
+}
+
+func (r *HybridRetriever) linearFormula(normalizedBM25, vectorScore, score float64) string {
+	return fmt.Sprintf("%s: %.2f*%.3f [bm25] + %.2f*%.3f [vector] = %.4f",
+		r.fusion, r.bm25Weight, normalizedBM25, 1.0-r.bm25Weight, vectorScore, score)
+}
+
+func (r *HybridRetriever) linearFusion(bm25Results, vectorResults []domain.ScoredChunk) []domain.ScoredChunk {
+	vectorScores := make(map[string]float64, len(vectorResults))
+	for _, result := range vectorResults {
+		vectorScores[result.Chunk.ID] = result.Score
+	}
+
+	results := r.combineScores(bm25Results, vectorScores)
+
+	seen := make(map[string]bool, len(bm25Results))
+	for _, result := range bm25Results {
+		seen[result.Chunk.ID] = true
+	}
+
+	vectorWeight := 1.0 - r.bm25Weight
+	for _, result := range vectorResults {
+		if seen[result.Chunk.ID] {
+			continue
+		}
+		score := vectorWeight * result.Score
+		exp := result.Explanation.Clone()
+		exp.Vector = result.Score
+		exp.Fusion = r.linearFormula(0, result.Score, score)
+		results = append(results, domain.ScoredChunk{
+			Chunk:       result.Chunk,
+			Score:       score,
+			Explanation: exp,
+		})
+	}
+
+	sortByScore(results)
+	return results
+}
+
+func sortByScore(results []domain.ScoredChunk) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Chunk.ID < results[j].Chunk.ID
+	})
 }
 
 func (r *HybridRetriever) combineScores(bm25Results []domain.ScoredChunk, vectorScores map[string]float64) []domain.ScoredChunk {
@@ -141,16 +300,19 @@ func (r *HybridRetriever) combineScores(bm25Results []domain.ScoredChunk, vector
 
 		combinedScore := r.bm25Weight*normalizedBM25 + vectorWeight*vectorScore
 
+		exp := result.Explanation.Clone()
+		exp.BM25Normalized = normalizedBM25
+		exp.Vector = vectorScore
+		exp.Fusion = r.linearFormula(normalizedBM25, vectorScore, combinedScore)
+
 		results = append(results, domain.ScoredChunk{
-			Chunk: result.Chunk,
-			Score: combinedScore,
+			Chunk:       result.Chunk,
+			Score:       combinedScore,
+			Explanation: exp,
 		})
 	}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
-
+	sortByScore(results)
 	return results
 }
 
@@ -0,0 +1,179 @@
+package retriever
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/adapter/store"
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+type topicEmbedder struct{}
+
+func (topicEmbedder) Embed(texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := []float32{0.1, 0.1}
+		if strings.Contains(text, "login") || strings.Contains(text, "sign-in") || strings.Contains(text, "credentials") {
+			vec[0] = 1
+		}
+		if strings.Contains(text, "database") {
+			vec[1] = 1
+		}
+		out[i] = vec
+	}
+	return out, nil
+}
+
+func (topicEmbedder) Dimension() int    { return 2 }
+func (topicEmbedder) ModelName() string { return "topic" }
+
+func newHybridFixture(t *testing.T, fusion string) *HybridRetriever {
+	t.Helper()
+
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	tokenizer := analyzer.NewTokenizer(true)
+	embedder := topicEmbedder{}
+	vectors, err := store.NewBoltVectorStore(st.DB(), embedder.Dimension())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	texts := map[string]string{
+		"keyword":  "login handler validates the login form",
+		"semantic": "verify user credentials during sign-in",
+		"other":    "database connection pooling",
+	}
+
+	totalTokens := 0
+	var items []port.VectorItem
+	for id, text := range texts {
+		tokens := tokenizer.Tokenize(text)
+		totalTokens += len(tokens)
+		if err := st.PutChunk(domain.Chunk{ID: id, DocID: "doc1", Tokens: tokens, Text: text}); err != nil {
+			t.Fatal(err)
+		}
+		tf := make(map[string]int)
+		for _, token := range tokens {
+			tf[token]++
+		}
+		for term, count := range tf {
+			if err := st.PutPosting(term, id, count); err != nil {
+				t.Fatal(err)
+			}
+		}
+		vecs, _ := embedder.Embed([]string{text})
+		items = append(items, port.VectorItem{ID: id, Vector: vecs[0]})
+	}
+	if err := vectors.Upsert(items); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateStats(domain.Stats{TotalDocs: 1, TotalChunks: len(texts), AvgChunkLen: float64(totalTokens) / float64(len(texts))}); err != nil {
+		t.Fatal(err)
+	}
+
+	bm25 := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
+	r, err := NewHybridRetriever(bm25, vectors, embedder, st, fusion, 60, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func resultIDs(results []domain.ScoredChunk) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Chunk.ID
+	}
+	return ids
+}
+
+func TestHybridRetriever_RRFIncludesSemanticOnlyHits(t *testing.T) {
+	r := newHybridFixture(t, FusionRRF)
+
+	results, err := r.Search("login", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := resultIDs(results)
+	if len(ids) < 2 || ids[0] != "keyword" || ids[1] != "semantic" {
+		t.Fatalf("expected keyword hit then semantic-only hit, got %v", ids)
+	}
+
+	want := 1.0/61 + 1.0/61
+	if math.Abs(results[0].Score-want) > 1e-9 {
+		t.Errorf("expected RRF score %f for top hit, got %f", want, results[0].Score)
+	}
+
+	exp := results[1].Explanation
+	if exp == nil || exp.BM25Rank != 0 || exp.VectorRank != 2 || exp.Vector <= 0 {
+		t.Errorf("expected semantic hit to be explained by vector rank only, got %+v", exp)
+	}
+	if exp != nil && exp.Fusion != "rrf: 1/(60+2) [vector] = 0.0161" {
+		t.Errorf("unexpected fusion formula: %q", exp.Fusion)
+	}
+}
+
+func TestHybridRetriever_BM25SubsetOnlyRescoresKeywordHits(t *testing.T) {
+	r := newHybridFixture(t, FusionBM25Subset)
+
+	results, err := r.Search("login", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := resultIDs(results)
+	if len(ids) != 1 || ids[0] != "keyword" {
+		t.Errorf("expected only the BM25 hit, got %v", ids)
+	}
+}
+
+func TestHybridRetriever_LinearFusesBothPools(t *testing.T) {
+	r := newHybridFixture(t, FusionLinear)
+
+	results, err := r.Search("login", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[string]bool)
+	for _, id := range resultIDs(results) {
+		found[id] = true
+	}
+	if !found["keyword"] || !found["semantic"] {
+		t.Errorf("expected keyword and semantic hits, got %v", resultIDs(results))
+	}
+}
+
+func TestNewHybridRetriever_RejectsUnknownFusion(t *testing.T) {
+	if _, err := NewHybridRetriever(nil, nil, nil, nil, "rff", 60, 0.5); err == nil {
+		t.Error("expected an error for an unknown fusion method")
+	}
+	if r, err := NewHybridRetriever(nil, nil, nil, nil, "", 60, 0.5); err != nil || r.fusion != FusionLinear {
+		t.Errorf("expected an empty fusion method to default to rrf, got %v", err)
+	}
+}
+
+func TestHybridRetriever_CombineScoresBreaksTiesByChunkID(t *testing.T) {
+	r := &HybridRetriever{bm25Weight: 0.5}
+	bm25 := []domain.ScoredChunk{
+		{Chunk: domain.Chunk{ID: "c"}, Score: 2},
+		{Chunk: domain.Chunk{ID: "b"}, Score: 1},
+		{Chunk: domain.Chunk{ID: "a"}, Score: 1},
+	}
+	results := r.combineScores(bm25, map[string]float64{"c": 1, "b": 1, "a": 1})
+	if ids := resultIDs(results); !reflect.DeepEqual(ids, []string{"c", "a", "b"}) {
+		t.Errorf("expected equal scores ordered by chunk ID, got %v", ids)
+	}
+}
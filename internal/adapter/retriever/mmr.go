@@ -38,10 +38,15 @@ func (r *MMRReranker) Rerank(candidates []domain.ScoredChunk, k int) []domain.Sc
 	selected := make([]domain.ScoredChunk, 0, k)
 	remaining := make([]domain.ScoredChunk, len(candidates))
 	copy(remaining, candidates)
+	ranks := make([]int, len(candidates))
+	for i := range ranks {
+		ranks[i] = i + 1
+	}
 
 	for len(selected) < k && len(remaining) > 0 {
 		bestIdx := -1
 		bestMMR := -1e9
+		var best domain.MMRExplanation
 
 		for i, candidate := range remaining {
 
@@ -64,6 +69,13 @@ func (r *MMRReranker) Rerank(candidates []domain.ScoredChunk, k int) []domain.Sc
 			if mmr > bestMMR {
 				bestMMR = mmr
 				bestIdx = i
+				best = domain.MMRExplanation{
+					Lambda:        r.lambda,
+					Relevance:     relevance,
+					MaxSimilarity: maxSim,
+					Penalty:       (1 - r.lambda) * maxSim,
+					Score:         mmr,
+				}
 			}
 		}
 
@@ -72,9 +84,15 @@ func (r *MMRReranker) Rerank(candidates []domain.ScoredChunk, k int) []domain.Sc
 			break
 		}
 
-		selected = append(selected, remaining[bestIdx])
+		chosen := remaining[bestIdx]
+		chosen.Explanation = chosen.Explanation.Clone()
+		best.RankBefore = ranks[bestIdx]
+		best.RankAfter = len(selected) + 1
+		chosen.Explanation.MMR = &best
+		selected = append(selected, chosen)
 
 		remaining = append(remaining[:bestIdx], remaining[bestIdx+1:]...)
+		ranks = append(ranks[:bestIdx], ranks[bestIdx+1:]...)
 	}
 
 	return selected
@@ -178,3 +178,37 @@ func floatEquals(a, b, tolerance float64) bool {
 	}
 	return diff < tolerance
 }
+
+func TestMMRExplainsRankChanges(t *testing.T) {
+	reranker := NewMMRReranker(0.5, 0.95)
+
+	candidates := []domain.ScoredChunk{
+		{Chunk: domain.Chunk{ID: "c1", Tokens: []string{"auth", "login"}}, Score: 1.0},
+		{Chunk: domain.Chunk{ID: "c2", Tokens: []string{"auth", "login", "user"}}, Score: 0.9},
+		{Chunk: domain.Chunk{ID: "c3", Tokens: []string{"database", "query"}}, Score: 0.8},
+	}
+
+	results := reranker.Rerank(candidates, 3)
+	if len(results) != 3 || results[1].Chunk.ID != "c3" {
+		t.Fatalf("expected c3 to be promoted to second place, got %+v", results)
+	}
+
+	for _, r := range results {
+		if r.Explanation == nil || r.Explanation.MMR == nil {
+			t.Fatalf("expected MMR explanation for %s", r.Chunk.ID)
+		}
+	}
+
+	promoted := results[1].Explanation.MMR
+	if promoted.RankBefore != 3 || promoted.RankAfter != 2 {
+		t.Errorf("expected c3 to move from rank 3 to 2, got %d -> %d", promoted.RankBefore, promoted.RankAfter)
+	}
+
+	demoted := results[2].Explanation.MMR
+	if demoted.Penalty <= 0 || demoted.MaxSimilarity <= 0 {
+		t.Errorf("expected a similarity penalty for c2, got %+v", demoted)
+	}
+	if candidates[1].Explanation != nil {
+		t.Error("expected input candidates to be left untouched")
+	}
+}
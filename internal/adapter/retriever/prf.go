@@ -0,0 +1,142 @@
+package retriever
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+// PRFRetriever expands queries with RM3 pseudo-relevance feedback taken from
+// BM25 and runs the expanded query through the base retriever.
+type PRFRetriever struct {
+	base           port.Retriever
+	feedback       port.Retriever
+	tokenizer      port.Tokenizer
+	feedbackDocs   int
+	expansionTerms int
+	originalWeight float64
+}
+
+func NewPRFRetriever(base, feedback port.Retriever, tokenizer port.Tokenizer, feedbackDocs, expansionTerms int, originalWeight float64) *PRFRetriever {
+	if feedbackDocs <= 0 {
+		feedbackDocs = 10
+	}
+	if expansionTerms <= 0 {
+		expansionTerms = 10
+	}
+	if originalWeight <= 0 || originalWeight >= 1 {
+		originalWeight = 0.5
+	}
+
+	return &PRFRetriever{
+		base:           base,
+		feedback:       feedback,
+		tokenizer:      tokenizer,
+		feedbackDocs:   feedbackDocs,
+		expansionTerms: expansionTerms,
+		originalWeight: originalWeight,
+	}
+}
+
+func (r *PRFRetriever) Search(query string, k int) ([]domain.ScoredChunk, error) {
+	feedback, err := r.feedback.Search(query, r.feedbackDocs)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded, err := r.ExpandQuery(query, feedback)
+	if err != nil {
+		return nil, err
+	}
+	return r.base.Search(expanded, k)
+}
+
+func (r *PRFRetriever) ExpandQuery(query string, feedback []domain.ScoredChunk) (string, error) {
+	parsed, err := ParseQuery(query, r.tokenizer)
+	if err != nil {
+		return "", err
+	}
+
+	queryTerms := make(map[string]struct{})
+	for _, token := range append(parsed.ScoringTokens(), parsed.ExcludedTokens()...) {
+		queryTerms[token] = struct{}{}
+	}
+	if len(queryTerms) == 0 {
+		return query, nil
+	}
+
+	total := 0.0
+	for _, result := range feedback {
+		total += max(result.Score, 0)
+	}
+
+	model := make(map[string]float64)
+	for _, result := range feedback {
+		tokens := result.Chunk.Tokens
+		if len(tokens) == 0 {
+			continue
+		}
+		docWeight := 1 / float64(len(feedback))
+		if total > 0 {
+			docWeight = max(result.Score, 0) / total
+		}
+		for _, token := range tokens {
+			model[token] += docWeight / float64(len(tokens))
+		}
+	}
+
+	type weightedTerm struct {
+		term   string
+		weight float64
+	}
+	candidates := make([]weightedTerm, 0, len(model))
+	for term, weight := range model {
+		if _, exists := queryTerms[term]; exists || !r.expandable(term) {
+			continue
+		}
+		candidates = append(candidates, weightedTerm{term, weight})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].weight != candidates[j].weight {
+			return candidates[i].weight > candidates[j].weight
+		}
+		return candidates[i].term < candidates[j].term
+	})
+	if len(candidates) > r.expansionTerms {
+		candidates = candidates[:r.expansionTerms]
+	}
+
+	mass := 0.0
+	for _, c := range candidates {
+		mass += c.weight
+	}
+	if mass == 0 {
+		return query, nil
+	}
+
+	// RM3 interpolation: each expansion term weighs (1-λ)/λ × |q| × P(w|R).
+	scale := (1 - r.originalWeight) / r.originalWeight * float64(len(parsed.ScoringTokens()))
+	clauses := []string{query}
+	for _, c := range candidates {
+		boost := math.Min(scale*c.weight/mass, 1)
+		if boost < 0.001 {
+			continue
+		}
+		clauses = append(clauses, c.term+"^"+strconv.FormatFloat(boost, 'f', 3, 64))
+	}
+	return strings.Join(clauses, " "), nil
+}
+
+// Terms must tokenize back to themselves so the query matches the index term.
+func (r *PRFRetriever) expandable(term string) bool {
+	if strings.IndexFunc(term, unicode.IsLetter) < 0 {
+		return false
+	}
+	tokens := r.tokenizer.Tokenize(term)
+	return len(tokens) == 1 && tokens[0] == term
+}
@@ -0,0 +1,117 @@
+package retriever
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/adapter/store"
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+func TestPRFRetriever_ExpandQuery(t *testing.T) {
+	tokenizer := analyzer.NewTokenizer(false)
+	prf := NewPRFRetriever(nil, nil, tokenizer, 2, 2, 0.5)
+
+	feedback := []domain.ScoredChunk{
+		{Chunk: domain.Chunk{Tokens: []string{"retry", "backoff", "backoff", "jitter"}}, Score: 3},
+		{Chunk: domain.Chunk{Tokens: []string{"retry", "jitter", "404", "flaky"}}, Score: 1},
+	}
+	expanded, err := prf.ExpandQuery("retry -flaky", feedback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expanded != "retry -flaky backoff^0.600 jitter^0.400" {
+		t.Errorf("unexpected expansion %q", expanded)
+	}
+
+	parsed, err := ParseQuery(expanded, tokenizer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights := parsed.TermWeights(); !reflect.DeepEqual(weights, map[string]float64{"retry": 1, "backoff": 0.6, "jitter": 0.4}) {
+		t.Errorf("unexpected term weights %v", weights)
+	}
+}
+
+func TestPRFRetriever_FindsVocabularyMismatch(t *testing.T) {
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	tokenizer := analyzer.NewTokenizer(false)
+	texts := map[string]string{
+		"retry":   "retry the request with exponential backoff and jitter",
+		"backoff": "exponential backoff with jitter between attempts",
+		"other":   "render dashboard charts for the admin page",
+	}
+
+	file := port.IndexedFile{
+		Doc:      domain.Document{ID: "doc1", Path: "/repo/a.txt"},
+		Postings: make(map[string]map[string]int),
+	}
+	for id, text := range texts {
+		tokens := tokenizer.Tokenize(text)
+		file.Chunks = append(file.Chunks, domain.Chunk{ID: id, DocID: "doc1", Tokens: tokens, Text: text})
+		for _, token := range tokens {
+			if file.Postings[token] == nil {
+				file.Postings[token] = make(map[string]int)
+			}
+			file.Postings[token][id]++
+		}
+	}
+	if err := st.BatchIndex([]port.IndexedFile{file}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateStats(domain.Stats{TotalDocs: 1, TotalChunks: 3, AvgChunkLen: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	bm25 := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
+	results, err := bm25.Search("retry", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(results); !reflect.DeepEqual(ids, []string{"retry"}) {
+		t.Fatalf("expected a single literal match, got %v", ids)
+	}
+
+	prf := NewPRFRetriever(bm25, bm25, tokenizer, 1, 3, 0.5)
+	results, err = prf.Search("retry", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(results); !reflect.DeepEqual(ids, []string{"retry", "backoff"}) {
+		t.Fatalf("expected feedback terms to surface the backoff chunk, got %v", ids)
+	}
+}
+
+type recordingRetriever struct {
+	queries []string
+}
+
+func (r *recordingRetriever) Search(query string, k int) ([]domain.ScoredChunk, error) {
+	r.queries = append(r.queries, query)
+	return nil, nil
+}
+
+func TestPRFRetriever_FeedbackFromSeparateRetriever(t *testing.T) {
+	tokenizer := analyzer.NewTokenizer(false)
+	feedback := &recordingRetriever{}
+	base := &recordingRetriever{}
+	prf := NewPRFRetriever(base, feedback, tokenizer, 1, 3, 0.5)
+
+	if _, err := prf.Search("retry", 10); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(feedback.queries, []string{"retry"}) {
+		t.Errorf("expected feedback to come from the feedback retriever, got %v", feedback.queries)
+	}
+	if !reflect.DeepEqual(base.queries, []string{"retry"}) {
+		t.Errorf("expected the query to run through the base retriever, got %v", base.queries)
+	}
+}
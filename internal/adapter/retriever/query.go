@@ -0,0 +1,482 @@
+package retriever
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+type QueryClause struct {
+	Raw      string
+	Tokens   []string
+	Variants [][]string // analyses in the other indexed languages
+	Phrase   bool
+	Boost    float64
+}
+
+type variantTokenizer interface {
+	TokenizeVariants(text string) [][]string
+}
+
+type QueryGroup struct {
+	Clauses  []QueryClause
+	Required bool
+	Excluded bool
+}
+
+type QueryFilter struct {
+	Field   string
+	Op      string
+	Value   string
+	Negated bool
+	date    time.Time
+}
+
+type ParsedQuery struct {
+	Groups  []QueryGroup
+	Filters []QueryFilter
+}
+
+var langAliases = map[string]string{
+	"py":     "python",
+	"js":     "javascript",
+	"ts":     "typescript",
+	"rs":     "rust",
+	"rb":     "ruby",
+	"md":     "markdown",
+	"golang": "go",
+	"c++":    "cpp",
+	"yml":    "yaml",
+	"txt":    "text",
+}
+
+func ParseQuery(query string, tokenizer port.Tokenizer) (*ParsedQuery, error) {
+	parsed := &ParsedQuery{}
+	joinNext := false
+
+	for _, word := range splitQuery(query) {
+		if word == "OR" {
+			joinNext = len(parsed.Groups) > 0
+			continue
+		}
+
+		negated := false
+		required := false
+		body := word
+		switch {
+		case strings.HasPrefix(body, "-") && len(body) > 1:
+			negated = true
+			body = body[1:]
+		case strings.HasPrefix(body, "+") && len(body) > 1:
+			required = true
+			body = body[1:]
+		}
+
+		if filter, ok, err := parseFilter(body); ok || err != nil {
+			if err != nil {
+				return nil, err
+			}
+			filter.Negated = negated
+			parsed.Filters = append(parsed.Filters, filter)
+			joinNext = false
+			continue
+		}
+
+		clause := QueryClause{Raw: body, Boost: 1}
+		if i := strings.LastIndex(body, "^"); i > 0 {
+			if boost, err := strconv.ParseFloat(body[i+1:], 64); err == nil && boost > 0 {
+				body, clause.Boost = body[:i], boost
+			}
+		}
+		if len(body) >= 2 && strings.HasPrefix(body, `"`) && strings.HasSuffix(body, `"`) {
+			clause.Raw = body[1 : len(body)-1]
+			clause.Phrase = true
+		} else {
+			clause.Raw = strings.Trim(body, `"`)
+		}
+		if vt, ok := tokenizer.(variantTokenizer); ok {
+			variants := vt.TokenizeVariants(clause.Raw)
+			clause.Tokens, clause.Variants = variants[0], variants[1:]
+		} else {
+			clause.Tokens = tokenizer.Tokenize(clause.Raw)
+		}
+		if len(clause.Tokens) == 0 {
+			joinNext = false
+			continue
+		}
+
+		if negated {
+			parsed.Groups = append(parsed.Groups, QueryGroup{Clauses: []QueryClause{clause}, Excluded: true})
+			joinNext = false
+			continue
+		}
+
+		last := len(parsed.Groups) - 1
+		if joinNext && !parsed.Groups[last].Excluded {
+			parsed.Groups[last].Clauses = append(parsed.Groups[last].Clauses, clause)
+			parsed.Groups[last].Required = parsed.Groups[last].Required || required || clause.Phrase
+		} else {
+			parsed.Groups = append(parsed.Groups, QueryGroup{
+				Clauses:  []QueryClause{clause},
+				Required: required || clause.Phrase,
+			})
+		}
+		joinNext = false
+	}
+
+	return parsed, nil
+}
+
+func splitQuery(query string) []string {
+	var words []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+
+	return words
+}
+
+func parseFilter(word string) (QueryFilter, bool, error) {
+	field, value, found := strings.Cut(word, ":")
+	if !found || value == "" {
+		return QueryFilter{}, false, nil
+	}
+
+	filter := QueryFilter{Field: strings.ToLower(field), Value: value}
+	switch filter.Field {
+	case "path":
+	case "lang":
+		filter.Value = strings.ToLower(value)
+		if alias, exists := langAliases[filter.Value]; exists {
+			filter.Value = alias
+		}
+	case "ext":
+		filter.Value = "." + strings.TrimPrefix(strings.ToLower(value), ".")
+	case "modified":
+		for _, op := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(value, op) {
+				filter.Op = op
+				value = value[len(op):]
+				break
+			}
+		}
+		if filter.Op == "" {
+			filter.Op = ">="
+		}
+		date, err := parseQueryDate(value)
+		if err != nil {
+			return QueryFilter{}, false, fmt.Errorf("invalid modified filter %q: %w", word, err)
+		}
+		filter.Value = value
+		filter.date = date
+	default:
+		return QueryFilter{}, false, nil
+	}
+
+	return filter, true, nil
+}
+
+func parseQueryDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02T15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC3339 date")
+}
+
+func (q *ParsedQuery) ScoringTokens() []string {
+	var tokens []string
+	for _, group := range q.Groups {
+		if group.Excluded {
+			continue
+		}
+		for _, clause := range group.Clauses {
+			tokens = append(tokens, clause.Tokens...)
+		}
+	}
+	return tokens
+}
+
+// A token that appears in several clauses keeps its largest boost.
+func (q *ParsedQuery) TermWeights() map[string]float64 {
+	weights := make(map[string]float64)
+	for _, group := range q.Groups {
+		if group.Excluded {
+			continue
+		}
+		for _, clause := range group.Clauses {
+			boost := clause.Boost
+			if boost == 0 {
+				boost = 1
+			}
+			for _, token := range clause.allTokens() {
+				weights[token] = max(weights[token], boost)
+			}
+		}
+	}
+	return weights
+}
+
+// VariantTokens are the scored tokens that only other query languages produced.
+func (q *ParsedQuery) VariantTokens() []string {
+	var tokens []string
+	for _, group := range q.Groups {
+		if group.Excluded {
+			continue
+		}
+		for _, clause := range group.Clauses {
+			for _, variant := range clause.Variants {
+				tokens = append(tokens, variant...)
+			}
+		}
+	}
+	return tokens
+}
+
+func (q *ParsedQuery) ExcludedTokens() []string {
+	var tokens []string
+	for _, group := range q.Groups {
+		if !group.Excluded {
+			continue
+		}
+		for _, clause := range group.Clauses {
+			tokens = append(tokens, clause.allTokens()...)
+		}
+	}
+	return tokens
+}
+
+func (q *ParsedQuery) Text() string {
+	var parts []string
+	for _, group := range q.Groups {
+		if group.Excluded {
+			continue
+		}
+		for _, clause := range group.Clauses {
+			parts = append(parts, clause.Raw)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (q *ParsedQuery) HasConstraints() bool {
+	if len(q.Filters) > 0 {
+		return true
+	}
+	for _, group := range q.Groups {
+		if group.Required || group.Excluded {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *ParsedQuery) MatchTerms(hasTerm func(term string) bool, hasPhrase func(tokens []string) bool) bool {
+	for _, group := range q.Groups {
+		if !group.Required && !group.Excluded {
+			continue
+		}
+
+		matched := false
+		for _, clause := range group.Clauses {
+			if clause.matches(hasTerm, hasPhrase) {
+				matched = true
+				break
+			}
+		}
+
+		if group.Excluded && matched {
+			return false
+		}
+		if group.Required && !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (c QueryClause) allTokens() []string {
+	tokens := c.Tokens
+	for _, variant := range c.Variants {
+		tokens = append(tokens[:len(tokens):len(tokens)], variant...)
+	}
+	return tokens
+}
+
+// A clause matches when its tokens in any query language do.
+func (c QueryClause) matches(hasTerm func(term string) bool, hasPhrase func(tokens []string) bool) bool {
+	for _, tokens := range append([][]string{c.Tokens}, c.Variants...) {
+		if matchesTokens(tokens, c.Phrase, hasTerm, hasPhrase) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesTokens(tokens []string, phrase bool, hasTerm func(term string) bool, hasPhrase func(tokens []string) bool) bool {
+	for _, token := range tokens {
+		if !hasTerm(token) {
+			return false
+		}
+	}
+	if phrase && len(tokens) > 1 {
+		return hasPhrase(tokens)
+	}
+	return true
+}
+
+func (q *ParsedQuery) MatchTokens(tokens []string) bool {
+	set := make(map[string]struct{}, len(tokens))
+	for _, t := range tokens {
+		set[t] = struct{}{}
+	}
+	return q.MatchTerms(
+		func(term string) bool {
+			_, exists := set[term]
+			return exists
+		},
+		func(phrase []string) bool {
+			return containsPhrase(tokens, phrase)
+		},
+	)
+}
+
+func (q *ParsedQuery) MatchDoc(doc domain.Document) bool {
+	matchedFields := make(map[string]bool)
+	seenFields := make(map[string]bool)
+
+	for _, filter := range q.Filters {
+		matched := filter.matches(doc)
+		if filter.Negated {
+			if matched {
+				return false
+			}
+			continue
+		}
+		seenFields[filter.Field] = true
+		if matched {
+			matchedFields[filter.Field] = true
+		}
+	}
+
+	for field := range seenFields {
+		if !matchedFields[field] {
+			return false
+		}
+	}
+	return true
+}
+
+func (f QueryFilter) matches(doc domain.Document) bool {
+	switch f.Field {
+	case "path":
+		return matchPathFilter(f.Value, doc.Path)
+	case "lang":
+		return strings.EqualFold(doc.Lang, f.Value)
+	case "ext":
+		return strings.EqualFold(filepath.Ext(doc.Path), f.Value)
+	case "modified":
+		switch f.Op {
+		case ">":
+			return doc.ModTime.After(f.date)
+		case "<":
+			return doc.ModTime.Before(f.date)
+		case "<=":
+			return !doc.ModTime.After(f.date)
+		case "=":
+			y1, m1, d1 := doc.ModTime.Date()
+			y2, m2, d2 := f.date.Date()
+			return y1 == y2 && m1 == m2 && d1 == d2
+		default:
+			return !doc.ModTime.Before(f.date)
+		}
+	}
+	return true
+}
+
+func matchPathFilter(pattern, path string) bool {
+	path = filepath.ToSlash(path)
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+
+	candidates := []string{pattern, "**/" + pattern}
+	if !strings.ContainsAny(pattern, "*?[{") {
+		candidates = append(candidates, "**/"+strings.TrimSuffix(pattern, "/")+"/**")
+	}
+
+	for _, p := range candidates {
+		if matched, err := doublestar.Match(p, path); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPhrase(tokens, phrase []string) bool {
+	if len(phrase) == 0 {
+		return true
+	}
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		match := true
+		for j, p := range phrase {
+			if tokens[i+j] != p {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *ParsedQuery) FilterChunks(store port.IndexStore, results []domain.ScoredChunk) []domain.ScoredChunk {
+	if !q.HasConstraints() {
+		return results
+	}
+
+	docMatches := make(map[string]bool)
+	filtered := results[:0]
+	for _, result := range results {
+		if len(q.Filters) > 0 {
+			matched, exists := docMatches[result.Chunk.DocID]
+			if !exists {
+				doc, err := store.GetDoc(result.Chunk.DocID)
+				matched = err == nil && q.MatchDoc(doc)
+				docMatches[result.Chunk.DocID] = matched
+			}
+			if !matched {
+				continue
+			}
+		}
+		if q.MatchTokens(result.Chunk.Tokens) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
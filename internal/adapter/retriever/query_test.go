@@ -0,0 +1,194 @@
+package retriever
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/adapter/store"
+	"rag/internal/domain"
+)
+
+func TestParseQuery(t *testing.T) {
+	tokenizer := analyzer.NewTokenizer(false)
+
+	parsed, err := ParseQuery(`"connection pool" +retry -flaky timeout OR deadline lang:py path:internal/** -ext:md modified:>2025-01-01`, tokenizer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed.Groups) != 4 {
+		t.Fatalf("expected 4 groups, got %+v", parsed.Groups)
+	}
+	phrase := parsed.Groups[0]
+	if !phrase.Required || !phrase.Clauses[0].Phrase || !reflect.DeepEqual(phrase.Clauses[0].Tokens, []string{"connection", "pool"}) {
+		t.Errorf("unexpected phrase group: %+v", phrase)
+	}
+	if !parsed.Groups[1].Required || !parsed.Groups[2].Excluded {
+		t.Errorf("expected +retry required and -flaky excluded, got %+v %+v", parsed.Groups[1], parsed.Groups[2])
+	}
+	if or := parsed.Groups[3]; or.Required || len(or.Clauses) != 2 {
+		t.Errorf("expected optional OR group with two clauses, got %+v", or)
+	}
+
+	if len(parsed.Filters) != 4 {
+		t.Fatalf("expected 4 filters, got %+v", parsed.Filters)
+	}
+	if f := parsed.Filters[0]; f.Field != "lang" || f.Value != "python" {
+		t.Errorf("expected lang alias to resolve, got %+v", f)
+	}
+	if f := parsed.Filters[2]; f.Field != "ext" || f.Value != ".md" || !f.Negated {
+		t.Errorf("expected negated ext filter, got %+v", f)
+	}
+	if f := parsed.Filters[3]; f.Op != ">" || !f.date.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected modified filter: %+v", f)
+	}
+
+	if text := parsed.Text(); text != "connection pool retry timeout deadline" {
+		t.Errorf("expected free text without exclusions or filters, got %q", text)
+	}
+
+	if _, err := ParseQuery("modified:>yesterday", tokenizer); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
+
+func TestParseQuery_LanguageVariants(t *testing.T) {
+	tokenizer := analyzer.NewTokenizerWithOptions(analyzer.TokenizerOptions{
+		Stemming:       true,
+		Language:       analyzer.LanguageAuto,
+		QueryLanguages: []string{analyzer.LanguageGerman},
+	})
+	parsed, err := ParseQuery("Verbindungen", tokenizer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clause := parsed.Groups[0].Clauses[0]
+	if !reflect.DeepEqual(clause.Variants, [][]string{{"verbind"}}) {
+		t.Fatalf("expected a German variant, got %+v", clause)
+	}
+	if _, ok := parsed.TermWeights()["verbind"]; !ok {
+		t.Error("expected the variant token to be weighted")
+	}
+	hasTerm := func(term string) bool { return term == "verbind" }
+	if !clause.matches(hasTerm, func([]string) bool { return true }) {
+		t.Error("expected the clause to match through its German variant")
+	}
+}
+
+func TestParsedQuery_MatchDoc(t *testing.T) {
+	tokenizer := analyzer.NewTokenizer(false)
+	doc := domain.Document{
+		Path:    "/repo/internal/store/db.go",
+		Lang:    "go",
+		ModTime: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"path:internal/**", true},
+		{"path:internal", true},
+		{"path:cmd/**", false},
+		{"path:**/*.go", true},
+		{"lang:go", true},
+		{"lang:python OR lang:go", true},
+		{"lang:python lang:go", true},
+		{"lang:go ext:md", false},
+		{"-ext:go", false},
+		{"modified:>2025-01-01", true},
+		{"modified:<2025-01-01", false},
+		{"modified:=2025-03-01", true},
+	}
+
+	for _, tt := range tests {
+		parsed, err := ParseQuery(tt.query, tokenizer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := parsed.MatchDoc(doc); got != tt.want {
+			t.Errorf("MatchDoc(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestBM25Retriever_QueryLanguage(t *testing.T) {
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	tokenizer := analyzer.NewTokenizer(true)
+	docs := []domain.Document{
+		{ID: "go", Path: "/repo/internal/pool/pool.go", Lang: "go", ModTime: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "md", Path: "/repo/docs/pool.md", Lang: "markdown", ModTime: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	chunks := []domain.Chunk{
+		{ID: "ordered", DocID: "go", Text: "the connection pool retries on timeout"},
+		{ID: "reversed", DocID: "go", Text: "pool connection setup without retries"},
+		{ID: "doc", DocID: "md", Text: "connection pool tuning guide"},
+	}
+
+	for _, doc := range docs {
+		if err := st.PutDoc(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	totalTokens := 0
+	for _, chunk := range chunks {
+		chunk.Tokens = tokenizer.Tokenize(chunk.Text)
+		totalTokens += len(chunk.Tokens)
+		if err := st.PutChunk(chunk); err != nil {
+			t.Fatal(err)
+		}
+		tf := make(map[string]int)
+		for _, token := range chunk.Tokens {
+			tf[token]++
+		}
+		for term, count := range tf {
+			if err := st.PutPosting(term, chunk.ID, count); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := st.UpdateStats(domain.Stats{TotalDocs: len(docs), TotalChunks: len(chunks), AvgChunkLen: float64(totalTokens) / float64(len(chunks))}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"connection pool", []string{"doc", "ordered", "reversed"}},
+		{`"connection pool"`, []string{"doc", "ordered"}},
+		{`"connection pool" lang:go`, []string{"ordered"}},
+		{"connection -retries", []string{"doc"}},
+		{"+timeout OR +guide pool", []string{"doc", "ordered"}},
+		{"pool ext:md", []string{"doc"}},
+		{"pool path:internal/**", []string{"ordered", "reversed"}},
+		{"pool modified:<2025-01-01", []string{"doc"}},
+		{"lang:go", nil},
+	}
+
+	for _, tt := range tests {
+		results, err := r.Search(tt.query, 10)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", tt.query, err)
+		}
+		got := resultIDs(results)
+		sort.Strings(got)
+		if len(got) == 0 {
+			got = nil
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
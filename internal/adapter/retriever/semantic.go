@@ -11,17 +11,20 @@ type SemanticRetriever struct {
 	vectorStore port.VectorStore
 	embedder    port.Embedder
 	chunkStore  port.IndexStore
+	tokenizer   port.Tokenizer
 }
 
 func NewSemanticRetriever(
 	vectorStore port.VectorStore,
 	embedder port.Embedder,
 	chunkStore port.IndexStore,
+	tokenizer port.Tokenizer,
 ) *SemanticRetriever {
 	return &SemanticRetriever{
 		vectorStore: vectorStore,
 		embedder:    embedder,
 		chunkStore:  chunkStore,
+		tokenizer:   tokenizer,
 	}
 }
 
@@ -30,7 +33,21 @@ func (r *SemanticRetriever) Search(query string, k int) ([]domain.ScoredChunk, e
 		return nil, fmt.Errorf("semantic search not available: embeddings not configured")
 	}
 
-	embeddings, err := r.embedder.Embed([]string{query})
+	parsed, err := ParseQuery(query, r.tokenizer)
+	if err != nil {
+		return nil, err
+	}
+	text := parsed.Text()
+	if text == "" {
+		return nil, nil
+	}
+
+	fetchK := k
+	if parsed.HasConstraints() {
+		fetchK = k * 10
+	}
+
+	embeddings, err := r.embedder.Embed([]string{text})
 	if err != nil {
 		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
@@ -38,7 +55,7 @@ func (r *SemanticRetriever) Search(query string, k int) ([]domain.ScoredChunk, e
 		return nil, fmt.Errorf("embedding returned empty result")
 	}
 
-	results, err := r.vectorStore.Search(embeddings[0], k)
+	results, err := r.vectorStore.Search(embeddings[0], fetchK)
 	if err != nil {
 		return nil, fmt.Errorf("vector search failed: %w", err)
 	}
@@ -50,10 +67,12 @@ func (r *SemanticRetriever) Search(query string, k int) ([]domain.ScoredChunk, e
 			continue
 		}
 		chunks = append(chunks, domain.ScoredChunk{
-			Chunk: chunk,
-			Score: result.Score,
+			Chunk:       chunk,
+			Score:       result.Score,
+			Explanation: &domain.Explanation{Vector: result.Score},
 		})
 	}
 
-	return chunks, nil
+	chunks = parsed.FilterChunks(r.chunkStore, chunks)
+	return chunks[:min(k, len(chunks))], nil
 }
@@ -0,0 +1,152 @@
+package retriever
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"rag/internal/port"
+)
+
+var DefaultSynonymGroups = [][]string{
+	{"auth", "authn", "authentication", "authenticate"},
+	{"authz", "authorization", "authorize"},
+	{"cfg", "conf", "config", "configuration"},
+	{"ctx", "context"},
+	{"db", "database"},
+	{"repo", "repository"},
+	{"env", "environment"},
+	{"err", "error"},
+	{"msg", "message"},
+	{"req", "request"},
+	{"res", "resp", "response"},
+	{"arg", "argument"},
+	{"param", "parameter"},
+	{"args", "arguments"},
+	{"params", "parameters"},
+	{"fn", "func", "function"},
+	{"impl", "implementation"},
+	{"init", "initialize"},
+	{"dir", "directory"},
+	{"pkg", "package"},
+	{"lib", "library"},
+	{"util", "utility"},
+	{"tmp", "temp", "temporary"},
+	{"str", "string"},
+	{"num", "number"},
+	{"int", "integer"},
+	{"bool", "boolean"},
+	{"idx", "index"},
+	{"len", "length"},
+	{"max", "maximum"},
+	{"min", "minimum"},
+	{"src", "source"},
+	{"dst", "dest", "destination"},
+	{"addr", "address"},
+	{"conn", "connection"},
+	{"val", "value"},
+	{"var", "variable"},
+	{"doc", "document"},
+	{"docs", "documentation"},
+	{"info", "information"},
+	{"spec", "specification"},
+	{"async", "asynchronous"},
+	{"sync", "synchronous", "synchronize"},
+	{"alloc", "allocate", "allocation"},
+	{"buf", "buffer"},
+	{"cmd", "command"},
+	{"exec", "execute"},
+	{"gen", "generate"},
+	{"calc", "calculate"},
+	{"cnt", "count"},
+	{"prev", "previous"},
+	{"ptr", "pointer"},
+	{"ref", "reference"},
+	{"regex", "regexp"},
+	{"http", "https"},
+	{"url", "uri"},
+	{"k8s", "kubernetes"},
+	{"i18n", "internationalization"},
+	{"l10n", "localization"},
+}
+
+type SynonymMap struct {
+	expansions map[string][]string
+	weight     float64
+}
+
+func NewSynonymMap(groups [][]string, tokenizer port.Tokenizer, weight float64) *SynonymMap {
+	m := &SynonymMap{expansions: make(map[string][]string), weight: weight}
+	for _, group := range groups {
+		var tokens []string
+		for _, term := range group {
+			// The last token is the whole word when the tokenizer also splits it.
+			if t := tokenizer.Tokenize(term); len(t) > 0 && !strings.ContainsAny(term, " \t") {
+				tokens = append(tokens, t[len(t)-1])
+			}
+		}
+		for _, token := range tokens {
+			for _, other := range tokens {
+				if other != token && !containsString(m.expansions[token], other) {
+					m.expansions[token] = append(m.expansions[token], other)
+				}
+			}
+		}
+	}
+	return m
+}
+
+// Expand adds synonyms to weights, scaled by the weight of the token they expand.
+func (m *SynonymMap) Expand(tokens []string, weights map[string]float64) []string {
+	if m == nil || m.weight <= 0 {
+		return nil
+	}
+	var expanded []string
+	for _, token := range tokens {
+		for _, synonym := range m.expansions[token] {
+			weight := m.weight * weights[token]
+			if containsString(expanded, synonym) {
+				weights[synonym] = max(weights[synonym], weight)
+			} else if _, exists := weights[synonym]; !exists {
+				weights[synonym] = weight
+				expanded = append(expanded, synonym)
+			}
+		}
+	}
+	return expanded
+}
+
+// ParseSynonyms reads one comma-separated group per line; # starts a comment.
+func ParseSynonyms(r io.Reader) ([][]string, error) {
+	var groups [][]string
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var group []string
+		for _, term := range strings.Split(text, ",") {
+			if term = strings.TrimSpace(term); term != "" {
+				group = append(group, term)
+			}
+		}
+		if len(group) < 2 {
+			return nil, fmt.Errorf("line %d: expected at least two comma-separated terms", line)
+		}
+		groups = append(groups, group)
+	}
+	return groups, scanner.Err()
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package retriever
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/adapter/store"
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+func TestParseSynonyms(t *testing.T) {
+	groups, err := ParseSynonyms(strings.NewReader("# team jargon\nauthn, auth , authentication\n\nk8s,kubernetes\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"authn", "auth", "authentication"}, {"k8s", "kubernetes"}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("ParseSynonyms = %v, want %v", groups, want)
+	}
+
+	if _, err := ParseSynonyms(strings.NewReader("lonely\n")); err == nil {
+		t.Error("expected error for a group with a single term")
+	}
+}
+
+func TestSynonymMap_Expand(t *testing.T) {
+	tokenizer := analyzer.NewTokenizer(true)
+	synonyms := NewSynonymMap(DefaultSynonymGroups, tokenizer, 0.5)
+
+	weights := map[string]float64{"load": 1, "cfg": 0.4}
+	expanded := synonyms.Expand([]string{"load", "cfg"}, weights)
+	for _, want := range tokenizer.Tokenize("config configuration") {
+		if !containsString(expanded, want) || weights[want] != 0.2 {
+			t.Errorf("expected %q in expansion of cfg with weight 0.2, got %v %v", want, expanded, weights)
+		}
+	}
+
+	query := tokenizer.Tokenize("auth authentication")
+	weights = map[string]float64{query[0]: 1, query[1]: 1}
+	if expanded := synonyms.Expand(query, weights); containsString(expanded, query[0]) || weights[query[0]] != 1 {
+		t.Errorf("query tokens must not be expanded again, got %v %v", expanded, weights)
+	}
+	if expanded := (*SynonymMap)(nil).Expand([]string{"cfg"}, map[string]float64{"cfg": 1}); expanded != nil {
+		t.Errorf("expected nil map to expand nothing, got %v", expanded)
+	}
+}
+
+func TestBM25Synonyms(t *testing.T) {
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	tokenizer := analyzer.NewTokenizer(true)
+	texts := map[string]string{
+		"long":  "validate the authentication token before the session starts",
+		"short": "check authn token before the session starts",
+		"other": "render the session dashboard with charts",
+	}
+
+	file := port.IndexedFile{
+		Doc:      domain.Document{ID: "doc1", Path: "/repo/a.txt"},
+		Postings: make(map[string]map[string]int),
+	}
+	for id, text := range texts {
+		tokens := tokenizer.Tokenize(text)
+		file.Chunks = append(file.Chunks, domain.Chunk{ID: id, DocID: "doc1", Tokens: tokens, Text: text})
+		for _, token := range tokens {
+			if file.Postings[token] == nil {
+				file.Postings[token] = make(map[string]int)
+			}
+			file.Postings[token][id]++
+		}
+	}
+	if err := st.BatchIndex([]port.IndexedFile{file}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateStats(domain.Stats{TotalDocs: 1, TotalChunks: 3, AvgChunkLen: 6}); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75})
+	results, err := plain.Search("authn", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(results); !reflect.DeepEqual(ids, []string{"short"}) {
+		t.Fatalf("expected only the literal match without synonyms, got %v", ids)
+	}
+
+	synonyms := NewSynonymMap(DefaultSynonymGroups, tokenizer, 0.5)
+	expanded := NewBM25Retriever(st, tokenizer, BM25Options{K1: 1.2, B: 0.75, Synonyms: synonyms})
+	results, err = expanded.Search("authn", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(results); !reflect.DeepEqual(ids, []string{"short", "long"}) {
+		t.Fatalf("expected the literal match ranked above the synonym match, got %v", ids)
+	}
+
+	terms := results[1].Explanation.Terms
+	if len(terms) != 1 || terms[0].Weight != 0.5 {
+		t.Errorf("expected a single synonym contribution with weight 0.5, got %+v", terms)
+	}
+}
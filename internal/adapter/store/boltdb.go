@@ -1,6 +1,7 @@
 package store
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -35,7 +36,7 @@ func NewBoltStore(path string) (*BoltStore, error) {
 	}
 
 	err = db.Update(func(tx *bbolt.Tx) error {
-		buckets := [][]byte{bucketDocs, bucketChunks, bucketBlobs, bucketTerms, bucketStats, bucketDocChunks, bucketSymbols, bucketDocSymbols, bucketCallGraph}
+		buckets := [][]byte{bucketDocs, bucketChunks, bucketBlobs, bucketTerms, bucketStats, bucketDocChunks, bucketSymbols, bucketDocSymbols, bucketCallGraph, bucketChunkOrds, bucketOrdChunks, bucketNorms, bucketDocOrds, bucketOrdDocs, bucketFieldTerms}
 		for _, b := range buckets {
 			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
 				return fmt.Errorf("failed to create bucket %s: %w", b, err)
@@ -56,26 +57,46 @@ func (s *BoltStore) DB() *bbolt.DB {
 }
 
 type docMeta struct {
-	Path    string `json:"path"`
-	ModTime int64  `json:"mod_time"`
-	Lang    string `json:"lang"`
+	Path        string `json:"path"`
+	ModTime     int64  `json:"mod_time"`
+	Lang        string `json:"lang"`
+	TextLang    string `json:"text_lang,omitempty"`
+	ContentHash string `json:"content_hash,omitempty"`
+}
+
+func newDocMeta(doc domain.Document) docMeta {
+	return docMeta{
+		Path:        doc.Path,
+		ModTime:     doc.ModTime.Unix(),
+		Lang:        doc.Lang,
+		TextLang:    doc.TextLang,
+		ContentHash: doc.ContentHash,
+	}
+}
+
+func (m docMeta) toDocument(id string) domain.Document {
+	return domain.Document{
+		ID:          id,
+		Path:        m.Path,
+		ModTime:     time.Unix(m.ModTime, 0),
+		Lang:        m.Lang,
+		TextLang:    m.TextLang,
+		ContentHash: m.ContentHash,
+	}
 }
 
 type chunkMeta struct {
-	DocID     string   `json:"doc_id"`
-	StartLine int      `json:"start_line"`
-	EndLine   int      `json:"end_line"`
-	Tokens    []string `json:"tokens"`
+	DocID     string                `json:"doc_id"`
+	StartLine int                   `json:"start_line"`
+	EndLine   int                   `json:"end_line"`
+	Tokens    []string              `json:"tokens"`
+	Fields    map[string][]string   `json:"fields,omitempty"`
+	Metadata  *domain.ChunkMetadata `json:"metadata,omitempty"`
 }
 
 func (s *BoltStore) PutDoc(doc domain.Document) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
-		meta := docMeta{
-			Path:    doc.Path,
-			ModTime: doc.ModTime.Unix(),
-			Lang:    doc.Lang,
-		}
-		data, err := json.Marshal(meta)
+		data, err := json.Marshal(newDocMeta(doc))
 		if err != nil {
 			return err
 		}
@@ -94,12 +115,7 @@ func (s *BoltStore) GetDoc(id string) (domain.Document, error) {
 		if err := json.Unmarshal(data, &meta); err != nil {
 			return err
 		}
-		doc = domain.Document{
-			ID:      id,
-			Path:    meta.Path,
-			ModTime: time.Unix(meta.ModTime, 0),
-			Lang:    meta.Lang,
-		}
+		doc = meta.toDocument(id)
 		return nil
 	})
 	return doc, err
@@ -107,6 +123,9 @@ func (s *BoltStore) GetDoc(id string) (domain.Document, error) {
 
 func (s *BoltStore) DeleteDoc(id string) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := deleteDocOrdinal(tx, id); err != nil {
+			return err
+		}
 		return tx.Bucket(bucketDocs).Delete([]byte(id))
 	})
 }
@@ -120,12 +139,7 @@ func (s *BoltStore) ListDocs() ([]domain.Document, error) {
 			if err := json.Unmarshal(v, &meta); err != nil {
 				return err
 			}
-			docs = append(docs, domain.Document{
-				ID:      string(k),
-				Path:    meta.Path,
-				ModTime: time.Unix(meta.ModTime, 0),
-				Lang:    meta.Lang,
-			})
+			docs = append(docs, meta.toDocument(string(k)))
 			return nil
 		})
 	})
@@ -140,6 +154,7 @@ func (s *BoltStore) PutChunk(chunk domain.Chunk) error {
 			StartLine: chunk.StartLine,
 			EndLine:   chunk.EndLine,
 			Tokens:    chunk.Tokens,
+			Fields:    chunk.Fields,
 		}
 		data, err := json.Marshal(meta)
 		if err != nil {
@@ -153,6 +168,18 @@ func (s *BoltStore) PutChunk(chunk domain.Chunk) error {
 			return err
 		}
 
+		if err := putNorm(tx, chunk.ID, chunk.DocID, len(chunk.Tokens), fieldLengths(chunk.Fields)); err != nil {
+			return err
+		}
+
+		ord, err := chunkOrdinal(tx, chunk.ID)
+		if err != nil {
+			return err
+		}
+		if err := putFieldPostings(tx, ord, chunk.Fields); err != nil {
+			return err
+		}
+
 		docChunks := tx.Bucket(bucketDocChunks)
 		var chunkIDs []string
 		if existing := docChunks.Get([]byte(chunk.DocID)); existing != nil {
@@ -183,12 +210,28 @@ func (s *BoltStore) GetChunk(id string) (domain.Chunk, error) {
 			EndLine:   meta.EndLine,
 			Tokens:    meta.Tokens,
 			Text:      string(text),
+			Fields:    meta.Fields,
 		}
 		return nil
 	})
 	return chunk, err
 }
 
+func (s *BoltStore) GetChunkMetadata(id string) (domain.ChunkMetadata, error) {
+	var meta chunkMeta
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(bucketChunks).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("chunk not found: %s", id)
+		}
+		return json.Unmarshal(data, &meta)
+	})
+	if err != nil || meta.Metadata == nil {
+		return domain.ChunkMetadata{}, err
+	}
+	return *meta.Metadata, nil
+}
+
 func (s *BoltStore) GetChunksByDoc(docID string) ([]domain.Chunk, error) {
 	var chunks []domain.Chunk
 	err := s.db.View(func(tx *bbolt.Tx) error {
@@ -220,6 +263,7 @@ func (s *BoltStore) GetChunksByDoc(docID string) ([]domain.Chunk, error) {
 				EndLine:   meta.EndLine,
 				Tokens:    meta.Tokens,
 				Text:      string(text),
+				Fields:    meta.Fields,
 			})
 		}
 		return nil
@@ -241,8 +285,24 @@ func (s *BoltStore) DeleteChunksByDoc(docID string) error {
 		chunkBucket := tx.Bucket(bucketChunks)
 		blobBucket := tx.Bucket(bucketBlobs)
 		for _, id := range chunkIDs {
+			if data := chunkBucket.Get([]byte(id)); data != nil {
+				var meta chunkMeta
+				if err := json.Unmarshal(data, &meta); err == nil && len(meta.Fields) > 0 {
+					if ord, ok := lookupOrdinal(tx, id); ok {
+						if err := removeFieldPostings(tx, ord, meta.Fields); err != nil {
+							return err
+						}
+					}
+				}
+			}
 			chunkBucket.Delete([]byte(id))
 			blobBucket.Delete([]byte(id))
+			if err := deleteNorm(tx, id); err != nil {
+				return err
+			}
+			if err := deleteOrdinal(tx, id); err != nil {
+				return err
+			}
 		}
 		return docChunks.Delete([]byte(docID))
 	})
@@ -250,28 +310,16 @@ func (s *BoltStore) DeleteChunksByDoc(docID string) error {
 
 func (s *BoltStore) PutPosting(term string, chunkID string, tf int) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucketTerms)
-		var postings []domain.Posting
-		if data := b.Get([]byte(term)); data != nil {
-			json.Unmarshal(data, &postings)
-		}
-
-		found := false
-		for i := range postings {
-			if postings[i].ChunkID == chunkID {
-				postings[i].TF = tf
-				found = true
-				break
-			}
-		}
-		if !found {
-			postings = append(postings, domain.Posting{ChunkID: chunkID, TF: tf})
+		ord, err := chunkOrdinal(tx, chunkID)
+		if err != nil {
+			return err
 		}
-		data, err := json.Marshal(postings)
+		postings, err := readPostings(tx, term)
 		if err != nil {
 			return err
 		}
-		return b.Put([]byte(term), data)
+		postings = mergePostings(postings, []ordPosting{{ord: ord, tf: tf}})
+		return writePostings(tx, term, postings)
 	})
 }
 
@@ -282,41 +330,65 @@ func (s *BoltStore) GetPostings(term string) ([]domain.Posting, error) {
 		if data == nil {
 			return nil
 		}
-		return json.Unmarshal(data, &postings)
+		if isLegacyPostings(data) {
+			return json.Unmarshal(data, &postings)
+		}
+		decoded, err := decodePostings(data)
+		if err != nil {
+			return err
+		}
+		postings = resolvePostings(tx, decoded)
+		return nil
 	})
 	return postings, err
 }
 
 func (s *BoltStore) DeletePostings(chunkID string, terms []string) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucketTerms)
+		ord, ok := lookupOrdinal(tx, chunkID)
+		if !ok {
+			return nil
+		}
 		for _, term := range terms {
-			data := b.Get([]byte(term))
-			if data == nil {
-				continue
-			}
-			var postings []domain.Posting
-			if err := json.Unmarshal(data, &postings); err != nil {
+			postings, err := readPostings(tx, term)
+			if err != nil || len(postings) == 0 {
 				continue
 			}
 
-			filtered := make([]domain.Posting, 0, len(postings))
+			filtered := make([]ordPosting, 0, len(postings))
 			for _, p := range postings {
-				if p.ChunkID != chunkID {
+				if p.ord != ord {
 					filtered = append(filtered, p)
 				}
 			}
-			if len(filtered) == 0 {
-				b.Delete([]byte(term))
-			} else {
-				data, _ := json.Marshal(filtered)
-				b.Put([]byte(term), data)
+			if err := writePostings(tx, term, filtered); err != nil {
+				return err
 			}
 		}
 		return nil
 	})
 }
 
+// TextLanguages lists the languages indexed documents were analyzed with.
+func (s *BoltStore) TextLanguages() ([]string, error) {
+	seen := make(map[string]bool)
+	var langs []string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketDocs).ForEach(func(_, v []byte) error {
+			var meta docMeta
+			if err := json.Unmarshal(v, &meta); err != nil {
+				return nil
+			}
+			if meta.TextLang != "" && !seen[meta.TextLang] {
+				seen[meta.TextLang] = true
+				langs = append(langs, meta.TextLang)
+			}
+			return nil
+		})
+	})
+	return langs, err
+}
+
 func (s *BoltStore) GetStats() (domain.Stats, error) {
 	var stats domain.Stats
 	err := s.db.View(func(tx *bbolt.Tx) error {
@@ -361,18 +433,12 @@ func (s *BoltStore) BatchIndex(files []port.IndexedFile) error {
 		chunksBucket := tx.Bucket(bucketChunks)
 		blobsBucket := tx.Bucket(bucketBlobs)
 		docChunksBucket := tx.Bucket(bucketDocChunks)
-		termsBucket := tx.Bucket(bucketTerms)
 
-		allPostings := make(map[string][]domain.Posting)
+		allPostings := make(map[string][]ordPosting)
 
 		for _, file := range files {
 
-			meta := docMeta{
-				Path:    file.Doc.Path,
-				ModTime: file.Doc.ModTime.Unix(),
-				Lang:    file.Doc.Lang,
-			}
-			data, err := json.Marshal(meta)
+			data, err := json.Marshal(newDocMeta(file.Doc))
 			if err != nil {
 				return err
 			}
@@ -380,6 +446,12 @@ func (s *BoltStore) BatchIndex(files []port.IndexedFile) error {
 				return err
 			}
 
+			for _, chunk := range file.Removed {
+				if err := removeChunk(tx, chunk); err != nil {
+					return err
+				}
+			}
+
 			chunkIDs := make([]string, 0, len(file.Chunks))
 			for _, chunk := range file.Chunks {
 				chunkMeta := chunkMeta{
@@ -387,6 +459,10 @@ func (s *BoltStore) BatchIndex(files []port.IndexedFile) error {
 					StartLine: chunk.StartLine,
 					EndLine:   chunk.EndLine,
 					Tokens:    chunk.Tokens,
+					Fields:    chunk.Fields,
+				}
+				if meta, ok := file.Metadata[chunk.ID]; ok {
+					chunkMeta.Metadata = &meta
 				}
 				data, err := json.Marshal(chunkMeta)
 				if err != nil {
@@ -395,10 +471,28 @@ func (s *BoltStore) BatchIndex(files []port.IndexedFile) error {
 				if err := chunksBucket.Put([]byte(chunk.ID), data); err != nil {
 					return err
 				}
+				chunkIDs = append(chunkIDs, chunk.ID)
+				if _, kept := file.Kept[chunk.ID]; kept {
+					if !bytes.Equal(blobsBucket.Get([]byte(chunk.ID)), []byte(chunk.Text)) {
+						if err := blobsBucket.Put([]byte(chunk.ID), []byte(chunk.Text)); err != nil {
+							return err
+						}
+					}
+					continue
+				}
 				if err := blobsBucket.Put([]byte(chunk.ID), []byte(chunk.Text)); err != nil {
 					return err
 				}
-				chunkIDs = append(chunkIDs, chunk.ID)
+				if err := putNorm(tx, chunk.ID, chunk.DocID, len(chunk.Tokens), fieldLengths(chunk.Fields)); err != nil {
+					return err
+				}
+				ord, err := chunkOrdinal(tx, chunk.ID)
+				if err != nil {
+					return err
+				}
+				if err := putFieldPostings(tx, ord, chunk.Fields); err != nil {
+					return err
+				}
 			}
 
 			chunkIDsData, _ := json.Marshal(chunkIDs)
@@ -406,27 +500,39 @@ func (s *BoltStore) BatchIndex(files []port.IndexedFile) error {
 				return err
 			}
 
+			if err := deleteSymbols(tx, file.Doc.ID); err != nil {
+				return err
+			}
+			if len(file.Symbols) > 0 {
+				if err := putSymbols(tx, file.Doc.ID, file.Symbols); err != nil {
+					return err
+				}
+			}
+			if err := putCallGraph(tx, file.Doc.ID, file.Calls); err != nil {
+				return err
+			}
+
 			for term, chunkTFs := range file.Postings {
 				for chunkID, tf := range chunkTFs {
-					allPostings[term] = append(allPostings[term], domain.Posting{
-						ChunkID: chunkID,
-						TF:      tf,
+					ord, err := chunkOrdinal(tx, chunkID)
+					if err != nil {
+						return err
+					}
+					allPostings[term] = append(allPostings[term], ordPosting{
+						ord:       ord,
+						tf:        tf,
+						positions: file.Positions[term][chunkID],
 					})
 				}
 			}
 		}
 
 		for term, newPostings := range allPostings {
-			var existing []domain.Posting
-			if data := termsBucket.Get([]byte(term)); data != nil {
-				json.Unmarshal(data, &existing)
-			}
-			existing = append(existing, newPostings...)
-			data, err := json.Marshal(existing)
+			existing, err := readPostings(tx, term)
 			if err != nil {
 				return err
 			}
-			if err := termsBucket.Put([]byte(term), data); err != nil {
+			if err := writePostings(tx, term, mergePostings(existing, newPostings)); err != nil {
 				return err
 			}
 		}
@@ -435,29 +541,73 @@ func (s *BoltStore) BatchIndex(files []port.IndexedFile) error {
 	})
 }
 
-func (s *BoltStore) PutSymbols(docID string, symbols []domain.Symbol) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
-		symbolBucket := tx.Bucket(bucketSymbols)
-		docSymbolsBucket := tx.Bucket(bucketDocSymbols)
+func removeChunk(tx *bbolt.Tx, chunk domain.Chunk) error {
+	if ord, ok := lookupOrdinal(tx, chunk.ID); ok {
+		if err := removeFieldPostings(tx, ord, chunk.Fields); err != nil {
+			return err
+		}
+		seen := make(map[string]struct{}, len(chunk.Tokens))
+		for _, term := range chunk.Tokens {
+			if _, done := seen[term]; done {
+				continue
+			}
+			seen[term] = struct{}{}
 
-		symbolIDs := make([]string, 0, len(symbols))
-		for _, sym := range symbols {
-			data, err := json.Marshal(sym)
-			if err != nil {
-				return err
+			postings, err := readPostings(tx, term)
+			if err != nil || len(postings) == 0 {
+				continue
 			}
-			if err := symbolBucket.Put([]byte(sym.ID), data); err != nil {
+			filtered := make([]ordPosting, 0, len(postings))
+			for _, p := range postings {
+				if p.ord != ord {
+					filtered = append(filtered, p)
+				}
+			}
+			if err := writePostings(tx, term, filtered); err != nil {
 				return err
 			}
-			symbolIDs = append(symbolIDs, sym.ID)
 		}
+	}
+
+	if err := tx.Bucket(bucketChunks).Delete([]byte(chunk.ID)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketBlobs).Delete([]byte(chunk.ID)); err != nil {
+		return err
+	}
+	if err := deleteNorm(tx, chunk.ID); err != nil {
+		return err
+	}
+	return deleteOrdinal(tx, chunk.ID)
+}
+
+func (s *BoltStore) PutSymbols(docID string, symbols []domain.Symbol) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return putSymbols(tx, docID, symbols)
+	})
+}
 
-		idsData, err := json.Marshal(symbolIDs)
+func putSymbols(tx *bbolt.Tx, docID string, symbols []domain.Symbol) error {
+	symbolBucket := tx.Bucket(bucketSymbols)
+	docSymbolsBucket := tx.Bucket(bucketDocSymbols)
+
+	symbolIDs := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		data, err := json.Marshal(sym)
 		if err != nil {
 			return err
 		}
-		return docSymbolsBucket.Put([]byte(docID), idsData)
-	})
+		if err := symbolBucket.Put([]byte(sym.ID), data); err != nil {
+			return err
+		}
+		symbolIDs = append(symbolIDs, sym.ID)
+	}
+
+	idsData, err := json.Marshal(symbolIDs)
+	if err != nil {
+		return err
+	}
+	return docSymbolsBucket.Put([]byte(docID), idsData)
 }
 
 func (s *BoltStore) GetSymbol(id string) (domain.Symbol, error) {
@@ -500,34 +650,58 @@ func (s *BoltStore) GetSymbolsByDoc(docID string) ([]domain.Symbol, error) {
 
 func (s *BoltStore) DeleteSymbolsByDoc(docID string) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
-		docSymbolsBucket := tx.Bucket(bucketDocSymbols)
-		data := docSymbolsBucket.Get([]byte(docID))
-		if data == nil {
-			return nil
-		}
-		var ids []string
-		if err := json.Unmarshal(data, &ids); err != nil {
+		return deleteSymbols(tx, docID)
+	})
+}
+
+func deleteSymbols(tx *bbolt.Tx, docID string) error {
+	docSymbolsBucket := tx.Bucket(bucketDocSymbols)
+	data := docSymbolsBucket.Get([]byte(docID))
+	if data == nil {
+		return nil
+	}
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return err
+	}
+	symbolBucket := tx.Bucket(bucketSymbols)
+	for _, id := range ids {
+		if err := symbolBucket.Delete([]byte(id)); err != nil {
 			return err
 		}
-		symbolBucket := tx.Bucket(bucketSymbols)
-		for _, id := range ids {
-			symbolBucket.Delete([]byte(id))
-		}
-		return docSymbolsBucket.Delete([]byte(docID))
-	})
+	}
+	return docSymbolsBucket.Delete([]byte(docID))
 }
 
 func (s *BoltStore) PutCallGraph(docID string, entries []domain.CallGraphEntry) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucketCallGraph)
-		data, err := json.Marshal(entries)
-		if err != nil {
-			return err
+		return putCallGraph(tx, docID, entries)
+	})
+}
+
+func (s *BoltStore) PutCallGraphs(entries map[string][]domain.CallGraphEntry) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		for docID, docEntries := range entries {
+			if err := putCallGraph(tx, docID, docEntries); err != nil {
+				return err
+			}
 		}
-		return b.Put([]byte(docID), data)
+		return nil
 	})
 }
 
+func putCallGraph(tx *bbolt.Tx, docID string, entries []domain.CallGraphEntry) error {
+	b := tx.Bucket(bucketCallGraph)
+	if len(entries) == 0 {
+		return b.Delete([]byte(docID))
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(docID), data)
+}
+
 func (s *BoltStore) GetCallGraph(docID string) ([]domain.CallGraphEntry, error) {
 	var entries []domain.CallGraphEntry
 	err := s.db.View(func(tx *bbolt.Tx) error {
@@ -540,6 +714,21 @@ func (s *BoltStore) GetCallGraph(docID string) ([]domain.CallGraphEntry, error)
 	return entries, err
 }
 
+func (s *BoltStore) GetAllCallGraph() ([]domain.CallGraphEntry, error) {
+	var entries []domain.CallGraphEntry
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketCallGraph).ForEach(func(k, v []byte) error {
+			var docEntries []domain.CallGraphEntry
+			if err := json.Unmarshal(v, &docEntries); err != nil {
+				return nil
+			}
+			entries = append(entries, docEntries...)
+			return nil
+		})
+	})
+	return entries, err
+}
+
 func (s *BoltStore) DeleteCallGraph(docID string) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
 		return tx.Bucket(bucketCallGraph).Delete([]byte(docID))
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	bucketEmbeddingCache     = []byte("embedding_cache")
+	bucketEmbeddingCacheUsed = []byte("embedding_cache_used")
+)
+
+type EmbeddingCache struct {
+	db        *bbolt.DB
+	namespace []byte
+	dimension int
+}
+
+func NewEmbeddingCache(db *bbolt.DB, model string, dimension int) (*EmbeddingCache, error) {
+	namespace := []byte(fmt.Sprintf("%s@%d", model, dimension))
+
+	err := db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{bucketEmbeddingCache, bucketEmbeddingCacheUsed} {
+			root, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+			if _, err := root.CreateBucketIfNotExists(namespace); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding cache bucket: %w", err)
+	}
+
+	return &EmbeddingCache{
+		db:        db,
+		namespace: namespace,
+		dimension: dimension,
+	}, nil
+}
+
+func (c *EmbeddingCache) Get(hashes []string) (map[string][]float32, error) {
+	found := make(map[string][]float32)
+	err := c.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketEmbeddingCache).Bucket(c.namespace)
+		used := tx.Bucket(bucketEmbeddingCacheUsed).Bucket(c.namespace)
+		for _, hash := range hashes {
+			data := b.Get([]byte(hash))
+			if data == nil {
+				continue
+			}
+			vec, err := decodeFloat32s(data)
+			if err != nil || len(vec) != c.dimension {
+				continue
+			}
+			found[hash] = vec
+			if err := touch(used, hash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (c *EmbeddingCache) Put(entries map[string][]float32) error {
+	return c.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketEmbeddingCache).Bucket(c.namespace)
+		used := tx.Bucket(bucketEmbeddingCacheUsed).Bucket(c.namespace)
+		for hash, vec := range entries {
+			if len(vec) != c.dimension {
+				return fmt.Errorf("vector dimension mismatch: expected %d, got %d", c.dimension, len(vec))
+			}
+			if err := b.Put([]byte(hash), encodeFloat32s(vec)); err != nil {
+				return err
+			}
+			if err := touch(used, hash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Prune evicts this model's least recently used vectors beyond maxEntries.
+func (c *EmbeddingCache) Prune(maxEntries int) (int, error) {
+	if maxEntries <= 0 {
+		return 0, nil
+	}
+	pruned := 0
+	err := c.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketEmbeddingCache).Bucket(c.namespace)
+		used := tx.Bucket(bucketEmbeddingCacheUsed).Bucket(c.namespace)
+
+		type entry struct {
+			hash []byte
+			seq  uint64
+		}
+		var entries []entry
+		if err := b.ForEach(func(hash, _ []byte) error {
+			var seq uint64
+			if data := used.Get(hash); len(data) == 8 {
+				seq = binary.BigEndian.Uint64(data)
+			}
+			entries = append(entries, entry{append([]byte(nil), hash...), seq})
+			return nil
+		}); err != nil {
+			return err
+		}
+		if len(entries) <= maxEntries {
+			return nil
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].seq < entries[j].seq
+		})
+		for _, e := range entries[:len(entries)-maxEntries] {
+			if err := b.Delete(e.hash); err != nil {
+				return err
+			}
+			if err := used.Delete(e.hash); err != nil {
+				return err
+			}
+		}
+		pruned = len(entries) - maxEntries
+		return nil
+	})
+	return pruned, err
+}
+
+func touch(used *bbolt.Bucket, hash string) error {
+	seq, err := used.NextSequence()
+	if err != nil {
+		return err
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], seq)
+	return used.Put([]byte(hash), buf[:])
+}
+
+func encodeFloat32s(vec []float32) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeFloat32s(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid float32 payload length: %d", len(data))
+	}
+	vec := make([]float32, len(data)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vec, nil
+}
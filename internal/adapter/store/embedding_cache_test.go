@@ -0,0 +1,93 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEmbeddingCache_NamespacedByModelAndDimension(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "embedding_cache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	st, err := NewBoltStore(tmpDir + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	cache, err := NewEmbeddingCache(st.DB(), "nomic-embed-text", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Put(map[string][]float32{"h1": {0.1, -0.2, 0.3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Put(map[string][]float32{"h2": {1, 2}}); err == nil {
+		t.Error("expected dimension mismatch error")
+	}
+
+	found, err := cache.Get([]string{"h1", "h2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found["h1"][1] != -0.2 {
+		t.Errorf("unexpected cache contents: %+v", found)
+	}
+
+	if err := st.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	found, _ = cache.Get([]string{"h1"})
+	if len(found) != 1 {
+		t.Error("expected cache to survive index rebuild")
+	}
+
+	other, err := NewEmbeddingCache(st.DB(), "mxbai-embed-large", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, _ = other.Get([]string{"h1"})
+	if len(found) != 0 {
+		t.Errorf("expected no hits for a different model, got %+v", found)
+	}
+}
+
+func TestEmbeddingCache_Prune(t *testing.T) {
+	st, err := NewBoltStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	cache, _ := NewEmbeddingCache(st.DB(), "nomic-embed-text", 2)
+	other, _ := NewEmbeddingCache(st.DB(), "mxbai-embed-large", 2)
+	for _, hash := range []string{"old", "used", "new"} {
+		if err := cache.Put(map[string][]float32{hash: {1, 0}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := other.Put(map[string][]float32{"old": {0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	cache.Get([]string{"used"})
+
+	if pruned, _ := cache.Prune(0); pruned != 0 {
+		t.Errorf("expected no pruning without a limit, got %d", pruned)
+	}
+	pruned, err := cache.Prune(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 1 {
+		t.Errorf("expected one eviction, got %d", pruned)
+	}
+	if found, _ := cache.Get([]string{"old", "used", "new"}); len(found) != 2 || found["old"] != nil {
+		t.Errorf("expected the least recently used hash to be evicted, got %+v", found)
+	}
+	if found, _ := other.Get([]string{"old"}); len(found) != 1 {
+		t.Errorf("expected the other model's cache to be left alone, got %+v", found)
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"go.etcd.io/bbolt"
+	"rag/internal/domain"
+)
+
+var bucketFieldTerms = []byte("field_terms")
+
+func fieldTermKey(field, term string) []byte {
+	key := make([]byte, 0, len(field)+1+len(term))
+	key = append(key, field...)
+	key = append(key, 0)
+	return append(key, term...)
+}
+
+func fieldLengths(fields map[string][]string) map[string]int {
+	if len(fields) == 0 {
+		return nil
+	}
+	lengths := make(map[string]int, len(fields))
+	for field, tokens := range fields {
+		if len(tokens) > 0 {
+			lengths[field] = len(tokens)
+		}
+	}
+	return lengths
+}
+
+func fieldTFs(fields map[string][]string) map[string]map[string]int {
+	tfs := make(map[string]map[string]int, len(fields))
+	for field, tokens := range fields {
+		if len(tokens) == 0 {
+			continue
+		}
+		tf := make(map[string]int)
+		for _, token := range tokens {
+			tf[token]++
+		}
+		tfs[field] = tf
+	}
+	return tfs
+}
+
+func putFieldPostings(tx *bbolt.Tx, ord uint64, fields map[string][]string) error {
+	b := tx.Bucket(bucketFieldTerms)
+	for field, tf := range fieldTFs(fields) {
+		for term, count := range tf {
+			key := fieldTermKey(field, term)
+			existing, err := decodePostings(b.Get(key))
+			if err != nil {
+				return err
+			}
+			merged := mergePostings(existing, []ordPosting{{ord: ord, tf: count}})
+			if err := b.Put(key, encodePostings(merged)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func removeFieldPostings(tx *bbolt.Tx, ord uint64, fields map[string][]string) error {
+	b := tx.Bucket(bucketFieldTerms)
+	for field, tf := range fieldTFs(fields) {
+		for term := range tf {
+			key := fieldTermKey(field, term)
+			postings, err := decodePostings(b.Get(key))
+			if err != nil || len(postings) == 0 {
+				continue
+			}
+			filtered := make([]ordPosting, 0, len(postings))
+			for _, p := range postings {
+				if p.ord != ord {
+					filtered = append(filtered, p)
+				}
+			}
+			if len(filtered) == 0 {
+				if err := b.Delete(key); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := b.Put(key, encodePostings(filtered)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *BoltStore) GetFieldPostings(field, term string) ([]domain.Posting, error) {
+	if field == domain.FieldBody {
+		return s.GetPostings(term)
+	}
+
+	var postings []domain.Posting
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		decoded, err := decodePostings(tx.Bucket(bucketFieldTerms).Get(fieldTermKey(field, term)))
+		if err != nil {
+			return err
+		}
+		postings = resolvePostings(tx, decoded)
+		return nil
+	})
+	return postings, err
+}
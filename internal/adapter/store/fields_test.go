@@ -0,0 +1,73 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+func TestBoltStore_FieldPostings(t *testing.T) {
+	st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	err = st.BatchIndex([]port.IndexedFile{{
+		Doc: domain.Document{ID: "doc1", Path: "/pool.go"},
+		Chunks: []domain.Chunk{{
+			ID:     "c1",
+			DocID:  "doc1",
+			Tokens: []string{"func", "acquire", "pool"},
+			Fields: map[string][]string{
+				domain.FieldPath:   {"pool", "go"},
+				domain.FieldSymbol: {"acquire"},
+			},
+		}},
+		Postings: map[string]map[string]int{"func": {"c1": 1}, "acquire": {"c1": 1}, "pool": {"c1": 1}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postings, err := st.GetFieldPostings(domain.FieldSymbol, "acquire")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postings) != 1 || postings[0].ChunkID != "c1" || postings[0].TF != 1 {
+		t.Errorf("unexpected symbol postings: %+v", postings)
+	}
+	if postings, _ := st.GetFieldPostings(domain.FieldSymbol, "pool"); len(postings) != 0 {
+		t.Errorf("expected fields to be indexed separately, got %+v", postings)
+	}
+	if postings, _ := st.GetFieldPostings(domain.FieldBody, "func"); len(postings) != 1 {
+		t.Errorf("expected body field to read the main postings, got %+v", postings)
+	}
+
+	norms, err := st.GetChunkNorms([]string{"c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{domain.FieldPath: 2, domain.FieldSymbol: 1}
+	if norm := norms["c1"]; norm.Length != 3 || !reflect.DeepEqual(norm.FieldLengths, want) {
+		t.Errorf("unexpected norm: %+v", norm)
+	}
+
+	chunk, err := st.GetChunk("c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(chunk.Fields[domain.FieldSymbol], []string{"acquire"}) {
+		t.Errorf("expected fields to round-trip, got %+v", chunk.Fields)
+	}
+
+	if err := st.DeleteChunksByDoc("doc1"); err != nil {
+		t.Fatal(err)
+	}
+	if postings, _ := st.GetFieldPostings(domain.FieldPath, "pool"); len(postings) != 0 {
+		t.Errorf("expected field postings to be removed with the chunk, got %+v", postings)
+	}
+}
@@ -0,0 +1,623 @@
+package store
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	bucketHNSWNodes = []byte("hnsw_nodes")
+	bucketHNSWMeta  = []byte("hnsw_meta")
+
+	hnswMetaKey = []byte("graph")
+)
+
+// Version 2 keys nodes by chunk ordinal instead of chunk ID.
+const hnswNodeFormat = 2
+
+type HNSWParams struct {
+	M              int
+	EfConstruction int
+	EfSearch       int
+	Seed           int64
+}
+
+func DefaultHNSWParams() HNSWParams {
+	return HNSWParams{M: 16, EfConstruction: 200, EfSearch: 64}
+}
+
+type hnswMeta struct {
+	Format         int    `json:"format"`
+	Entry          uint64 `json:"entry"`
+	MaxLevel       int    `json:"max_level"`
+	M              int    `json:"m"`
+	EfConstruction int    `json:"ef_construction"`
+}
+
+type hnswNode struct {
+	id        string
+	neighbors [][]uint64
+	in        []uint64 // one entry per layer a node links here on
+}
+
+type hnswCandidate struct {
+	ord uint64
+	sim float64
+}
+
+// hnswSpace resolves node vectors for the transaction a graph operation runs in.
+type hnswSpace interface {
+	similarity(query []float32, id string) float64
+	vector(id string) []float32
+}
+
+type hnswGraph struct {
+	params    HNSWParams
+	levelMult float64
+	nodes     map[uint64]*hnswNode
+	ords      map[string]uint64
+	entry     uint64 // 0 when empty; ordinals start at 1
+	maxLevel  int
+	rng       *rand.Rand
+	dirty     map[uint64]struct{}
+	reset     bool
+}
+
+func newHNSWGraph(params HNSWParams) *hnswGraph {
+	defaults := DefaultHNSWParams()
+	if params.M < 2 {
+		params.M = defaults.M
+	}
+	if params.EfConstruction < params.M {
+		params.EfConstruction = defaults.EfConstruction
+	}
+	if params.EfSearch <= 0 {
+		params.EfSearch = defaults.EfSearch
+	}
+	seed := params.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &hnswGraph{
+		params:    params,
+		levelMult: 1 / math.Log(float64(params.M)),
+		nodes:     make(map[uint64]*hnswNode),
+		ords:      make(map[string]uint64),
+		rng:       rand.New(rand.NewSource(seed)),
+		dirty:     make(map[uint64]struct{}),
+	}
+}
+
+func (g *hnswGraph) randomLevel() int {
+	return int(math.Floor(-math.Log(1-g.rng.Float64()) * g.levelMult))
+}
+
+func (g *hnswGraph) maxConnections(layer int) int {
+	if layer == 0 {
+		return 2 * g.params.M
+	}
+	return g.params.M
+}
+
+func (g *hnswGraph) sim(space hnswSpace, query []float32, ord uint64) float64 {
+	return space.similarity(query, g.nodes[ord].id)
+}
+
+func (g *hnswGraph) insert(space hnswSpace, id string, ord uint64) {
+	if _, exists := g.ords[id]; exists {
+		g.remove(space, id)
+	}
+
+	query := space.vector(id)
+	level := g.randomLevel()
+	node := &hnswNode{id: id, neighbors: make([][]uint64, level+1)}
+	g.nodes[ord] = node
+	g.ords[id] = ord
+	g.dirty[ord] = struct{}{}
+
+	if g.entry == 0 {
+		g.entry = ord
+		g.maxLevel = level
+		return
+	}
+
+	ep := []hnswCandidate{{ord: g.entry, sim: g.sim(space, query, g.entry)}}
+	for layer := g.maxLevel; layer > level; layer-- {
+		ep = g.searchLayer(space, query, ep, 1, layer)[:1]
+	}
+
+	for layer := minInt(level, g.maxLevel); layer >= 0; layer-- {
+		found := g.searchLayer(space, query, ep, g.params.EfConstruction, layer)
+		g.setNeighbors(ord, layer, g.selectNeighbors(space, found, g.params.M))
+		for _, neighbor := range node.neighbors[layer] {
+			g.link(space, neighbor, ord, layer)
+		}
+		ep = found
+	}
+
+	if level > g.maxLevel {
+		g.maxLevel = level
+		g.entry = ord
+	}
+}
+
+// setNeighbors replaces a neighbour list and keeps the reverse links in step.
+func (g *hnswGraph) setNeighbors(ord uint64, layer int, neighbors []uint64) {
+	node := g.nodes[ord]
+	for _, old := range node.neighbors[layer] {
+		if target := g.nodes[old]; target != nil && !containsOrd(neighbors, old) {
+			target.in = removeOrd(target.in, ord)
+		}
+	}
+	for _, nid := range neighbors {
+		if target := g.nodes[nid]; target != nil && !containsOrd(node.neighbors[layer], nid) {
+			target.in = append(target.in, ord)
+		}
+	}
+	node.neighbors[layer] = neighbors
+	g.dirty[ord] = struct{}{}
+}
+
+func containsOrd(ords []uint64, ord uint64) bool {
+	for _, o := range ords {
+		if o == ord {
+			return true
+		}
+	}
+	return false
+}
+
+func removeOrd(ords []uint64, ord uint64) []uint64 {
+	for i, o := range ords {
+		if o == ord {
+			ords[i] = ords[len(ords)-1]
+			return ords[:len(ords)-1]
+		}
+	}
+	return ords
+}
+
+func (g *hnswGraph) link(space hnswSpace, from, to uint64, layer int) {
+	node := g.nodes[from]
+	if node == nil || layer >= len(node.neighbors) {
+		return
+	}
+	neighbors := append(append([]uint64(nil), node.neighbors[layer]...), to)
+	if len(neighbors) > g.maxConnections(layer) {
+		neighbors = g.prune(space, from, neighbors, g.maxConnections(layer))
+	}
+	g.setNeighbors(from, layer, neighbors)
+}
+
+func (g *hnswGraph) prune(space hnswSpace, ord uint64, ords []uint64, m int) []uint64 {
+	base := space.vector(g.nodes[ord].id)
+	seen := make(map[uint64]struct{}, len(ords))
+	candidates := make([]hnswCandidate, 0, len(ords))
+	for _, c := range ords {
+		if c == ord {
+			continue
+		}
+		if _, dup := seen[c]; dup {
+			continue
+		}
+		seen[c] = struct{}{}
+		if _, exists := g.nodes[c]; !exists {
+			continue
+		}
+		candidates = append(candidates, hnswCandidate{ord: c, sim: g.sim(space, base, c)})
+	}
+	sortCandidates(candidates)
+	return g.selectNeighbors(space, candidates, m)
+}
+
+func (g *hnswGraph) selectNeighbors(space hnswSpace, candidates []hnswCandidate, m int) []uint64 {
+	selected := make([]hnswCandidate, 0, m)
+	var skipped []hnswCandidate
+
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		vec := space.vector(g.nodes[c.ord].id)
+		diverse := true
+		for _, s := range selected {
+			if g.sim(space, vec, s.ord) > c.sim {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+
+	for _, c := range skipped {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, c)
+	}
+
+	ords := make([]uint64, len(selected))
+	for i, c := range selected {
+		ords[i] = c.ord
+	}
+	return ords
+}
+
+// remove reconnects former in-neighbours through the removed nodes' neighbours.
+func (g *hnswGraph) remove(space hnswSpace, ids ...string) {
+	removed := make(map[uint64]*hnswNode, len(ids))
+	for _, id := range ids {
+		ord, exists := g.ords[id]
+		if !exists {
+			continue
+		}
+		removed[ord] = g.nodes[ord]
+		delete(g.nodes, ord)
+		delete(g.ords, id)
+		g.dirty[ord] = struct{}{}
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	affected := make(map[uint64]struct{})
+	for ord, dead := range removed {
+		for _, neighbors := range dead.neighbors {
+			for _, nid := range neighbors {
+				if target := g.nodes[nid]; target != nil {
+					target.in = removeOrd(target.in, ord)
+				}
+			}
+		}
+		for _, nid := range dead.in {
+			if _, exists := g.nodes[nid]; exists {
+				affected[nid] = struct{}{}
+			}
+		}
+	}
+
+	for nid := range affected {
+		n := g.nodes[nid]
+		for layer, neighbors := range n.neighbors {
+			kept := make([]uint64, 0, len(neighbors))
+			var repair []uint64
+			for _, c := range neighbors {
+				dead, gone := removed[c]
+				if !gone {
+					kept = append(kept, c)
+					continue
+				}
+				if layer < len(dead.neighbors) {
+					repair = append(repair, dead.neighbors[layer]...)
+				}
+			}
+			if len(kept) == len(neighbors) {
+				continue
+			}
+			if len(repair) > 0 {
+				kept = g.prune(space, nid, append(kept, repair...), g.maxConnections(layer))
+			}
+			g.setNeighbors(nid, layer, kept)
+		}
+	}
+
+	if _, gone := removed[g.entry]; gone {
+		g.resetEntry()
+	}
+}
+
+func (g *hnswGraph) resetEntry() {
+	g.entry = 0
+	g.maxLevel = 0
+	for ord, node := range g.nodes {
+		level := len(node.neighbors) - 1
+		if g.entry == 0 || level > g.maxLevel || (level == g.maxLevel && ord < g.entry) {
+			g.entry = ord
+			g.maxLevel = level
+		}
+	}
+}
+
+// search returns the IDs of the k nearest nodes, closest first.
+func (g *hnswGraph) search(space hnswSpace, query []float32, k int) []string {
+	if g.entry == 0 || k <= 0 {
+		return nil
+	}
+
+	ef := g.params.EfSearch
+	if ef < k {
+		ef = k
+	}
+
+	ep := []hnswCandidate{{ord: g.entry, sim: g.sim(space, query, g.entry)}}
+	for layer := g.maxLevel; layer > 0; layer-- {
+		ep = g.searchLayer(space, query, ep, 1, layer)[:1]
+	}
+
+	found := g.searchLayer(space, query, ep, ef, 0)
+	if len(found) > k {
+		found = found[:k]
+	}
+	ids := make([]string, len(found))
+	for i, c := range found {
+		ids[i] = g.nodes[c.ord].id
+	}
+	return ids
+}
+
+func (g *hnswGraph) searchLayer(space hnswSpace, query []float32, entries []hnswCandidate, ef, layer int) []hnswCandidate {
+	visited := make(map[uint64]struct{}, ef*4)
+	candidates := &nearestFirst{}
+	results := &furthestFirst{}
+
+	for _, e := range entries {
+		visited[e.ord] = struct{}{}
+		heap.Push(candidates, e)
+		heap.Push(results, e)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && c.sim < (*results)[0].sim {
+			break
+		}
+
+		node := g.nodes[c.ord]
+		if node == nil || layer >= len(node.neighbors) {
+			continue
+		}
+
+		for _, nid := range node.neighbors[layer] {
+			if _, seen := visited[nid]; seen {
+				continue
+			}
+			visited[nid] = struct{}{}
+			if _, exists := g.nodes[nid]; !exists {
+				continue
+			}
+
+			sim := g.sim(space, query, nid)
+			if results.Len() < ef || sim > (*results)[0].sim {
+				heap.Push(candidates, hnswCandidate{ord: nid, sim: sim})
+				heap.Push(results, hnswCandidate{ord: nid, sim: sim})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := make([]hnswCandidate, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(results).(hnswCandidate)
+	}
+	return out
+}
+
+func (g *hnswGraph) load(tx *bbolt.Tx) (bool, error) {
+	g.reset = true
+	metaBucket := tx.Bucket(bucketHNSWMeta)
+	nodesBucket := tx.Bucket(bucketHNSWNodes)
+	if metaBucket == nil || nodesBucket == nil {
+		return false, nil
+	}
+
+	data := metaBucket.Get(hnswMetaKey)
+	if data == nil {
+		return false, nil
+	}
+
+	var meta hnswMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return false, nil
+	}
+	if meta.Format != hnswNodeFormat || meta.M != g.params.M || meta.EfConstruction != g.params.EfConstruction {
+		return false, nil
+	}
+
+	err := nodesBucket.ForEach(func(k, v []byte) error {
+		node, err := decodeHNSWNode(v)
+		if err != nil || len(k) != 8 {
+			return fmt.Errorf("corrupt hnsw node %x: %v", k, err)
+		}
+		ord := binary.BigEndian.Uint64(k)
+		g.nodes[ord] = node
+		g.ords[node.id] = ord
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for ord, node := range g.nodes {
+		for _, neighbors := range node.neighbors {
+			for _, nid := range neighbors {
+				if target := g.nodes[nid]; target != nil {
+					target.in = append(target.in, ord)
+				}
+			}
+		}
+	}
+
+	g.reset = false
+	g.entry = meta.Entry
+	g.maxLevel = meta.MaxLevel
+	if _, exists := g.nodes[g.entry]; !exists {
+		g.resetEntry()
+	}
+	return true, nil
+}
+
+func (g *hnswGraph) flush(tx *bbolt.Tx) error {
+	if g.reset {
+		if err := clearHNSW(tx); err != nil {
+			return err
+		}
+		for ord := range g.nodes {
+			g.dirty[ord] = struct{}{}
+		}
+		g.reset = false
+	}
+
+	nodesBucket, err := tx.CreateBucketIfNotExists(bucketHNSWNodes)
+	if err != nil {
+		return err
+	}
+	metaBucket, err := tx.CreateBucketIfNotExists(bucketHNSWMeta)
+	if err != nil {
+		return err
+	}
+
+	for ord := range g.dirty {
+		node, exists := g.nodes[ord]
+		if !exists {
+			if err := nodesBucket.Delete(ordinalKey(ord)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := nodesBucket.Put(ordinalKey(ord), encodeHNSWNode(node)); err != nil {
+			return err
+		}
+	}
+	g.dirty = make(map[uint64]struct{})
+
+	data, err := json.Marshal(hnswMeta{
+		Format:         hnswNodeFormat,
+		Entry:          g.entry,
+		MaxLevel:       g.maxLevel,
+		M:              g.params.M,
+		EfConstruction: g.params.EfConstruction,
+	})
+	if err != nil {
+		return err
+	}
+	return metaBucket.Put(hnswMetaKey, data)
+}
+
+func clearHNSW(tx *bbolt.Tx) error {
+	for _, name := range [][]byte{bucketHNSWNodes, bucketHNSWMeta} {
+		if tx.Bucket(name) == nil {
+			continue
+		}
+		if err := tx.DeleteBucket(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeHNSWNode(node *hnswNode) []byte {
+	buf := make([]byte, 0, 64)
+	buf = binary.AppendUvarint(buf, uint64(len(node.id)))
+	buf = append(buf, node.id...)
+	buf = binary.AppendUvarint(buf, uint64(len(node.neighbors)))
+	for _, layer := range node.neighbors {
+		buf = binary.AppendUvarint(buf, uint64(len(layer)))
+		for _, ord := range layer {
+			buf = binary.AppendUvarint(buf, ord)
+		}
+	}
+	return buf
+}
+
+func decodeHNSWNode(data []byte) (*hnswNode, error) {
+	readUvarint := func() (uint64, error) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, fmt.Errorf("truncated node")
+		}
+		data = data[n:]
+		return v, nil
+	}
+
+	size, err := readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) < size {
+		return nil, fmt.Errorf("truncated node")
+	}
+	node := &hnswNode{id: string(data[:size])}
+	data = data[size:]
+
+	layers, err := readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	node.neighbors = make([][]uint64, 0, layers)
+	for l := uint64(0); l < layers; l++ {
+		count, err := readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		ords := make([]uint64, 0, count)
+		for i := uint64(0); i < count; i++ {
+			ord, err := readUvarint()
+			if err != nil {
+				return nil, err
+			}
+			ords = append(ords, ord)
+		}
+		node.neighbors = append(node.neighbors, ords)
+	}
+	return node, nil
+}
+
+func sortCandidates(candidates []hnswCandidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].sim != candidates[j].sim {
+			return candidates[i].sim > candidates[j].sim
+		}
+		return candidates[i].ord < candidates[j].ord
+	})
+}
+
+type nearestFirst []hnswCandidate
+
+func (h nearestFirst) Len() int            { return len(h) }
+func (h nearestFirst) Less(i, j int) bool  { return h[i].sim > h[j].sim }
+func (h nearestFirst) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nearestFirst) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *nearestFirst) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+type furthestFirst []hnswCandidate
+
+func (h furthestFirst) Len() int            { return len(h) }
+func (h furthestFirst) Less(i, j int) bool  { return h[i].sim < h[j].sim }
+func (h furthestFirst) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *furthestFirst) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *furthestFirst) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
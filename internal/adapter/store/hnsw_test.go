@@ -0,0 +1,249 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/bbolt"
+	"rag/internal/port"
+)
+
+func randomVectors(n, dim int, seed int64) []port.VectorItem {
+	rng := rand.New(rand.NewSource(seed))
+	items := make([]port.VectorItem, n)
+	for i := range items {
+		vec := make([]float32, dim)
+		for j := range vec {
+			vec[j] = float32(rng.NormFloat64())
+		}
+		items[i] = port.VectorItem{ID: fmt.Sprintf("chunk-%04d", i), Vector: vec}
+	}
+	return items
+}
+
+func TestHNSWVectorStore_RecallAgainstExact(t *testing.T) {
+	st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	const dim = 24
+	params := HNSWParams{M: 12, EfConstruction: 100, EfSearch: 64, Seed: 42}
+	vs, err := NewHNSWVectorStore(st.DB(), dim, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vs.Upsert(randomVectors(1500, dim, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := randomVectors(40, dim, 2)
+	hits, total := 0, 0
+	for _, q := range queries {
+		approx, err := vs.Search(q.Vector, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exact, err := vs.SearchExact(q.Vector, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := make(map[string]bool)
+		for _, r := range exact {
+			want[r.ID] = true
+		}
+		for _, r := range approx {
+			if want[r.ID] {
+				hits++
+			}
+		}
+		total += len(exact)
+	}
+
+	if recall := float64(hits) / float64(total); recall < 0.9 {
+		t.Errorf("expected recall@10 >= 0.9, got %.3f", recall)
+	}
+}
+
+func TestHNSWVectorStore_PersistsGraphAndDeletes(t *testing.T) {
+	st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	const dim = 8
+	params := HNSWParams{M: 8, EfConstruction: 50, EfSearch: 32, Seed: 42}
+	vs, err := NewHNSWVectorStore(st.DB(), dim, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := randomVectors(200, dim, 3)
+	if err := vs.Upsert(items); err != nil {
+		t.Fatal(err)
+	}
+	if err := vs.Delete([]string{items[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewHNSWVectorStore(st.DB(), dim, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reopened.graph.nodes) != 199 {
+		t.Fatalf("expected 199 persisted graph nodes, got %d", len(reopened.graph.nodes))
+	}
+
+	results, err := reopened.Search(items[0].Vector, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.ID == items[0].ID {
+			t.Error("deleted vector returned from search")
+		}
+	}
+
+	results, err = reopened.Search(items[1].Vector, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != items[1].ID {
+		t.Errorf("expected exact self match for %s, got %+v", items[1].ID, results)
+	}
+}
+
+func TestHNSWVectorStore_DeletePrunesInLinks(t *testing.T) {
+	st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	params := HNSWParams{M: 4, EfConstruction: 16, EfSearch: 16, Seed: 7}
+	vs, err := NewHNSWVectorStore(st.DB(), 8, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := randomVectors(300, 8, 5)
+	if err := vs.Upsert(items); err != nil {
+		t.Fatal(err)
+	}
+	var deleted []string
+	for _, item := range items[:100] {
+		deleted = append(deleted, item.ID)
+	}
+	if err := vs.Delete(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewHNSWVectorStore(st.DB(), 8, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []*hnswGraph{vs.graph, reopened.graph} {
+		inbound := make(map[uint64]int)
+		for _, node := range g.nodes {
+			for _, neighbors := range node.neighbors {
+				for _, nid := range neighbors {
+					if _, exists := g.nodes[nid]; !exists {
+						t.Fatalf("node %s still links to deleted node %d", node.id, nid)
+					}
+					inbound[nid]++
+				}
+			}
+		}
+		for ord, node := range g.nodes {
+			if len(node.in) != inbound[ord] {
+				t.Fatalf("node %s has %d reverse links, want %d", node.id, len(node.in), inbound[ord])
+			}
+		}
+	}
+}
+
+func TestHNSWVectorStore_StaleGraphFallsBackToExactSearch(t *testing.T) {
+	st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	flat, err := NewBoltVectorStore(st.DB(), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := randomVectors(50, 8, 9)
+	if err := flat.Upsert(items); err != nil {
+		t.Fatal(err)
+	}
+
+	params := HNSWParams{M: 8, EfConstruction: 32, EfSearch: 32, Seed: 42}
+	vs, err := NewHNSWVectorStore(st.DB(), 8, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vs.graphStale || len(vs.graph.nodes) != 0 {
+		t.Fatalf("expected opening the store to leave the graph unbuilt, got %d nodes", len(vs.graph.nodes))
+	}
+	results, err := vs.Search(items[3].Vector, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != items[3].ID {
+		t.Errorf("expected the exact match from the fallback scan, got %+v", results)
+	}
+
+	if err := vs.RepairGraph(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewHNSWVectorStore(st.DB(), 8, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.graphStale || len(reopened.graph.nodes) != len(items) {
+		t.Errorf("expected a repaired graph with %d nodes, got %d", len(items), len(reopened.graph.nodes))
+	}
+}
+
+func TestHNSWVectorStore_ResetsLegacyGraphFormat(t *testing.T) {
+	st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	params := HNSWParams{M: 8, EfConstruction: 32, EfSearch: 32, Seed: 42}
+	vs, err := NewHNSWVectorStore(st.DB(), 8, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := randomVectors(40, 8, 11)
+	if err := vs.Upsert(items); err != nil {
+		t.Fatal(err)
+	}
+
+	err = st.DB().Update(func(tx *bbolt.Tx) error {
+		legacy := []byte(`{"entry":"chunk-0000","max_level":1,"m":8,"ef_construction":32}`)
+		return tx.Bucket(bucketHNSWMeta).Put(hnswMetaKey, legacy)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewHNSWVectorStore(st.DB(), 8, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.graphStale || len(reopened.graph.nodes) != 0 {
+		t.Fatalf("expected a legacy graph to be discarded, got %d nodes", len(reopened.graph.nodes))
+	}
+	if err := reopened.RepairGraph(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reopened.graph.nodes) != len(items) {
+		t.Errorf("expected %d rebuilt nodes, got %d", len(items), len(reopened.graph.nodes))
+	}
+}
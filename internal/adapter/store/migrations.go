@@ -10,7 +10,7 @@ import (
 	"rag/config"
 )
 
-const CurrentSchemaVersion = 2
+const CurrentSchemaVersion = 6
 
 var (
 	keySchemaVersion = []byte("schema_version")
@@ -18,8 +18,9 @@ var (
 )
 
 type SchemaInfo struct {
-	Version    int    `json:"version"`
-	ConfigHash string `json:"config_hash"`
+	Version      int    `json:"version"`
+	ConfigHash   string `json:"config_hash"`
+	VectorFormat string `json:"vector_format,omitempty"`
 }
 
 func (s *BoltStore) GetSchemaInfo() (*SchemaInfo, error) {
@@ -43,6 +44,8 @@ func (s *BoltStore) GetSchemaInfo() (*SchemaInfo, error) {
 			info.ConfigHash = string(hashData)
 		}
 
+		info.VectorFormat = string(b.Get(keyVectorFormat))
+
 		return nil
 	})
 	return &info, err
@@ -67,22 +70,36 @@ func (s *BoltStore) SetSchemaInfo(info *SchemaInfo) error {
 func ComputeConfigHash(cfg *config.Config) string {
 
 	relevant := struct {
-		Stemming     bool    `json:"stemming"`
-		ChunkTokens  int     `json:"chunk_tokens"`
-		ChunkOverlap int     `json:"chunk_overlap"`
-		K1           float64 `json:"k1"`
-		B            float64 `json:"b"`
-		ASTChunking  bool    `json:"ast_chunking"`
-		EmbEnabled   bool    `json:"emb_enabled"`
-		EmbProvider  string  `json:"emb_provider"`
-		EmbModel     string  `json:"emb_model"`
+		Stemming     bool            `json:"stemming"`
+		Language     string          `json:"language,omitempty"`
+		SplitIdents  bool            `json:"split_identifiers,omitempty"`
+		SplitLangs   map[string]bool `json:"split_languages,omitempty"`
+		ChunkTokens  int             `json:"chunk_tokens"`
+		ChunkOverlap int             `json:"chunk_overlap"`
+		K1           float64         `json:"k1"`
+		B            float64         `json:"b"`
+		ASTChunking  bool            `json:"ast_chunking"`
+		Positions    bool            `json:"positions,omitempty"`
+		Fields       bool            `json:"fields,omitempty"`
+		Symbols      bool            `json:"symbols,omitempty"`
+		CallGraph    string          `json:"call_graph,omitempty"`
+		EmbEnabled   bool            `json:"emb_enabled"`
+		EmbProvider  string          `json:"emb_provider"`
+		EmbModel     string          `json:"emb_model"`
 	}{
 		Stemming:     cfg.Index.Stemming,
+		Language:     hashedLanguage(cfg),
+		SplitIdents:  cfg.Index.SplitIdentifiers,
+		SplitLangs:   cfg.Index.SplitLanguages,
 		ChunkTokens:  cfg.Index.ChunkTokens,
 		ChunkOverlap: cfg.Index.ChunkOverlap,
 		K1:           cfg.Index.K1,
 		B:            cfg.Index.B,
 		ASTChunking:  cfg.Index.ASTChunking,
+		Positions:    cfg.Index.Positions,
+		Fields:       cfg.Index.Fields,
+		Symbols:      cfg.Index.Symbols,
+		CallGraph:    typedCallGraph(cfg),
 		EmbEnabled:   cfg.Embedding.Enabled,
 		EmbProvider:  cfg.Embedding.Provider,
 		EmbModel:     cfg.Embedding.Model,
@@ -93,6 +110,22 @@ func ComputeConfigHash(cfg *config.Config) string {
 	return hex.EncodeToString(hash[:8])
 }
 
+// Automatic detection analyzes English files as before, so it keeps older indexes valid.
+func hashedLanguage(cfg *config.Config) string {
+	if cfg.Index.Language == "auto" {
+		return ""
+	}
+	return cfg.Index.Language
+}
+
+// Keeping the default mode out of the hash leaves older indexes valid.
+func typedCallGraph(cfg *config.Config) string {
+	if cfg.Index.CallGraph == "types" {
+		return cfg.Index.CallGraph
+	}
+	return ""
+}
+
 type MigrationResult struct {
 	NeedsMigration bool
 	NeedsRebuild   bool
@@ -166,15 +199,86 @@ func (s *BoltStore) runMigration(from, to int) error {
 			_, err := tx.CreateBucketIfNotExists(bucketDocChunks)
 			return err
 		})
+	case from == 2 && to == 3:
+
+		return s.db.Update(migrateBinaryPostings)
+	case from == 3 && to == 4:
+
+		return s.db.Update(migrateChunkNorms)
+	case from == 4 && to == 5:
+
+		return s.db.Update(migrateBinaryVectors)
+	case from == 5 && to == 6:
+
+		return s.db.Update(migrateChunkMetadata)
 	default:
 
 		return nil
 	}
 }
 
+func migrateBinaryPostings(tx *bbolt.Tx) error {
+	for _, name := range [][]byte{bucketChunkOrds, bucketOrdChunks} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+
+	terms := tx.Bucket(bucketTerms)
+	var legacyTerms []string
+	err := terms.ForEach(func(k, v []byte) error {
+		if isLegacyPostings(v) {
+			legacyTerms = append(legacyTerms, string(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, term := range legacyTerms {
+		postings, err := readPostings(tx, term)
+		if err != nil {
+			return fmt.Errorf("failed to convert postings for %q: %w", term, err)
+		}
+		if err := writePostings(tx, term, mergePostings(nil, postings)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Forgetting mtimes and hashes makes the next index run record chunk metadata.
+func migrateChunkMetadata(tx *bbolt.Tx) error {
+	docs := tx.Bucket(bucketDocs)
+	updates := make(map[string][]byte)
+	err := docs.ForEach(func(k, v []byte) error {
+		var meta docMeta
+		if err := json.Unmarshal(v, &meta); err != nil {
+			return nil
+		}
+		meta.ModTime, meta.ContentHash = 0, ""
+		data, err := json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+		updates[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for id, data := range updates {
+		if err := docs.Put([]byte(id), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *BoltStore) Clear() error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
-		buckets := [][]byte{bucketDocs, bucketChunks, bucketBlobs, bucketTerms, bucketDocChunks}
+		buckets := [][]byte{bucketDocs, bucketChunks, bucketBlobs, bucketTerms, bucketDocChunks, bucketChunkOrds, bucketOrdChunks, bucketNorms, bucketDocOrds, bucketOrdDocs, bucketVectors, bucketVectorCodes, bucketHNSWNodes, bucketHNSWMeta, bucketFieldTerms, bucketSymbols, bucketDocSymbols, bucketCallGraph}
 		for _, name := range buckets {
 			b := tx.Bucket(name)
 			if b == nil {
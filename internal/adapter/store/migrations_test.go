@@ -0,0 +1,77 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"rag/config"
+	"rag/internal/domain"
+)
+
+func TestComputeConfigHash_DefaultsMatchLegacyHash(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	legacy := struct {
+		Stemming     bool    `json:"stemming"`
+		ChunkTokens  int     `json:"chunk_tokens"`
+		ChunkOverlap int     `json:"chunk_overlap"`
+		K1           float64 `json:"k1"`
+		B            float64 `json:"b"`
+		ASTChunking  bool    `json:"ast_chunking"`
+		EmbEnabled   bool    `json:"emb_enabled"`
+		EmbProvider  string  `json:"emb_provider"`
+		EmbModel     string  `json:"emb_model"`
+	}{
+		Stemming:     cfg.Index.Stemming,
+		ChunkTokens:  cfg.Index.ChunkTokens,
+		ChunkOverlap: cfg.Index.ChunkOverlap,
+		K1:           cfg.Index.K1,
+		B:            cfg.Index.B,
+		ASTChunking:  cfg.Index.ASTChunking,
+		EmbEnabled:   cfg.Embedding.Enabled,
+		EmbProvider:  cfg.Embedding.Provider,
+		EmbModel:     cfg.Embedding.Model,
+	}
+	data, _ := json.Marshal(legacy)
+	sum := sha256.Sum256(data)
+
+	if got, want := ComputeConfigHash(cfg), hex.EncodeToString(sum[:8]); got != want {
+		t.Errorf("default config hash = %s, want legacy %s", got, want)
+	}
+
+	cfg.Index.Language = "german"
+	if ComputeConfigHash(cfg) == hex.EncodeToString(sum[:8]) {
+		t.Error("expected an explicit language to change the hash")
+	}
+}
+
+func TestBoltStore_ClearRemovesSymbolsAndCallGraph(t *testing.T) {
+	st, err := NewBoltStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	if err := st.PutSymbols("doc1", []domain.Symbol{{ID: "doc1:Start", Name: "Start", Type: "function", DocID: "doc1", Line: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.PutCallGraph("doc1", []domain.CallGraphEntry{{CallerID: "doc1:Start", CalleeID: "doc1:listen", Line: 4}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.Clear(); err != nil {
+		t.Fatal(err)
+	}
+
+	if symbols, err := st.GetAllSymbols(); err != nil || len(symbols) != 0 {
+		t.Errorf("expected no symbols after Clear, got %v (%v)", symbols, err)
+	}
+	if symbols, err := st.GetSymbolsByDoc("doc1"); err != nil || len(symbols) != 0 {
+		t.Errorf("expected no document symbols after Clear, got %v (%v)", symbols, err)
+	}
+	if entries, err := st.GetAllCallGraph(); err != nil || len(entries) != 0 {
+		t.Errorf("expected no call graph after Clear, got %v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+	"rag/internal/domain"
+)
+
+var (
+	bucketNorms   = []byte("norms")
+	bucketDocOrds = []byte("doc_ords")
+	bucketOrdDocs = []byte("ord_docs")
+)
+
+func encodeNorm(length int, docOrd uint64, fieldLengths map[string]int) []byte {
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64)
+	buf = binary.AppendUvarint(buf, uint64(length))
+	buf = binary.AppendUvarint(buf, docOrd)
+	for i, field := range domain.ChunkFields {
+		if fieldLengths[field] > 0 {
+			buf = binary.AppendUvarint(buf, uint64(i+1))
+			buf = binary.AppendUvarint(buf, uint64(fieldLengths[field]))
+		}
+	}
+	return buf
+}
+
+func decodeNorm(data []byte) (length int, docOrd uint64, fieldLengths map[string]int, err error) {
+	l, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, 0, nil, fmt.Errorf("corrupt norm entry")
+	}
+	d, m := binary.Uvarint(data[n:])
+	if m <= 0 {
+		return 0, 0, nil, fmt.Errorf("corrupt norm entry")
+	}
+
+	pos := n + m
+	for pos < len(data) {
+		idx, n := binary.Uvarint(data[pos:])
+		if n <= 0 || idx == 0 || int(idx) > len(domain.ChunkFields) {
+			return 0, 0, nil, fmt.Errorf("corrupt norm field entry")
+		}
+		pos += n
+		fl, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return 0, 0, nil, fmt.Errorf("corrupt norm field entry")
+		}
+		pos += n
+		if fieldLengths == nil {
+			fieldLengths = make(map[string]int)
+		}
+		fieldLengths[domain.ChunkFields[idx-1]] = int(fl)
+	}
+	return int(l), d, fieldLengths, nil
+}
+
+func docOrdinal(tx *bbolt.Tx, docID string) (uint64, error) {
+	ords := tx.Bucket(bucketDocOrds)
+	if data := ords.Get([]byte(docID)); len(data) == 8 {
+		return binary.BigEndian.Uint64(data), nil
+	}
+
+	ord, err := ords.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+	if err := ords.Put([]byte(docID), ordinalKey(ord)); err != nil {
+		return 0, err
+	}
+	if err := tx.Bucket(bucketOrdDocs).Put(ordinalKey(ord), []byte(docID)); err != nil {
+		return 0, err
+	}
+	return ord, nil
+}
+
+func deleteDocOrdinal(tx *bbolt.Tx, docID string) error {
+	ords := tx.Bucket(bucketDocOrds)
+	data := ords.Get([]byte(docID))
+	if len(data) != 8 {
+		return nil
+	}
+	if err := tx.Bucket(bucketOrdDocs).Delete(data); err != nil {
+		return err
+	}
+	return ords.Delete([]byte(docID))
+}
+
+func putNorm(tx *bbolt.Tx, chunkID, docID string, length int, fieldLengths map[string]int) error {
+	chunkOrd, err := chunkOrdinal(tx, chunkID)
+	if err != nil {
+		return err
+	}
+	docOrd, err := docOrdinal(tx, docID)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketNorms).Put(ordinalKey(chunkOrd), encodeNorm(length, docOrd, fieldLengths))
+}
+
+func deleteNorm(tx *bbolt.Tx, chunkID string) error {
+	ord, ok := lookupOrdinal(tx, chunkID)
+	if !ok {
+		return nil
+	}
+	return tx.Bucket(bucketNorms).Delete(ordinalKey(ord))
+}
+
+func (s *BoltStore) GetChunkNorms(chunkIDs []string) (map[string]domain.ChunkNorm, error) {
+	norms := make(map[string]domain.ChunkNorm, len(chunkIDs))
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		normBucket := tx.Bucket(bucketNorms)
+		ordDocs := tx.Bucket(bucketOrdDocs)
+		docIDs := make(map[uint64]string)
+
+		for _, id := range chunkIDs {
+			if _, done := norms[id]; done {
+				continue
+			}
+
+			if ord, ok := lookupOrdinal(tx, id); ok {
+				if data := normBucket.Get(ordinalKey(ord)); data != nil {
+					length, docOrd, lengths, err := decodeNorm(data)
+					if err != nil {
+						return err
+					}
+					docID, cached := docIDs[docOrd]
+					if !cached {
+						docID = string(ordDocs.Get(ordinalKey(docOrd)))
+						docIDs[docOrd] = docID
+					}
+					norms[id] = domain.ChunkNorm{DocID: docID, Length: length, FieldLengths: lengths}
+					continue
+				}
+			}
+
+			data := tx.Bucket(bucketChunks).Get([]byte(id))
+			if data == nil {
+				continue
+			}
+			var meta chunkMeta
+			if err := json.Unmarshal(data, &meta); err != nil {
+				continue
+			}
+			norms[id] = domain.ChunkNorm{DocID: meta.DocID, Length: len(meta.Tokens), FieldLengths: fieldLengths(meta.Fields)}
+		}
+		return nil
+	})
+	return norms, err
+}
+
+func migrateChunkNorms(tx *bbolt.Tx) error {
+	for _, name := range [][]byte{bucketNorms, bucketDocOrds, bucketOrdDocs} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+
+	type pendingNorm struct {
+		chunkID string
+		docID   string
+		length  int
+	}
+	var pending []pendingNorm
+	err := tx.Bucket(bucketChunks).ForEach(func(k, v []byte) error {
+		var meta chunkMeta
+		if err := json.Unmarshal(v, &meta); err != nil {
+			return nil
+		}
+		pending = append(pending, pendingNorm{chunkID: string(k), docID: meta.DocID, length: len(meta.Tokens)})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		if err := putNorm(tx, p.chunkID, p.docID, p.length, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
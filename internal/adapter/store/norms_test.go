@@ -0,0 +1,59 @@
+package store
+
+import (
+	"os"
+	"testing"
+
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+func TestBoltStore_ChunkNorms(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "norms_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	st, err := NewBoltStore(tmpDir + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	err = st.BatchIndex([]port.IndexedFile{
+		{
+			Doc:    domain.Document{ID: "doc1", Path: "/a.go"},
+			Chunks: []domain.Chunk{{ID: "c1", DocID: "doc1", Tokens: []string{"a", "b", "c"}}},
+		},
+		{
+			Doc:    domain.Document{ID: "doc2", Path: "/b.go"},
+			Chunks: []domain.Chunk{{ID: "c2", DocID: "doc2", Tokens: []string{"d"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	norms, err := st.GetChunkNorms([]string{"c1", "c2", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(norms) != 2 {
+		t.Fatalf("expected 2 norms, got %d: %+v", len(norms), norms)
+	}
+	if norms["c1"].Length != 3 || norms["c1"].DocID != "doc1" {
+		t.Errorf("unexpected norm for c1: %+v", norms["c1"])
+	}
+	if norms["c2"].Length != 1 || norms["c2"].DocID != "doc2" {
+		t.Errorf("unexpected norm for c2: %+v", norms["c2"])
+	}
+
+	if err := st.DeleteChunksByDoc("doc1"); err != nil {
+		t.Fatal(err)
+	}
+	norms, _ = st.GetChunkNorms([]string{"c1"})
+	if len(norms) != 0 {
+		t.Errorf("expected no norm after chunk deletion, got %+v", norms)
+	}
+}
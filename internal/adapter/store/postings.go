@@ -0,0 +1,236 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.etcd.io/bbolt"
+	"rag/internal/domain"
+)
+
+const (
+	postingFormatV1 byte = 1
+	postingFormatV2 byte = 2
+)
+
+var (
+	bucketChunkOrds = []byte("chunk_ords")
+	bucketOrdChunks = []byte("ord_chunks")
+)
+
+type ordPosting struct {
+	ord       uint64
+	tf        int
+	positions []int
+}
+
+func encodePostings(postings []ordPosting) []byte {
+	sort.Slice(postings, func(i, j int) bool {
+		return postings[i].ord < postings[j].ord
+	})
+
+	format := postingFormatV1
+	for _, p := range postings {
+		if len(p.positions) > 0 {
+			format = postingFormatV2
+			break
+		}
+	}
+
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(postings)*4)
+	buf = append(buf, format)
+	buf = binary.AppendUvarint(buf, uint64(len(postings)))
+
+	var prev uint64
+	for _, p := range postings {
+		buf = binary.AppendUvarint(buf, p.ord-prev)
+		buf = binary.AppendUvarint(buf, uint64(p.tf))
+		prev = p.ord
+
+		if format == postingFormatV2 {
+			buf = binary.AppendUvarint(buf, uint64(len(p.positions)))
+			prevPos := 0
+			for _, pos := range p.positions {
+				buf = binary.AppendUvarint(buf, uint64(pos-prevPos))
+				prevPos = pos
+			}
+		}
+	}
+	return buf
+}
+
+func decodePostings(data []byte) ([]ordPosting, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	format := data[0]
+	if format != postingFormatV1 && format != postingFormatV2 {
+		return nil, fmt.Errorf("unsupported posting format: %d", format)
+	}
+
+	pos := 1
+	count, n := binary.Uvarint(data[pos:])
+	if n <= 0 {
+		return nil, fmt.Errorf("corrupt posting list header")
+	}
+	pos += n
+
+	postings := make([]ordPosting, 0, count)
+	var prev uint64
+	for i := uint64(0); i < count; i++ {
+		delta, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return nil, fmt.Errorf("corrupt posting list at entry %d", i)
+		}
+		pos += n
+
+		tf, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return nil, fmt.Errorf("corrupt posting list at entry %d", i)
+		}
+		pos += n
+
+		prev += delta
+		posting := ordPosting{ord: prev, tf: int(tf)}
+
+		if format == postingFormatV2 {
+			count, n := binary.Uvarint(data[pos:])
+			if n <= 0 {
+				return nil, fmt.Errorf("corrupt posting list at entry %d", i)
+			}
+			pos += n
+
+			if count > 0 {
+				posting.positions = make([]int, 0, count)
+			}
+			prevPos := 0
+			for j := uint64(0); j < count; j++ {
+				delta, n := binary.Uvarint(data[pos:])
+				if n <= 0 {
+					return nil, fmt.Errorf("corrupt positions at entry %d", i)
+				}
+				pos += n
+				prevPos += int(delta)
+				posting.positions = append(posting.positions, prevPos)
+			}
+		}
+
+		postings = append(postings, posting)
+	}
+	return postings, nil
+}
+
+func isLegacyPostings(data []byte) bool {
+	return len(data) > 0 && (data[0] == '[' || data[0] == 'n')
+}
+
+func mergePostings(existing, added []ordPosting) []ordPosting {
+	byOrd := make(map[uint64]int, len(existing)+len(added))
+	merged := make([]ordPosting, 0, len(existing)+len(added))
+	for _, list := range [][]ordPosting{existing, added} {
+		for _, p := range list {
+			if idx, ok := byOrd[p.ord]; ok {
+				merged[idx] = p
+				continue
+			}
+			byOrd[p.ord] = len(merged)
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+func ordinalKey(ord uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, ord)
+	return key
+}
+
+func lookupOrdinal(tx *bbolt.Tx, chunkID string) (uint64, bool) {
+	data := tx.Bucket(bucketChunkOrds).Get([]byte(chunkID))
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+func chunkOrdinal(tx *bbolt.Tx, chunkID string) (uint64, error) {
+	if ord, ok := lookupOrdinal(tx, chunkID); ok {
+		return ord, nil
+	}
+
+	ords := tx.Bucket(bucketChunkOrds)
+	ord, err := ords.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+	if err := ords.Put([]byte(chunkID), ordinalKey(ord)); err != nil {
+		return 0, err
+	}
+	if err := tx.Bucket(bucketOrdChunks).Put(ordinalKey(ord), []byte(chunkID)); err != nil {
+		return 0, err
+	}
+	return ord, nil
+}
+
+func deleteOrdinal(tx *bbolt.Tx, chunkID string) error {
+	ord, ok := lookupOrdinal(tx, chunkID)
+	if !ok {
+		return nil
+	}
+	if err := tx.Bucket(bucketOrdChunks).Delete(ordinalKey(ord)); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketChunkOrds).Delete([]byte(chunkID))
+}
+
+func readPostings(tx *bbolt.Tx, term string) ([]ordPosting, error) {
+	data := tx.Bucket(bucketTerms).Get([]byte(term))
+	if data == nil {
+		return nil, nil
+	}
+	if isLegacyPostings(data) {
+		return legacyToOrdPostings(tx, data)
+	}
+	return decodePostings(data)
+}
+
+func writePostings(tx *bbolt.Tx, term string, postings []ordPosting) error {
+	b := tx.Bucket(bucketTerms)
+	if len(postings) == 0 {
+		return b.Delete([]byte(term))
+	}
+	return b.Put([]byte(term), encodePostings(postings))
+}
+
+func legacyToOrdPostings(tx *bbolt.Tx, data []byte) ([]ordPosting, error) {
+	var legacy []domain.Posting
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+
+	postings := make([]ordPosting, 0, len(legacy))
+	for _, p := range legacy {
+		ord, err := chunkOrdinal(tx, p.ChunkID)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, ordPosting{ord: ord, tf: p.TF})
+	}
+	return postings, nil
+}
+
+func resolvePostings(tx *bbolt.Tx, postings []ordPosting) []domain.Posting {
+	ordChunks := tx.Bucket(bucketOrdChunks)
+	resolved := make([]domain.Posting, 0, len(postings))
+	for _, p := range postings {
+		chunkID := ordChunks.Get(ordinalKey(p.ord))
+		if chunkID == nil {
+			continue
+		}
+		resolved = append(resolved, domain.Posting{ChunkID: string(chunkID), TF: p.tf, Positions: p.positions})
+	}
+	return resolved
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"go.etcd.io/bbolt"
+	"rag/config"
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+func TestEncodeDecodePostings(t *testing.T) {
+	postings := []ordPosting{
+		{ord: 300, tf: 2},
+		{ord: 1, tf: 7},
+		{ord: 70000, tf: 1},
+	}
+
+	decoded, err := decodePostings(encodePostings(postings))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ordPosting{{ord: 1, tf: 7}, {ord: 300, tf: 2}, {ord: 70000, tf: 1}}
+	if len(decoded) != len(expected) {
+		t.Fatalf("expected %d postings, got %d", len(expected), len(decoded))
+	}
+	for i := range expected {
+		if decoded[i].ord != expected[i].ord || decoded[i].tf != expected[i].tf {
+			t.Errorf("posting %d: expected %+v, got %+v", i, expected[i], decoded[i])
+		}
+	}
+}
+
+func TestEncodeDecodePostings_Positions(t *testing.T) {
+	postings := []ordPosting{
+		{ord: 9, tf: 1},
+		{ord: 4, tf: 3, positions: []int{0, 17, 300}},
+	}
+
+	data := encodePostings(postings)
+	if data[0] != postingFormatV2 {
+		t.Fatalf("expected positional format, got %d", data[0])
+	}
+
+	decoded, err := decodePostings(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 2 || decoded[0].ord != 4 || !reflect.DeepEqual(decoded[0].positions, []int{0, 17, 300}) {
+		t.Errorf("unexpected positional postings: %+v", decoded)
+	}
+	if decoded[1].ord != 9 || decoded[1].positions != nil {
+		t.Errorf("expected posting without positions, got %+v", decoded[1])
+	}
+
+	if _, err := decodePostings(data[:len(data)-1]); err == nil {
+		t.Error("expected error for truncated positions")
+	}
+}
+
+func TestDecodePostings_Corrupt(t *testing.T) {
+	if _, err := decodePostings([]byte{postingFormatV1, 3, 1}); err == nil {
+		t.Error("expected error for truncated posting list")
+	}
+	if _, err := decodePostings([]byte{99, 0}); err == nil {
+		t.Error("expected error for unknown format version")
+	}
+}
+
+func TestBoltStore_PostingsRoundTrip(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "postings_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	st, err := NewBoltStore(tmpDir + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	err = st.BatchIndex([]port.IndexedFile{{
+		Doc: domain.Document{ID: "doc1", Path: "/a.go"},
+		Chunks: []domain.Chunk{
+			{ID: "c1", DocID: "doc1", Tokens: []string{"pool", "pool"}},
+			{ID: "c2", DocID: "doc1", Tokens: []string{"pool"}},
+		},
+		Postings: map[string]map[string]int{
+			"pool": {"c1": 2, "c2": 1},
+		},
+		Positions: map[string]map[string][]int{
+			"pool": {"c1": {0, 1}, "c2": {0}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postings, err := st.GetPostings("pool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range postings {
+		if p.ChunkID == "c1" && !reflect.DeepEqual(p.Positions, []int{0, 1}) {
+			t.Errorf("expected positions to round-trip, got %+v", p)
+		}
+	}
+
+	if err := st.PutPosting("pool", "c2", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	postings, err = st.GetPostings("pool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tfs := make(map[string]int)
+	for _, p := range postings {
+		tfs[p.ChunkID] = p.TF
+	}
+	if len(tfs) != 2 || tfs["c1"] != 2 || tfs["c2"] != 5 {
+		t.Errorf("unexpected postings: %+v", postings)
+	}
+
+	if err := st.DeletePostings("c1", []string{"pool"}); err != nil {
+		t.Fatal(err)
+	}
+	postings, _ = st.GetPostings("pool")
+	if len(postings) != 1 || postings[0].ChunkID != "c2" {
+		t.Errorf("expected only c2 after delete, got %+v", postings)
+	}
+
+	if err := st.DeletePostings("c2", []string{"pool"}); err != nil {
+		t.Fatal(err)
+	}
+	terms, _ := st.AllTerms()
+	if len(terms) != 0 {
+		t.Errorf("expected empty term dictionary, got %v", terms)
+	}
+}
+
+func TestMigrate_LegacyJSONPostings(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "postings_migrate_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	st, err := NewBoltStore(tmpDir + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	cfg := config.DefaultConfig()
+	if err := st.SetSchemaInfo(&SchemaInfo{Version: 2, ConfigHash: ComputeConfigHash(cfg)}); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, _ := json.Marshal([]domain.Posting{{ChunkID: "c1", TF: 3}, {ChunkID: "c2", TF: 1}})
+	err = st.DB().Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketTerms).Put([]byte("auth"), legacy)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postings, err := st.GetPostings("auth")
+	if err != nil || len(postings) != 2 {
+		t.Fatalf("expected legacy postings to remain readable, got %v (err=%v)", postings, err)
+	}
+
+	if err := st.Migrate(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	err = st.DB().View(func(tx *bbolt.Tx) error {
+		if isLegacyPostings(tx.Bucket(bucketTerms).Get([]byte("auth"))) {
+			t.Error("expected postings to be rewritten in binary format")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postings, err = st.GetPostings("auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postings) != 2 || postings[0].ChunkID != "c1" || postings[0].TF != 3 {
+		t.Errorf("unexpected postings after migration: %+v", postings)
+	}
+
+	info, _ := st.GetSchemaInfo()
+	if info.Version != CurrentSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", CurrentSchemaVersion, info.Version)
+	}
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	QuantizationNone   = "none"
+	QuantizationInt8   = "int8"
+	QuantizationBinary = "binary"
+)
+
+type quantizedVector struct {
+	codes []byte
+	scale float32
+	norm  float64
+}
+
+func quantizeVector(mode string, vec []float32) quantizedVector {
+	q := quantizedVector{}
+
+	switch mode {
+	case QuantizationInt8:
+		var maxAbs float64
+		for _, v := range vec {
+			maxAbs = math.Max(maxAbs, math.Abs(float64(v)))
+		}
+		q.codes = make([]byte, len(vec))
+		if maxAbs > 0 {
+			q.scale = float32(maxAbs / 127)
+			for i, v := range vec {
+				q.codes[i] = byte(int8(math.Round(float64(v) / float64(q.scale))))
+			}
+		}
+	case QuantizationBinary:
+		q.codes = make([]byte, (len(vec)+7)/8)
+		for i, v := range vec {
+			if v > 0 {
+				q.codes[i/8] |= 1 << (i % 8)
+			}
+		}
+		q.scale = 1
+	}
+
+	q.norm = q.computeNorm(mode, len(vec))
+	return q
+}
+
+func (q quantizedVector) computeNorm(mode string, dimension int) float64 {
+	switch mode {
+	case QuantizationInt8:
+		var sum float64
+		for _, c := range q.codes {
+			v := float64(int8(c))
+			sum += v * v
+		}
+		return float64(q.scale) * math.Sqrt(sum)
+	case QuantizationBinary:
+		return math.Sqrt(float64(dimension))
+	}
+	return 0
+}
+
+func (q quantizedVector) similarity(mode string, query []float32) float64 {
+	var dot, queryNorm float64
+
+	switch mode {
+	case QuantizationInt8:
+		if len(q.codes) != len(query) {
+			return 0
+		}
+		for i, x := range query {
+			dot += float64(x) * float64(int8(q.codes[i]))
+			queryNorm += float64(x) * float64(x)
+		}
+		dot *= float64(q.scale)
+	case QuantizationBinary:
+		if len(q.codes)*8 < len(query) {
+			return 0
+		}
+		for i, x := range query {
+			if q.codes[i/8]&(1<<(i%8)) != 0 {
+				dot += float64(x)
+			} else {
+				dot -= float64(x)
+			}
+			queryNorm += float64(x) * float64(x)
+		}
+	}
+
+	if queryNorm == 0 || q.norm == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(queryNorm) * q.norm)
+}
+
+func (q quantizedVector) dequantize(mode string, dimension int) []float32 {
+	vec := make([]float32, dimension)
+	switch mode {
+	case QuantizationInt8:
+		for i := range vec {
+			vec[i] = float32(int8(q.codes[i])) * q.scale
+		}
+	case QuantizationBinary:
+		for i := range vec {
+			if q.codes[i/8]&(1<<(i%8)) != 0 {
+				vec[i] = 1
+			} else {
+				vec[i] = -1
+			}
+		}
+	}
+	return vec
+}
+
+func encodeQuantized(q quantizedVector) []byte {
+	buf := make([]byte, 4+len(q.codes))
+	binary.LittleEndian.PutUint32(buf, math.Float32bits(q.scale))
+	copy(buf[4:], q.codes)
+	return buf
+}
+
+func decodeQuantized(mode string, dimension int, data []byte) (quantizedVector, error) {
+	want := dimension
+	if mode == QuantizationBinary {
+		want = (dimension + 7) / 8
+	}
+	if len(data) != 4+want {
+		return quantizedVector{}, fmt.Errorf("invalid %s code length: %d", mode, len(data))
+	}
+
+	q := quantizedVector{
+		scale: math.Float32frombits(binary.LittleEndian.Uint32(data)),
+		codes: append([]byte(nil), data[4:]...),
+	}
+	q.norm = q.computeNorm(mode, dimension)
+	return q, nil
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.etcd.io/bbolt"
+)
+
+const vectorFormatV1 byte = 1
+
+var (
+	keyVectorFormat = []byte("vector_format")
+)
+
+func vectorFormat(quantization string) string {
+	if quantization == "" || quantization == QuantizationNone {
+		return "float32"
+	}
+	return "float32+" + quantization
+}
+
+func encodeStoredVector(vec []float32, metadata map[string]string) []byte {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+4*len(vec))
+	buf = append(buf, vectorFormatV1)
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		buf = appendVectorString(buf, k)
+		buf = appendVectorString(buf, metadata[k])
+	}
+	return append(buf, encodeFloat32s(vec)...)
+}
+
+func appendVectorString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func decodeStoredVector(data []byte) (storedVector, error) {
+	var stored storedVector
+	if len(data) == 0 {
+		return stored, fmt.Errorf("empty vector record")
+	}
+
+	if data[0] == '{' {
+		err := json.Unmarshal(data, &stored)
+		return stored, err
+	}
+	if data[0] != vectorFormatV1 {
+		return stored, fmt.Errorf("unknown vector format: %d", data[0])
+	}
+	data = data[1:]
+
+	readString := func() (string, error) {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < size {
+			return "", fmt.Errorf("truncated vector record")
+		}
+		s := string(data[n : n+int(size)])
+		data = data[n+int(size):]
+		return s, nil
+	}
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return stored, fmt.Errorf("truncated vector record")
+	}
+	data = data[n:]
+
+	if count > 0 {
+		stored.Metadata = make(map[string]string, count)
+		for i := uint64(0); i < count; i++ {
+			k, err := readString()
+			if err != nil {
+				return stored, err
+			}
+			v, err := readString()
+			if err != nil {
+				return stored, err
+			}
+			stored.Metadata[k] = v
+		}
+	}
+
+	vec, err := decodeFloat32s(data)
+	if err != nil {
+		return stored, err
+	}
+	stored.Vector = vec
+	return stored, nil
+}
+
+// decodeStoredFloats reads only the vector, skipping metadata.
+func decodeStoredFloats(data []byte) ([]float32, error) {
+	if len(data) == 0 || data[0] != vectorFormatV1 {
+		stored, err := decodeStoredVector(data)
+		return stored.Vector, err
+	}
+	data = data[1:]
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("truncated vector record")
+	}
+	data = data[n:]
+	for i := uint64(0); i < 2*count; i++ {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < size {
+			return nil, fmt.Errorf("truncated vector record")
+		}
+		data = data[n+int(size):]
+	}
+	return decodeFloat32s(data)
+}
+
+func migrateBinaryVectors(tx *bbolt.Tx) error {
+	b, err := tx.CreateBucketIfNotExists(bucketVectors)
+	if err != nil {
+		return err
+	}
+
+	legacy := make(map[string]storedVector)
+	err = b.ForEach(func(k, v []byte) error {
+		if len(v) == 0 || v[0] != '{' {
+			return nil
+		}
+		stored, err := decodeStoredVector(v)
+		if err != nil {
+			return nil
+		}
+		legacy[string(k)] = stored
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for id, stored := range legacy {
+		if err := b.Put([]byte(id), encodeStoredVector(stored.Vector, stored.Metadata)); err != nil {
+			return err
+		}
+	}
+
+	stats := tx.Bucket(bucketStats)
+	if stats.Get(keyVectorFormat) == nil {
+		return stats.Put(keyVectorFormat, []byte(vectorFormat(QuantizationNone)))
+	}
+	return nil
+}
@@ -1,7 +1,6 @@
 package store
 
 import (
-	"encoding/json"
 	"fmt"
 	"math"
 	"sort"
@@ -12,20 +11,31 @@ import (
 )
 
 var (
-	bucketVectors = []byte("vectors")
+	bucketVectors     = []byte("vectors")
+	bucketVectorCodes = []byte("vector_codes")
 )
 
-type BoltVectorStore struct {
-	db        *bbolt.DB
-	dimension int
-	mu        sync.RWMutex
+type VectorStoreOptions struct {
+	HNSW          *HNSWParams
+	Quantization  string
+	RescoreFactor int
+}
 
-	vectors map[string]vectorEntry
+type BoltVectorStore struct {
+	db            *bbolt.DB
+	dimension     int
+	quantization  string
+	rescoreFactor int
+	mu            sync.RWMutex
+
+	vectors    map[string]vectorEntry
+	graph      *hnswGraph
+	graphStale bool
 }
 
+// Full vectors stay in bbolt; only quantized codes are held in memory.
 type vectorEntry struct {
-	vector   []float32
-	metadata map[string]string
+	quantized quantizedVector
 }
 
 type storedVector struct {
@@ -34,46 +44,262 @@ type storedVector struct {
 }
 
 func NewBoltVectorStore(db *bbolt.DB, dimension int) (*BoltVectorStore, error) {
+	return NewBoltVectorStoreWithOptions(db, dimension, VectorStoreOptions{})
+}
+
+func NewHNSWVectorStore(db *bbolt.DB, dimension int, params HNSWParams) (*BoltVectorStore, error) {
+	return NewBoltVectorStoreWithOptions(db, dimension, VectorStoreOptions{HNSW: &params})
+}
+
+func NewBoltVectorStoreWithOptions(db *bbolt.DB, dimension int, opts VectorStoreOptions) (*BoltVectorStore, error) {
+	quantization := opts.Quantization
+	switch quantization {
+	case "":
+		quantization = QuantizationNone
+	case QuantizationNone, QuantizationInt8, QuantizationBinary:
+	default:
+		return nil, fmt.Errorf("unsupported vector quantization: %s", quantization)
+	}
+
+	rescoreFactor := opts.RescoreFactor
+	if rescoreFactor < 1 {
+		rescoreFactor = 4
+	}
 
 	err := db.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(bucketVectors)
-		return err
+		for _, name := range [][]byte{bucketVectors, bucketVectorCodes, bucketStats, bucketChunkOrds, bucketOrdChunks} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create vectors bucket: %w", err)
 	}
 
 	store := &BoltVectorStore{
-		db:        db,
-		dimension: dimension,
-		vectors:   make(map[string]vectorEntry),
+		db:            db,
+		dimension:     dimension,
+		quantization:  quantization,
+		rescoreFactor: rescoreFactor,
+		vectors:       make(map[string]vectorEntry),
 	}
 
 	if err := store.loadVectors(); err != nil {
 		return nil, fmt.Errorf("failed to load vectors: %w", err)
 	}
 
+	if opts.HNSW != nil {
+		store.graph = newHNSWGraph(*opts.HNSW)
+		if err := store.loadGraph(); err != nil {
+			return nil, fmt.Errorf("failed to load hnsw graph: %w", err)
+		}
+	}
+
 	return store, nil
 }
 
+func (s *BoltVectorStore) quantized() bool {
+	return s.quantization != QuantizationNone
+}
+
+// vectorSpace scores against quantized codes, or full vectors read from the transaction.
+type vectorSpace struct {
+	store   *BoltVectorStore
+	vectors *bbolt.Bucket
+}
+
+func (s *BoltVectorStore) space(tx *bbolt.Tx) vectorSpace {
+	return vectorSpace{store: s, vectors: tx.Bucket(bucketVectors)}
+}
+
+func (v vectorSpace) similarity(query []float32, id string) float64 {
+	if v.store.quantized() {
+		return v.store.vectors[id].quantized.similarity(v.store.quantization, query)
+	}
+	return cosineSimilarity(query, v.vector(id))
+}
+
+func (v vectorSpace) vector(id string) []float32 {
+	if v.store.quantized() {
+		return v.store.vectors[id].quantized.dequantize(v.store.quantization, v.store.dimension)
+	}
+	vec, err := decodeStoredFloats(v.vectors.Get([]byte(id)))
+	if err != nil {
+		return nil
+	}
+	return vec
+}
+
+// graphOrdinal reuses a node's ordinal, or the chunk ordinal for new nodes.
+func (s *BoltVectorStore) graphOrdinal(tx *bbolt.Tx, id string) (uint64, error) {
+	if ord, exists := s.graph.ords[id]; exists {
+		return ord, nil
+	}
+	return chunkOrdinal(tx, id)
+}
+
+func (s *BoltVectorStore) loadGraph() error {
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		_, err := s.graph.load(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	stale, missing := s.graphDiff()
+	s.graphStale = len(stale) > 0 || len(missing) > 0
+	return nil
+}
+
+// graphDiff lists graph nodes without a vector and vectors without a node.
+func (s *BoltVectorStore) graphDiff() (stale, missing []string) {
+	for id := range s.graph.ords {
+		if _, exists := s.vectors[id]; !exists {
+			stale = append(stale, id)
+		}
+	}
+	for id := range s.vectors {
+		if _, exists := s.graph.ords[id]; !exists {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	return stale, missing
+}
+
+// Searches fall back to an exact scan until a stale graph is repaired.
+func (s *BoltVectorStore) RepairGraph() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.graph == nil || !s.graphStale {
+		return nil
+	}
+	stale, missing := s.graphDiff()
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		space := s.space(tx)
+		s.graph.remove(space, stale...)
+		for _, id := range missing {
+			ord, err := s.graphOrdinal(tx, id)
+			if err != nil {
+				return err
+			}
+			s.graph.insert(space, id, ord)
+		}
+		if err := s.graph.flush(tx); err != nil {
+			return err
+		}
+		s.graphStale = false
+		return nil
+	})
+}
+
 func (s *BoltVectorStore) loadVectors() error {
-	return s.db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucketVectors)
-		if b == nil {
+	if s.quantized() {
+		return s.loadQuantized()
+	}
+
+	var recorded string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		recorded = string(tx.Bucket(bucketStats).Get(keyVectorFormat))
+		return tx.Bucket(bucketVectors).ForEach(func(k, _ []byte) error {
+			s.vectors[string(k)] = vectorEntry{}
 			return nil
-		}
+		})
+	})
+	if err != nil || recorded == vectorFormat(s.quantization) {
+		return err
+	}
 
-		return b.ForEach(func(k, v []byte) error {
-			var stored storedVector
-			if err := json.Unmarshal(v, &stored); err != nil {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketStats).Put(keyVectorFormat, []byte(vectorFormat(s.quantization)))
+	})
+}
+
+func (s *BoltVectorStore) loadQuantized() error {
+	format := vectorFormat(s.quantization)
+	var reset bool
+	var missing, stale []string
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		reset = string(tx.Bucket(bucketStats).Get(keyVectorFormat)) != format
+
+		if !reset {
+			err := tx.Bucket(bucketVectorCodes).ForEach(func(k, v []byte) error {
+				q, err := decodeQuantized(s.quantization, s.dimension, v)
+				if err != nil {
+					stale = append(stale, string(k))
+					return nil
+				}
+				s.vectors[string(k)] = vectorEntry{quantized: q}
 				return nil
+			})
+			if err != nil {
+				return err
 			}
-			s.vectors[string(k)] = vectorEntry{
-				vector:   stored.Vector,
-				metadata: stored.Metadata,
+		}
+
+		present := make(map[string]struct{}, len(s.vectors))
+		err := tx.Bucket(bucketVectors).ForEach(func(k, _ []byte) error {
+			present[string(k)] = struct{}{}
+			if _, exists := s.vectors[string(k)]; !exists {
+				missing = append(missing, string(k))
 			}
 			return nil
 		})
+		if err != nil {
+			return err
+		}
+
+		for id := range s.vectors {
+			if _, exists := present[id]; !exists {
+				stale = append(stale, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !reset && len(missing) == 0 && len(stale) == 0 {
+		return nil
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if reset {
+			if err := tx.DeleteBucket(bucketVectorCodes); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(bucketVectorCodes); err != nil {
+				return err
+			}
+		}
+
+		codes := tx.Bucket(bucketVectorCodes)
+		for _, id := range stale {
+			if err := codes.Delete([]byte(id)); err != nil {
+				return err
+			}
+			delete(s.vectors, id)
+		}
+
+		vectors := tx.Bucket(bucketVectors)
+		for _, id := range missing {
+			stored, err := decodeStoredVector(vectors.Get([]byte(id)))
+			if err != nil || len(stored.Vector) != s.dimension {
+				continue
+			}
+			q := quantizeVector(s.quantization, stored.Vector)
+			if err := codes.Put([]byte(id), encodeQuantized(q)); err != nil {
+				return err
+			}
+			s.vectors[id] = vectorEntry{quantized: q}
+		}
+
+		return tx.Bucket(bucketStats).Put(keyVectorFormat, []byte(format))
 	})
 }
 
@@ -86,32 +312,41 @@ func (s *BoltVectorStore) Upsert(items []port.VectorItem) error {
 		if b == nil {
 			return fmt.Errorf("vectors bucket not found")
 		}
+		codes := tx.Bucket(bucketVectorCodes)
 
 		for _, item := range items {
 			if len(item.Vector) != s.dimension {
 				return fmt.Errorf("vector dimension mismatch: expected %d, got %d", s.dimension, len(item.Vector))
 			}
 
-			stored := storedVector{
-				Vector:   item.Vector,
-				Metadata: item.Metadata,
-			}
-			data, err := json.Marshal(stored)
-			if err != nil {
+			if err := b.Put([]byte(item.ID), encodeStoredVector(item.Vector, item.Metadata)); err != nil {
 				return err
 			}
 
-			if err := b.Put([]byte(item.ID), data); err != nil {
-				return err
+			if !s.quantized() {
+				s.vectors[item.ID] = vectorEntry{}
+				continue
 			}
 
-			s.vectors[item.ID] = vectorEntry{
-				vector:   item.Vector,
-				metadata: item.Metadata,
+			q := quantizeVector(s.quantization, item.Vector)
+			if err := codes.Put([]byte(item.ID), encodeQuantized(q)); err != nil {
+				return err
 			}
+			s.vectors[item.ID] = vectorEntry{quantized: q}
 		}
 
-		return nil
+		if s.graph == nil || s.graphStale {
+			return nil
+		}
+		space := s.space(tx)
+		for _, item := range items {
+			ord, err := s.graphOrdinal(tx, item.ID)
+			if err != nil {
+				return err
+			}
+			s.graph.insert(space, item.ID, ord)
+		}
+		return s.graph.flush(tx)
 	})
 }
 
@@ -123,44 +358,74 @@ func (s *BoltVectorStore) Search(query []float32, k int) ([]port.VectorResult, e
 		return nil, fmt.Errorf("query dimension mismatch: expected %d, got %d", s.dimension, len(query))
 	}
 
-	if len(s.vectors) == 0 {
-		return nil, nil
+	candidates := k
+	if s.quantized() {
+		candidates = k * s.rescoreFactor
 	}
 
-	type scored struct {
-		id       string
-		score    float64
-		metadata map[string]string
+	var results []port.VectorResult
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		space := s.space(tx)
+		var ids []string
+		if s.graph != nil && !s.graphStale {
+			ids = s.graph.search(space, query, candidates)
+		} else {
+			ids = s.scan(space, query, candidates)
+		}
+		results = s.scoreStored(tx, query, ids)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) > k {
+		results = results[:k]
 	}
+	return results, nil
+}
 
-	scores := make([]scored, 0, len(s.vectors))
-	for id, entry := range s.vectors {
-		sim := cosineSimilarity(query, entry.vector)
-		scores = append(scores, scored{
-			id:       id,
-			score:    sim,
-			metadata: entry.metadata,
-		})
+func (s *BoltVectorStore) scan(space vectorSpace, query []float32, k int) []string {
+	if !s.quantized() {
+		return s.vectorIDs()
 	}
 
-	sort.Slice(scores, func(i, j int) bool {
-		return scores[i].score > scores[j].score
-	})
+	scores := make([]port.VectorResult, 0, len(s.vectors))
+	for id := range s.vectors {
+		scores = append(scores, port.VectorResult{ID: id, Score: space.similarity(query, id)})
+	}
+	sortVectorResults(scores)
 
 	if k > len(scores) {
 		k = len(scores)
 	}
+	ids := make([]string, k)
+	for i := range ids {
+		ids[i] = scores[i].ID
+	}
+	return ids
+}
 
-	results := make([]port.VectorResult, k)
-	for i := 0; i < k; i++ {
-		results[i] = port.VectorResult{
-			ID:       scores[i].id,
-			Score:    scores[i].score,
-			Metadata: scores[i].metadata,
-		}
+func (s *BoltVectorStore) SearchExact(query []float32, k int) ([]port.VectorResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(query) != s.dimension {
+		return nil, fmt.Errorf("query dimension mismatch: expected %d, got %d", s.dimension, len(query))
 	}
 
-	return results, nil
+	var results []port.VectorResult
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		results = s.scoreStored(tx, query, s.vectorIDs())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if k > len(results) {
+		k = len(results)
+	}
+	return results[:k], nil
 }
 
 func (s *BoltVectorStore) SearchSubset(query []float32, ids []string) ([]port.VectorResult, error) {
@@ -171,25 +436,36 @@ func (s *BoltVectorStore) SearchSubset(query []float32, ids []string) ([]port.Ve
 		return nil, fmt.Errorf("query dimension mismatch: expected %d, got %d", s.dimension, len(query))
 	}
 
+	var results []port.VectorResult
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		results = s.scoreStored(tx, query, ids)
+		return nil
+	})
+	return results, err
+}
+
+// scoreStored ranks ids by exact similarity against their stored full vectors.
+func (s *BoltVectorStore) scoreStored(tx *bbolt.Tx, query []float32, ids []string) []port.VectorResult {
 	results := make([]port.VectorResult, 0, len(ids))
+	b := tx.Bucket(bucketVectors)
 	for _, id := range ids {
-		entry, exists := s.vectors[id]
-		if !exists {
+		data := b.Get([]byte(id))
+		if data == nil {
+			continue
+		}
+		stored, err := decodeStoredVector(data)
+		if err != nil {
 			continue
 		}
-		sim := cosineSimilarity(query, entry.vector)
 		results = append(results, port.VectorResult{
 			ID:       id,
-			Score:    sim,
-			Metadata: entry.metadata,
+			Score:    cosineSimilarity(query, stored.Vector),
+			Metadata: stored.Metadata,
 		})
 	}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
-
-	return results, nil
+	sortVectorResults(results)
+	return results
 }
 
 func (s *BoltVectorStore) Delete(ids []string) error {
@@ -201,24 +477,64 @@ func (s *BoltVectorStore) Delete(ids []string) error {
 		if b == nil {
 			return nil
 		}
+		codes := tx.Bucket(bucketVectorCodes)
 
 		for _, id := range ids {
 			if err := b.Delete([]byte(id)); err != nil {
 				return err
 			}
+			if codes != nil {
+				if err := codes.Delete([]byte(id)); err != nil {
+					return err
+				}
+			}
 			delete(s.vectors, id)
 		}
 
-		return nil
+		if s.graph == nil || s.graphStale {
+			return nil
+		}
+		s.graph.remove(s.space(tx), ids...)
+		return s.graph.flush(tx)
 	})
 }
 
+func (s *BoltVectorStore) IDs() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vectorIDs(), nil
+}
+
+func (s *BoltVectorStore) vectorIDs() []string {
+	ids := make([]string, 0, len(s.vectors))
+	for id := range s.vectors {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *BoltVectorStore) Has(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, exists := s.vectors[id]
+	return exists
+}
+
 func (s *BoltVectorStore) Count() (int, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return len(s.vectors), nil
 }
 
+func sortVectorResults(results []port.VectorResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
 func cosineSimilarity(a, b []float32) float64 {
 	if len(a) != len(b) {
 		return 0
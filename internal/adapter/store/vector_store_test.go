@@ -0,0 +1,181 @@
+package store
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/bbolt"
+	"rag/config"
+)
+
+func TestMigrate_LegacyJSONVectors(t *testing.T) {
+	st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	cfg := config.DefaultConfig()
+	if err := st.SetSchemaInfo(&SchemaInfo{Version: 4, ConfigHash: ComputeConfigHash(cfg)}); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, _ := json.Marshal(storedVector{Vector: []float32{0.5, -1, 2}, Metadata: map[string]string{"lang": "go"}})
+	err = st.DB().Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketVectors)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("c1"), legacy)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.Migrate(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	err = st.DB().View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(bucketVectors).Get([]byte("c1"))
+		if len(data) != 1+1+(1+4)+(1+2)+3*4 || data[0] != vectorFormatV1 {
+			t.Errorf("expected binary vector record, got %d bytes", len(data))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ := st.GetSchemaInfo()
+	if info.VectorFormat != "float32" {
+		t.Errorf("expected vector format to be recorded, got %q", info.VectorFormat)
+	}
+
+	vs, err := NewBoltVectorStore(st.DB(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := vs.Search([]float32{0.5, -1, 2}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != "c1" || results[0].Metadata["lang"] != "go" {
+		t.Errorf("unexpected results after migration: %+v", results)
+	}
+}
+
+func TestQuantizedVectorStore_RescoresTopCandidates(t *testing.T) {
+	for _, tc := range []struct {
+		quantization string
+		rescore      int
+		minRecall    float64
+	}{
+		{QuantizationInt8, 2, 0.95},
+		{QuantizationBinary, 10, 0.8},
+	} {
+		t.Run(tc.quantization, func(t *testing.T) {
+			st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer st.Close()
+
+			const dim = 32
+			opts := VectorStoreOptions{Quantization: tc.quantization, RescoreFactor: tc.rescore}
+			vs, err := NewBoltVectorStoreWithOptions(st.DB(), dim, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := vs.Upsert(randomVectors(800, dim, 5)); err != nil {
+				t.Fatal(err)
+			}
+
+			reopened, err := NewBoltVectorStoreWithOptions(st.DB(), dim, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count, _ := reopened.Count(); count != 800 {
+				t.Fatalf("expected 800 quantized vectors after reopen, got %d", count)
+			}
+			for id, entry := range reopened.vectors {
+				if len(entry.quantized.codes) == 0 {
+					t.Fatalf("expected quantized codes in memory for %s", id)
+				}
+			}
+
+			hits, total := 0, 0
+			for _, q := range randomVectors(30, dim, 6) {
+				approx, err := reopened.Search(q.Vector, 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				exact, err := reopened.SearchExact(q.Vector, 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := make(map[string]float64)
+				for _, r := range exact {
+					want[r.ID] = r.Score
+				}
+				for _, r := range approx {
+					if score, ok := want[r.ID]; ok {
+						hits++
+						if score != r.Score {
+							t.Errorf("expected rescored similarity %f for %s, got %f", score, r.ID, r.Score)
+						}
+					}
+				}
+				total += len(exact)
+			}
+			if recall := float64(hits) / float64(total); recall < tc.minRecall {
+				t.Errorf("expected recall@10 >= %.2f, got %.3f", tc.minRecall, recall)
+			}
+
+			info, _ := st.GetSchemaInfo()
+			if info.VectorFormat != vectorFormat(tc.quantization) {
+				t.Errorf("expected vector format %q, got %q", vectorFormat(tc.quantization), info.VectorFormat)
+			}
+		})
+	}
+}
+
+func TestQuantizedVectorStore_RebuildsCodesWhenFormatChanges(t *testing.T) {
+	st, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	const dim = 16
+	vs, err := NewBoltVectorStoreWithOptions(st.DB(), dim, VectorStoreOptions{Quantization: QuantizationInt8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := randomVectors(50, dim, 7)
+	if err := vs.Upsert(items); err != nil {
+		t.Fatal(err)
+	}
+
+	binaryStore, err := NewBoltVectorStoreWithOptions(st.DB(), dim, VectorStoreOptions{Quantization: QuantizationBinary})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := binaryStore.Count(); count != 50 {
+		t.Fatalf("expected codes to be rebuilt for all 50 vectors, got %d", count)
+	}
+	for id, entry := range binaryStore.vectors {
+		if len(entry.quantized.codes) != dim/8 {
+			t.Fatalf("expected binary codes for %s, got %d bytes", id, len(entry.quantized.codes))
+		}
+	}
+
+	results, err := binaryStore.Search(items[3].Vector, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != items[3].ID {
+		t.Errorf("expected exact self match after rescoring, got %+v", results)
+	}
+}
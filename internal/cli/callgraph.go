@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"rag/internal/usecase"
+)
+
+var (
+	callDepth    int
+	callJSON     bool
+	callDOT      bool
+	callLang     string
+	callExternal bool
+)
+
+var callersCmd = &cobra.Command{
+	Use:   "callers <name>",
+	Short: "Show who calls a function or method",
+	Long: `Show the functions and methods that call <name>, as a tree with file:line
+citations. Methods can be named by their bare name (Start), with their
+receiver (Server.Start) or with a pointer receiver (*Server.Start).
+
+Examples:
+  rag callers NewBM25Retriever
+  rag callers Server.Start --depth 3
+  rag callers Search --dot | dot -Tsvg > callers.svg`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCallGraph(args[0], false)
+	},
+}
+
+var calleesCmd = &cobra.Command{
+	Use:   "callees <name>",
+	Short: "Show what a function or method calls",
+	Long: `Show the indexed functions and methods called by <name>, as a tree with
+file:line citations. Calls that do not resolve to an indexed symbol (standard
+library, dependencies, ambiguous names) are listed with --external.
+
+Examples:
+  rag callees runQuery
+  rag callees Server.Start --depth 0 --json
+  rag callees Index --dot | dot -Tpng > callees.png`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCallGraph(args[0], true)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{callersCmd, calleesCmd} {
+		rootCmd.AddCommand(cmd)
+		cmd.Flags().IntVar(&callDepth, "depth", 1, "levels of transitive calls to follow (0 for no limit)")
+		cmd.Flags().BoolVar(&callJSON, "json", false, "output as JSON")
+		cmd.Flags().BoolVar(&callDOT, "dot", false, "output as a Graphviz DOT digraph")
+		cmd.Flags().StringVar(&callLang, "lang", "", "only match definitions from files in this language")
+	}
+	calleesCmd.Flags().BoolVar(&callExternal, "external", false, "include calls that do not resolve to an indexed symbol")
+}
+
+func runCallGraph(name string, forward bool) error {
+	if callJSON && callDOT {
+		return fmt.Errorf("--json and --dot are mutually exclusive")
+	}
+
+	st, _, err := openSymbolIndex()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	callGraphUC := usecase.NewCallGraphUseCase(st, st)
+	opts := usecase.CallTreeOptions{Depth: callDepth, External: callExternal, Lang: callLang}
+
+	var trees []*usecase.CallNode
+	if forward {
+		trees, err = callGraphUC.Callees(name, opts)
+	} else {
+		trees, err = callGraphUC.Callers(name, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("call graph lookup failed: %w", err)
+	}
+
+	switch {
+	case callJSON:
+		if trees == nil {
+			trees = []*usecase.CallNode{}
+		}
+		output, _ := json.MarshalIndent(trees, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	case len(trees) == 0:
+		return fmt.Errorf("no function or method named %s", name)
+	case callDOT:
+		fmt.Print(formatCallDOT(trees, forward))
+		return nil
+	}
+
+	for i, tree := range trees {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(formatCallTree(tree, forward))
+	}
+	return nil
+}
+
+func formatCallTree(root *usecase.CallNode, forward bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n", formatCallNode(root))
+	if len(root.Children) == 0 {
+		if forward {
+			sb.WriteString("  (no calls to indexed functions)\n")
+		} else {
+			sb.WriteString("  (no callers)\n")
+		}
+	}
+	writeCallChildren(&sb, root, "")
+	return sb.String()
+}
+
+func writeCallChildren(sb *strings.Builder, node *usecase.CallNode, prefix string) {
+	for i, child := range node.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(node.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintf(sb, "%s%s%s", prefix, branch, formatCallNode(child))
+		switch {
+		case child.CallLine == 0:
+		case child.CallPath == child.Path || child.CallPath == node.Path:
+			fmt.Fprintf(sb, "  (call at L%d)", child.CallLine)
+		default:
+			fmt.Fprintf(sb, "  (call at %s:%d)", child.CallPath, child.CallLine)
+		}
+		if child.Repeated {
+			sb.WriteString(" ...")
+		}
+		sb.WriteString("\n")
+		writeCallChildren(sb, child, prefix+indent)
+	}
+}
+
+func formatCallNode(node *usecase.CallNode) string {
+	if node.External() {
+		return node.Name + " (external)"
+	}
+	return fmt.Sprintf("%s (%s) %s", node.Name, node.Kind, node.Citation())
+}
+
+// Edges always point caller -> callee, whichever direction was traversed.
+func formatCallDOT(trees []*usecase.CallNode, forward bool) string {
+	var sb strings.Builder
+	sb.WriteString("digraph calls {\n  rankdir=LR;\n  node [shape=box];\n")
+
+	nodes := make(map[string]bool)
+	edges := make(map[string]bool)
+	var walk func(node *usecase.CallNode)
+	walk = func(node *usecase.CallNode) {
+		if !nodes[node.ID] {
+			nodes[node.ID] = true
+			label := node.Name
+			if citation := node.Citation(); citation != "" {
+				label += "\n" + citation
+			}
+			fmt.Fprintf(&sb, "  %q [label=%q];\n", node.ID, label)
+		}
+		for _, child := range node.Children {
+			walk(child)
+			from, to := node.ID, child.ID
+			if !forward {
+				from, to = to, from
+			}
+			if edge := from + "\x00" + to; !edges[edge] {
+				edges[edge] = true
+				fmt.Fprintf(&sb, "  %q -> %q;\n", from, to)
+			}
+		}
+	}
+	for _, tree := range trees {
+		walk(tree)
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
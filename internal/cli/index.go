@@ -12,7 +12,6 @@ import (
 	"rag/config"
 	"rag/internal/adapter/analyzer"
 	"rag/internal/adapter/chunker"
-	"rag/internal/adapter/embedding"
 	"rag/internal/adapter/fs"
 	"rag/internal/adapter/store"
 	"rag/internal/port"
@@ -86,7 +85,10 @@ func runIndex(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	tokenizer := analyzer.NewTokenizer(cfg.Index.Stemming)
+	tokenizer, err := newTokenizer(cfg, st)
+	if err != nil {
+		return err
+	}
 
 	walker := fs.NewWalker(cfg.Index.Includes, cfg.Index.Excludes)
 
@@ -97,7 +99,42 @@ func runIndex(cmd *cobra.Command, args []string) error {
 		chk = chunker.NewLineChunker(cfg.Index.ChunkTokens, cfg.Index.ChunkOverlap, tokenizer)
 	}
 
-	indexUC := usecase.NewIndexUseCase(st, walker, chk, tokenizer)
+	var embedder port.Embedder
+	var vectorStore *store.BoltVectorStore
+	var vectors port.VectorStore
+	if cfg.Embedding.Enabled {
+		if embedder, err = newEmbedder(cfg); err != nil {
+			fmt.Printf("Warning: embeddings disabled for this run: %v\n", err)
+		} else {
+			if vectorStore, err = openVectorStore(st, cfg, embedder.Dimension()); err != nil {
+				return fmt.Errorf("failed to open vector store: %w", err)
+			}
+			if err := vectorStore.RepairGraph(); err != nil {
+				return fmt.Errorf("failed to repair vector index: %w", err)
+			}
+			vectors = vectorStore
+		}
+	}
+
+	var fields port.FieldExtractor
+	if cfg.Index.Fields {
+		fields = analyzer.NewFieldExtractor(tokenizer)
+	}
+
+	var symbols port.SymbolExtractor
+	var calls port.CallGraphAnalyzer
+	if cfg.Index.Symbols {
+		symbols = analyzer.NewSymbolExtractor()
+		switch cfg.Index.CallGraph {
+		case "", "syntax":
+		case "types":
+			calls = analyzer.NewGoCallGraph()
+		default:
+			return fmt.Errorf("index.call_graph: unknown mode %q (want syntax or types)", cfg.Index.CallGraph)
+		}
+	}
+
+	indexUC := usecase.NewIndexUseCase(st, walker, chk, tokenizer, vectors, cfg.Index.Positions, fields, symbols, calls)
 
 	fmt.Printf("Scanning %s...\n", path)
 
@@ -154,10 +191,10 @@ func runIndex(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to update schema info: %w", err)
 	}
 
-	var embeddingsGenerated int
+	var embStats embeddingStats
 	fmt.Printf("\nEmbedding config: enabled=%v, provider=%s, model=%s\n", cfg.Embedding.Enabled, cfg.Embedding.Provider, cfg.Embedding.Model)
-	if cfg.Embedding.Enabled {
-		embeddingsGenerated, err = generateEmbeddings(st, cfg)
+	if vectorStore != nil {
+		embStats, err = generateEmbeddings(st, vectorStore, embedder, cfg)
 		if err != nil {
 			fmt.Printf("\nWarning: embedding generation failed: %v\n", err)
 		}
@@ -166,10 +203,36 @@ func runIndex(cmd *cobra.Command, args []string) error {
 	fmt.Printf("\nIndexing complete:\n")
 	fmt.Printf("  Files indexed:  %d\n", result.FilesIndexed)
 	fmt.Printf("  Files skipped:  %d (unchanged)\n", result.FilesSkipped)
+	if result.FilesTouched > 0 {
+		fmt.Printf("  Files touched:  %d (mtime changed, content identical)\n", result.FilesTouched)
+	}
 	fmt.Printf("  Files deleted:  %d (removed)\n", result.FilesDeleted)
 	fmt.Printf("  Chunks created: %d\n", result.ChunksCreated)
-	if embeddingsGenerated > 0 {
-		fmt.Printf("  Embeddings:     %d\n", embeddingsGenerated)
+	if result.FilesIndexed > 0 {
+		fmt.Printf("  Chunk changes:  +%d -%d (%d kept)\n", result.ChunksAdded, result.ChunksRemoved, result.ChunksKept)
+	}
+	if result.SymbolsIndexed > 0 {
+		fmt.Printf("  Symbols:        %d (%d call edges)\n", result.SymbolsIndexed, result.CallEdges)
+	}
+	if result.VectorsPruned > 0 {
+		fmt.Printf("  Vectors pruned: %d (orphaned)\n", result.VectorsPruned)
+	}
+	if embStats.stored > 0 {
+		fmt.Printf("  Embeddings:     %d (cache hits: %d, misses: %d)\n", embStats.stored, embStats.cacheHits, embStats.cacheMisses)
+	}
+	if embStats.cachePruned > 0 {
+		fmt.Printf("  Cache pruned:   %d (least recently used)\n", embStats.cachePruned)
+	}
+
+	if len(result.FileChanges) > 0 {
+		fmt.Printf("\nModified files:\n")
+		for i, c := range result.FileChanges {
+			if i >= 10 {
+				fmt.Printf("  ... and %d more\n", len(result.FileChanges)-10)
+				break
+			}
+			fmt.Printf("  %s: +%d -%d (%d kept)\n", c.Path, c.Added, c.Removed, c.Kept)
+		}
 	}
 
 	if len(result.Errors) > 0 {
@@ -183,69 +246,98 @@ func runIndex(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func generateEmbeddings(st *store.BoltStore, cfg *config.Config) (int, error) {
-
-	var embedder port.Embedder
-	var err error
-
-	switch cfg.Embedding.Provider {
-	case "openai":
-		embedder, err = embedding.NewOpenAIEmbedder(cfg.Embedding.APIKeyEnv, cfg.Embedding.Model)
-	case "deepseek":
-		embedder, err = embedding.NewDeepSeekEmbedder(cfg.Embedding.APIKeyEnv, cfg.Embedding.Model)
-	case "jina":
-		embedder, err = embedding.NewJinaEmbedder(cfg.Embedding.APIKeyEnv, cfg.Embedding.Model)
-	case "ollama":
-		embedder, err = embedding.NewOllamaEmbedder(cfg.Embedding.Model, cfg.Embedding.BaseURL)
-	case "mock":
-		embedder = embedding.NewMockEmbedder(cfg.Embedding.Dimension)
-	default:
-		return 0, fmt.Errorf("unsupported embedding provider: %s", cfg.Embedding.Provider)
-	}
-	if err != nil {
-		return 0, fmt.Errorf("failed to create embedder: %w", err)
-	}
+type embeddingStats struct {
+	stored      int
+	cacheHits   int
+	cacheMisses int
+	cachePruned int
+}
 
-	vectorStore, err := store.NewBoltVectorStore(st.DB(), embedder.Dimension())
+func generateEmbeddings(st *store.BoltStore, vectorStore *store.BoltVectorStore, embedder port.Embedder, cfg *config.Config) (stats embeddingStats, err error) {
+	cache, err := store.NewEmbeddingCache(st.DB(), embedder.ModelName(), embedder.Dimension())
 	if err != nil {
-		return 0, fmt.Errorf("failed to create vector store: %w", err)
+		return stats, err
 	}
+	defer func() {
+		if err == nil {
+			if stats.cachePruned, err = cache.Prune(cfg.Embedding.CacheMaxEntries); err != nil {
+				err = fmt.Errorf("failed to prune embedding cache: %w", err)
+			}
+		}
+	}()
 
 	docs, err := st.ListDocs()
 	if err != nil {
-		return 0, err
+		return stats, err
 	}
 
-	var allChunks []struct {
+	type pendingChunk struct {
 		id   string
 		text string
+		hash string
 	}
 
+	var pending []pendingChunk
 	for _, doc := range docs {
 		chunks, err := st.GetChunksByDoc(doc.ID)
 		if err != nil {
 			continue
 		}
 		for _, chunk := range chunks {
-			allChunks = append(allChunks, struct {
-				id   string
-				text string
-			}{chunk.ID, chunk.Text})
+			if vectorStore.Has(chunk.ID) {
+				continue
+			}
+			pending = append(pending, pendingChunk{
+				id:   chunk.ID,
+				text: chunk.Text,
+				hash: usecase.HashContent([]byte(chunk.Text)),
+			})
 		}
 	}
 
-	if len(allChunks) == 0 {
-		return 0, nil
+	if len(pending) == 0 {
+		return stats, nil
 	}
 
-	fmt.Printf("\nGenerating embeddings for %d chunks...\n", len(allChunks))
+	hashes := make([]string, len(pending))
+	for i, c := range pending {
+		hashes[i] = c.hash
+	}
+	cached, err := cache.Get(hashes)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+
+	var hitItems []port.VectorItem
+	var misses []pendingChunk
+	for _, c := range pending {
+		if vec, ok := cached[c.hash]; ok {
+			hitItems = append(hitItems, port.VectorItem{ID: c.id, Vector: vec})
+		} else {
+			misses = append(misses, c)
+		}
+	}
+
+	if len(hitItems) > 0 {
+		if err := vectorStore.Upsert(hitItems); err != nil {
+			return stats, fmt.Errorf("failed to store cached vectors: %w", err)
+		}
+		stats.cacheHits = len(hitItems)
+		stats.stored += len(hitItems)
+	}
+
+	if len(misses) == 0 {
+		return stats, nil
+	}
+
+	fmt.Printf("\nGenerating embeddings for %d chunks (%d reused from cache)...\n", len(misses), len(hitItems))
 
 	batchSize := cfg.Embedding.BatchSize
 	if batchSize <= 0 {
 		batchSize = 100
 	}
 
-	bar := progressbar.NewOptions(len(allChunks),
+	bar := progressbar.NewOptions(len(misses),
 		progressbar.OptionEnableColorCodes(true),
 		progressbar.OptionShowBytes(false),
 		progressbar.OptionSetWidth(40),
@@ -257,12 +349,12 @@ func generateEmbeddings(st *store.BoltStore, cfg *config.Config) (int, error) {
 	)
 
 	generated := 0
-	for i := 0; i < len(allChunks); i += batchSize {
+	for i := 0; i < len(misses); i += batchSize {
 		end := i + batchSize
-		if end > len(allChunks) {
-			end = len(allChunks)
+		if end > len(misses) {
+			end = len(misses)
 		}
-		batch := allChunks[i:end]
+		batch := misses[i:end]
 
 		texts := make([]string, len(batch))
 		for j, c := range batch {
@@ -271,26 +363,33 @@ func generateEmbeddings(st *store.BoltStore, cfg *config.Config) (int, error) {
 
 		embeddings, err := embedder.Embed(texts)
 		if err != nil {
-			return generated, fmt.Errorf("embedding batch failed: %w", err)
+			return stats, fmt.Errorf("embedding batch failed: %w", err)
 		}
 
 		items := make([]port.VectorItem, len(batch))
+		cacheEntries := make(map[string][]float32, len(batch))
 		for j, c := range batch {
 			items[j] = port.VectorItem{
 				ID:     c.id,
 				Vector: embeddings[j],
 			}
+			cacheEntries[c.hash] = embeddings[j]
 		}
 
 		if err := vectorStore.Upsert(items); err != nil {
-			return generated, fmt.Errorf("failed to store vectors: %w", err)
+			return stats, fmt.Errorf("failed to store vectors: %w", err)
+		}
+		if err := cache.Put(cacheEntries); err != nil {
+			return stats, fmt.Errorf("failed to update embedding cache: %w", err)
 		}
 
 		generated += len(batch)
+		stats.cacheMisses += len(batch)
+		stats.stored += len(batch)
 		bar.Set(generated)
 	}
 
-	return generated, nil
+	return stats, nil
 }
 
 func formatDuration(d time.Duration) string {
@@ -10,6 +10,7 @@ import (
 	"rag/internal/adapter/analyzer"
 	"rag/internal/adapter/retriever"
 	"rag/internal/adapter/store"
+	"rag/internal/port"
 	"rag/internal/usecase"
 )
 
@@ -18,6 +19,8 @@ var (
 	packBudget int
 	packOutput string
 	packTopK   int
+	packPRF    bool
+	packGraph  bool
 )
 
 var packCmd = &cobra.Command{
@@ -26,9 +29,15 @@ var packCmd = &cobra.Command{
 	Long: `Search and pack relevant code chunks into a compressed context
 that fits within a token budget, including citations.
 
+The query accepts the same syntax as 'rag query': "phrases", +required,
+-excluded, OR, and path:, lang:, ext: and modified: filters.
+
 Examples:
   rag pack -q "how does authentication work"
-  rag pack -q "database layer" -b 2000 -o context.json`,
+  rag pack -q "database layer" -b 2000 -o context.json
+  rag pack -q "session handling lang:go -path:**/*_test.go"
+  rag pack -q "retry backoff" --prf
+  rag pack -q "index pipeline" --graph`,
 	RunE: runPack,
 }
 
@@ -38,6 +47,8 @@ func init() {
 	packCmd.Flags().IntVarP(&packBudget, "budget", "b", 0, "token budget (default from config)")
 	packCmd.Flags().StringVarP(&packOutput, "output", "o", "", "output file (default: stdout)")
 	packCmd.Flags().IntVarP(&packTopK, "top-k", "k", 0, "candidate pool size (default from config)")
+	packCmd.Flags().BoolVar(&packPRF, "prf", false, "expand the query with pseudo-relevance feedback (RM3)")
+	packCmd.Flags().BoolVar(&packGraph, "graph", false, "add callees, implemented interfaces, callers and imported definitions of the top chunks")
 	packCmd.MarkFlagRequired("query")
 }
 
@@ -56,13 +67,30 @@ func runPack(cmd *cobra.Command, args []string) error {
 	}
 	defer st.Close()
 
-	tokenizer := analyzer.NewTokenizer(cfg.Index.Stemming)
+	tokenizer, err := newTokenizer(cfg, st)
+	if err != nil {
+		return err
+	}
+
+	synonyms, err := newSynonyms(cfg, rootDir, tokenizer)
+	if err != nil {
+		return err
+	}
 
-	bm25 := retriever.NewBM25Retriever(st, tokenizer, cfg.Index.K1, cfg.Index.B, cfg.Retrieve.PathBoostWeight)
+	bm25 := newBM25Retriever(cfg, st, tokenizer, synonyms)
 	mmr := retriever.NewMMRReranker(cfg.Retrieve.MMRLambda, cfg.Retrieve.DedupJaccard)
 
-	retrieveUC := usecase.NewRetrieveUseCase(bm25, mmr, cfg.Retrieve.MinScoreThreshold)
-	packUC := usecase.NewPackUseCase(st, tokenizer, cfg.Pack.RecencyBoost)
+	var searchRetriever port.Retriever = bm25
+	if packPRF || cfg.Retrieve.PRF.Enabled {
+		searchRetriever = newPRFRetriever(searchRetriever, bm25, tokenizer, cfg)
+	}
+
+	retrieveUC := usecase.NewRetrieveUseCase(searchRetriever, mmr, cfg.Retrieve.MinScoreThreshold)
+	counter, err := analyzer.NewEncodingTokenizer(cfg.Pack.Tokenizer)
+	if err != nil {
+		return fmt.Errorf("failed to load tokenizer: %w", err)
+	}
+	packUC := usecase.NewPackUseCase(st, counter, cfg.Pack.RecencyBoost, newGraphExpander(st, cfg), cfg.Pack.Graph.Share)
 
 	topK := cfg.Retrieve.TopK
 	if packTopK > 0 {
@@ -100,10 +128,27 @@ func runPack(cmd *cobra.Command, args []string) error {
 		}
 		fmt.Printf("Context packed to: %s\n", packOutput)
 		fmt.Printf("  Snippets: %d\n", len(packed.Snippets))
-		fmt.Printf("  Tokens:   %d / %d\n", packed.UsedTokens, packed.BudgetTokens)
+		approx := ""
+		if bpe, ok := counter.(*analyzer.BPETokenizer); !ok || bpe.Approximate() {
+			approx = " (approximate)"
+		}
+		fmt.Printf("  Tokens:   %d / %d%s\n", packed.UsedTokens, packed.BudgetTokens, approx)
 	} else {
 		fmt.Println(string(output))
 	}
 
 	return nil
 }
+
+func newGraphExpander(st *store.BoltStore, cfg *config.Config) *usecase.ContextExpander {
+	graph := cfg.Pack.Graph
+	if !packGraph && !graph.Enabled {
+		return nil
+	}
+	return usecase.NewContextExpander(st, usecase.ContextExpanderOptions{
+		Imports:    graph.Imports,
+		Interfaces: graph.Interfaces,
+		Callees:    graph.Callees,
+		Callers:    graph.Callers,
+	})
+}
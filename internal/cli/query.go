@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -27,6 +28,8 @@ var (
 	queryContext     int
 	querySemantic    bool
 	queryNoAutoIndex bool
+	queryExplain     bool
+	queryPRF         bool
 )
 
 var queryCmd = &cobra.Command{
@@ -34,6 +37,15 @@ var queryCmd = &cobra.Command{
 	Short: "Search indexed files",
 	Long: `Search for relevant code chunks using BM25 retrieval with MMR deduplication.
 
+Query syntax:
+  "exact phrase"   words must appear in order
+  +term / -term    require / exclude a term
+  a OR b           either alternative
+  term^0.5         scale a term's score contribution
+  path:GLOB        doublestar glob on the file path
+  lang:go ext:md   language or extension filter
+  modified:>DATE   modification time (>, >=, <, <=, =)
+
 Search modes:
   - Default: BM25 keyword search (or hybrid if configured)
   - --semantic: Uses only embedding/vector search (requires embeddings enabled)
@@ -41,7 +53,10 @@ Search modes:
 Examples:
   rag query -q "authentication handler"
   rag query -q "database connection" --top-k 10 --json
-  rag query -q "how to handle errors" --semantic`,
+  rag query -q "how to handle errors" --semantic
+  rag query -q "token refresh" --explain
+  rag query -q "retry backoff" --prf
+  rag query -q '"connection pool" +retry -test lang:go path:internal/**'`,
 	RunE: runQuery,
 }
 
@@ -54,6 +69,8 @@ func init() {
 	queryCmd.Flags().IntVarP(&queryContext, "context", "c", 0, "expand results by N lines before/after")
 	queryCmd.Flags().BoolVar(&querySemantic, "semantic", false, "use only embedding/vector search (no BM25)")
 	queryCmd.Flags().BoolVar(&queryNoAutoIndex, "no-auto-index", false, "error instead of auto-indexing when index is missing")
+	queryCmd.Flags().BoolVar(&queryExplain, "explain", false, "show how each result's score was computed")
+	queryCmd.Flags().BoolVar(&queryPRF, "prf", false, "expand the query with pseudo-relevance feedback (RM3)")
 	queryCmd.MarkFlagRequired("query")
 }
 
@@ -104,12 +121,21 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	tokenizer := analyzer.NewTokenizer(cfg.Index.Stemming)
+	tokenizer, err := newTokenizer(cfg, st)
+	if err != nil {
+		return err
+	}
+
+	synonyms, err := newSynonyms(cfg, rootDir, tokenizer)
+	if err != nil {
+		return err
+	}
 
-	bm25 := retriever.NewBM25Retriever(st, tokenizer, cfg.Index.K1, cfg.Index.B, cfg.Retrieve.PathBoostWeight)
+	bm25 := newBM25Retriever(cfg, st, tokenizer, synonyms)
 	mmr := retriever.NewMMRReranker(cfg.Retrieve.MMRLambda, cfg.Retrieve.DedupJaccard)
 
 	var searchRetriever port.Retriever = bm25
+	minScore := cfg.Retrieve.MinScoreThreshold
 
 	if querySemantic {
 		if !cfg.Embedding.Enabled {
@@ -119,20 +145,32 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("semantic search unavailable: %v", err)
 		}
-		searchRetriever = retriever.NewSemanticRetriever(vectorStore, embedder, st)
+		searchRetriever = retriever.NewSemanticRetriever(vectorStore, embedder, st, tokenizer)
 	} else if cfg.Retrieve.HybridEnabled && cfg.Embedding.Enabled {
 		embedder, vectorStore, err := setupHybridRetrieval(st, cfg)
 		if err != nil {
 			fmt.Printf("Warning: hybrid retrieval unavailable: %v\n", err)
 		} else {
-			searchRetriever = retriever.NewHybridRetriever(
+			hybrid, err := retriever.NewHybridRetriever(
 				bm25, vectorStore, embedder, st,
-				cfg.Retrieve.RRFK, cfg.Retrieve.BM25Weight,
+				cfg.Retrieve.Fusion, cfg.Retrieve.RRFK, cfg.Retrieve.BM25Weight,
 			)
+			if err != nil {
+				return err
+			}
+			searchRetriever = hybrid
+			if cfg.Retrieve.Fusion == retriever.FusionRRF {
+				// RRF scores are rank-based and stay below 2/(rrf_k+1).
+				minScore = 0
+			}
 		}
 	}
 
-	retrieveUC := usecase.NewRetrieveUseCase(searchRetriever, mmr, cfg.Retrieve.MinScoreThreshold)
+	if queryPRF || cfg.Retrieve.PRF.Enabled {
+		searchRetriever = newPRFRetriever(searchRetriever, bm25, tokenizer, cfg)
+	}
+
+	retrieveUC := usecase.NewRetrieveUseCase(searchRetriever, mmr, minScore)
 
 	topK := cfg.Retrieve.TopK
 	if queryTopK > 0 {
@@ -165,19 +203,26 @@ func runQuery(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		results = append(results, usecase.ScoredChunkResult{
+		result := usecase.ScoredChunkResult{
 			Path:      doc.Path,
 			StartLine: startLine,
 			EndLine:   endLine,
 			Score:     c.Score,
 			Text:      text,
-		})
+		}
+		if queryExplain {
+			result.Explanation = c.Explanation
+		}
+		results = append(results, result)
 	}
 
 	if queryJSON {
 		output, _ := json.MarshalIndent(results, "", "  ")
 		fmt.Println(string(output))
 	} else {
+		if suggestion, ok := bm25.DidYouMean(queryText); ok {
+			fmt.Printf("Did you mean: %s\n\n", suggestion)
+		}
 		if len(results) == 0 {
 			fmt.Println("No results found.")
 			return nil
@@ -185,6 +230,9 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Found %d results for: %s\n\n", len(results), queryText)
 		for i, r := range results {
 			fmt.Printf("--- [%d] %s:L%d-%d (score: %.2f) ---\n", i+1, r.Path, r.StartLine, r.EndLine, r.Score)
+			if r.Explanation != nil {
+				fmt.Print(formatExplanation(r.Explanation))
+			}
 
 			text := r.Text
 			if queryContext == 0 && len(text) > 500 {
@@ -198,6 +246,52 @@ func runQuery(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func formatExplanation(exp *domain.Explanation) string {
+	var sb strings.Builder
+
+	if exp.BM25 > 0 {
+		fmt.Fprintf(&sb, "  bm25: %.4f", exp.BM25)
+		if exp.Proximity > 1 {
+			fmt.Fprintf(&sb, " x proximity %.3f (span %d)", exp.Proximity, exp.Span)
+		}
+		if exp.PathBoost > 0 && exp.PathBoost != 1 {
+			fmt.Fprintf(&sb, " x path boost %.3f", exp.PathBoost)
+		}
+		if exp.BM25Rank > 0 {
+			fmt.Fprintf(&sb, " (rank %d)", exp.BM25Rank)
+		}
+		sb.WriteString("\n")
+		for _, t := range exp.Terms {
+			fmt.Fprintf(&sb, "    %-20s tf=%d idf=%.3f -> %.4f", t.Term, t.TF, t.IDF, t.Score)
+			for _, field := range domain.ChunkFields {
+				if tf := t.Fields[field]; tf > 0 {
+					fmt.Fprintf(&sb, " %s=%d", field, tf)
+				}
+			}
+			if t.Weight > 0 {
+				fmt.Fprintf(&sb, " (weight %.2f)", t.Weight)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if exp.Vector != 0 || exp.VectorRank > 0 {
+		fmt.Fprintf(&sb, "  vector: %.4f", exp.Vector)
+		if exp.VectorRank > 0 {
+			fmt.Fprintf(&sb, " (rank %d)", exp.VectorRank)
+		}
+		sb.WriteString("\n")
+	}
+	if exp.Fusion != "" {
+		fmt.Fprintf(&sb, "  fusion: %s\n", exp.Fusion)
+	}
+	if exp.MMR != nil {
+		fmt.Fprintf(&sb, "  mmr: rank %d -> %d, %.2f*%.3f [relevance] - %.3f [penalty, max similarity %.3f] = %.3f\n",
+			exp.MMR.RankBefore, exp.MMR.RankAfter, exp.MMR.Lambda, exp.MMR.Relevance, exp.MMR.Penalty, exp.MMR.MaxSimilarity, exp.MMR.Score)
+	}
+
+	return sb.String()
+}
+
 func expandContext(path string, startLine, endLine, extraLines int) (newStart, newEnd int, text string, err error) {
 	if extraLines <= 0 {
 		return startLine, endLine, "", nil
@@ -251,7 +345,82 @@ func joinLines(lines []string) string {
 	return result
 }
 
-func setupHybridRetrieval(st *store.BoltStore, cfg *config.Config) (port.Embedder, port.VectorStore, error) {
+// Queries are analyzed in every language the index was built with.
+func newTokenizer(cfg *config.Config, st *store.BoltStore) (*analyzer.Tokenizer, error) {
+	language, err := analyzer.NormalizeLanguage(cfg.Index.Language)
+	if err != nil {
+		return nil, fmt.Errorf("index.language: %w", err)
+	}
+	indexed, err := st.TextLanguages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexed languages: %w", err)
+	}
+	return analyzer.NewTokenizerWithOptions(analyzer.TokenizerOptions{
+		Stemming:         cfg.Index.Stemming,
+		Language:         language,
+		QueryLanguages:   indexed,
+		SplitIdentifiers: cfg.Index.SplitIdentifiers,
+		SplitLanguages:   cfg.Index.SplitLanguages,
+	}), nil
+}
+
+func newSynonyms(cfg *config.Config, rootDir string, tokenizer port.Tokenizer) (*retriever.SynonymMap, error) {
+	if !cfg.Synonyms.Enabled {
+		return nil, nil
+	}
+
+	var groups [][]string
+	if cfg.Synonyms.Defaults {
+		groups = append(groups, retriever.DefaultSynonymGroups...)
+	}
+	groups = append(groups, cfg.Synonyms.Groups...)
+
+	if cfg.Synonyms.File != "" {
+		path := cfg.Synonyms.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(rootDir, path)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("synonyms file: %w", err)
+		}
+		defer f.Close()
+		fileGroups, err := retriever.ParseSynonyms(f)
+		if err != nil {
+			return nil, fmt.Errorf("synonyms file %s: %w", cfg.Synonyms.File, err)
+		}
+		groups = append(groups, fileGroups...)
+	}
+
+	return retriever.NewSynonymMap(groups, tokenizer, cfg.Synonyms.Weight), nil
+}
+
+func newBM25Retriever(cfg *config.Config, st *store.BoltStore, tokenizer port.Tokenizer, synonyms *retriever.SynonymMap) *retriever.BM25Retriever {
+	return retriever.NewBM25Retriever(st, tokenizer, retriever.BM25Options{
+		K1:              cfg.Index.K1,
+		B:               cfg.Index.B,
+		PathBoostWeight: cfg.Retrieve.PathBoostWeight,
+		ProximityWeight: cfg.Retrieve.ProximityWeight,
+		FieldWeights:    cfg.BM25FieldWeights(),
+		Synonyms:        synonyms,
+		Fuzzy:           newFuzzyMatcher(cfg, st),
+	})
+}
+
+func newFuzzyMatcher(cfg *config.Config, st *store.BoltStore) *retriever.FuzzyMatcher {
+	if !cfg.Retrieve.Fuzzy.Enabled {
+		return nil
+	}
+	return retriever.NewFuzzyMatcher(st.AllTerms, cfg.Retrieve.Fuzzy.MaxDistance, cfg.Retrieve.Fuzzy.Penalty)
+}
+
+// Feedback documents always come from BM25; the expanded query runs through base.
+func newPRFRetriever(base, bm25 port.Retriever, tokenizer port.Tokenizer, cfg *config.Config) *retriever.PRFRetriever {
+	prf := cfg.Retrieve.PRF
+	return retriever.NewPRFRetriever(base, bm25, tokenizer, prf.FeedbackDocs, prf.Terms, prf.OriginalWeight)
+}
+
+func newEmbedder(cfg *config.Config) (port.Embedder, error) {
 	var embedder port.Embedder
 	var err error
 
@@ -267,13 +436,21 @@ func setupHybridRetrieval(st *store.BoltStore, cfg *config.Config) (port.Embedde
 	case "mock":
 		embedder = embedding.NewMockEmbedder(cfg.Embedding.Dimension)
 	default:
-		return nil, nil, fmt.Errorf("unsupported embedding provider: %s", cfg.Embedding.Provider)
+		return nil, fmt.Errorf("unsupported embedding provider: %s", cfg.Embedding.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return embedder, nil
+}
+
+func setupHybridRetrieval(st *store.BoltStore, cfg *config.Config) (port.Embedder, port.VectorStore, error) {
+	embedder, err := newEmbedder(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	vectorStore, err := store.NewBoltVectorStore(st.DB(), embedder.Dimension())
+	vectorStore, err := openVectorStore(st, cfg, embedder.Dimension())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -319,9 +496,37 @@ func checkForChanges(st *store.BoltStore) []string {
 			}
 			continue
 		}
-		if info.ModTime().Unix() > doc.ModTime.Unix() {
-			changed = append(changed, doc.Path)
+		if info.ModTime().Unix() == doc.ModTime.Unix() {
+			continue
+		}
+		if doc.ContentHash != "" {
+			if data, err := os.ReadFile(doc.Path); err == nil && usecase.HashContent(data) == doc.ContentHash {
+				continue
+			}
 		}
+		changed = append(changed, doc.Path)
 	}
 	return changed
 }
+
+func openVectorStore(st *store.BoltStore, cfg *config.Config, dimension int) (*store.BoltVectorStore, error) {
+	opts := store.VectorStoreOptions{
+		Quantization:  cfg.Embedding.Quantization,
+		RescoreFactor: cfg.Embedding.RescoreFactor,
+	}
+
+	switch cfg.Embedding.Index {
+	case "", "hnsw":
+		opts.HNSW = &store.HNSWParams{
+			M:              cfg.Embedding.HNSW.M,
+			EfConstruction: cfg.Embedding.HNSW.EfConstruction,
+			EfSearch:       cfg.Embedding.HNSW.EfSearch,
+			Seed:           cfg.Embedding.HNSW.Seed,
+		}
+	case "flat":
+	default:
+		return nil, fmt.Errorf("unsupported vector index: %s", cfg.Embedding.Index)
+	}
+
+	return store.NewBoltVectorStoreWithOptions(st.DB(), dimension, opts)
+}
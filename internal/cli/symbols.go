@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"rag/config"
+	"rag/internal/adapter/store"
+	"rag/internal/usecase"
+)
+
+var (
+	symbolsKind  string
+	symbolsLang  string
+	symbolsLimit int
+	symbolsJSON  bool
+
+	defKind  string
+	defLang  string
+	defLimit int
+	defJSON  bool
+)
+
+var symbolsCmd = &cobra.Command{
+	Use:   "symbols <pattern>",
+	Short: "List indexed symbols matching a pattern",
+	Long: `List functions, methods, types and classes whose name contains the
+pattern (case-insensitive). Patterns containing *, ? or [ are matched as a
+glob against the whole name. Variables are only listed with --kind variable.
+
+Examples:
+  rag symbols Retriever
+  rag symbols "New*" --kind function --lang go
+  rag symbols handler --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSymbols,
+}
+
+var defCmd = &cobra.Command{
+	Use:   "def <name>",
+	Short: "Print the chunk that defines a symbol",
+	Long: `Print the indexed chunk that defines a symbol. Methods can be named by
+their bare name (Start), with their receiver (Server.Start) or with a pointer
+receiver (*Server.Start).
+
+Examples:
+  rag def NewBM25Retriever
+  rag def Server.Start --kind method
+  rag def Config --lang python --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDef,
+}
+
+func init() {
+	rootCmd.AddCommand(symbolsCmd)
+	symbolsCmd.Flags().StringVar(&symbolsKind, "kind", "", "only list symbols of this kind (function, method, struct, interface, type, class, constant, variable)")
+	symbolsCmd.Flags().StringVar(&symbolsLang, "lang", "", "only list symbols from files in this language")
+	symbolsCmd.Flags().IntVarP(&symbolsLimit, "limit", "n", 50, "maximum number of symbols (0 for no limit)")
+	symbolsCmd.Flags().BoolVar(&symbolsJSON, "json", false, "output as JSON")
+
+	rootCmd.AddCommand(defCmd)
+	defCmd.Flags().StringVar(&defKind, "kind", "", "only show definitions of this kind")
+	defCmd.Flags().StringVar(&defLang, "lang", "", "only show definitions from files in this language")
+	defCmd.Flags().IntVarP(&defLimit, "limit", "n", 0, "maximum number of definitions (0 for no limit)")
+	defCmd.Flags().BoolVar(&defJSON, "json", false, "output as JSON")
+}
+
+func openSymbolIndex() (*store.BoltStore, *usecase.SymbolUseCase, error) {
+	if !GetConfig().Index.Symbols {
+		return nil, nil, fmt.Errorf("symbols are not indexed. Enable in rag.yaml and re-index:\n  index:\n    symbols: true")
+	}
+
+	dbPath := config.IndexDBPath(GetRootDir())
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("no index found. Run 'rag index' first")
+	}
+
+	st, err := store.NewBoltStore(dbPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open index: %w", err)
+	}
+	return st, usecase.NewSymbolUseCase(st, st), nil
+}
+
+func runSymbols(cmd *cobra.Command, args []string) error {
+	st, symbolUC, err := openSymbolIndex()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	results, err := symbolUC.Search(args[0], usecase.SymbolFilter{Kind: symbolsKind, Lang: symbolsLang, Limit: symbolsLimit})
+	if err != nil {
+		return fmt.Errorf("symbol search failed: %w", err)
+	}
+
+	if symbolsJSON {
+		output, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No symbols found.")
+		return nil
+	}
+	for _, r := range results {
+		fmt.Printf("%-9s %s  %s:%d\n", r.Kind, r.Name, r.Path, r.Line)
+		if r.Signature != "" && r.Signature != r.Name {
+			fmt.Printf("          %s\n", r.Signature)
+		}
+	}
+	return nil
+}
+
+func runDef(cmd *cobra.Command, args []string) error {
+	st, symbolUC, err := openSymbolIndex()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	results, err := symbolUC.Define(args[0], usecase.SymbolFilter{Kind: defKind, Lang: defLang, Limit: defLimit})
+	if err != nil {
+		return fmt.Errorf("definition lookup failed: %w", err)
+	}
+
+	if defJSON {
+		output, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("no definition found for %s", args[0])
+	}
+	for _, r := range results {
+		if r.Text == "" {
+			fmt.Printf("--- %s (%s) %s:%d ---\n", r.Name, r.Kind, r.Path, r.Line)
+			if r.Signature != "" {
+				fmt.Println(r.Signature)
+			}
+			fmt.Println()
+			continue
+		}
+		fmt.Printf("--- %s (%s) %s:L%d-%d ---\n", r.Name, r.Kind, r.Path, r.StartLine, r.EndLine)
+		fmt.Println(r.Text)
+		fmt.Println()
+	}
+	return nil
+}
@@ -3,10 +3,12 @@ package domain
 import "time"
 
 type Document struct {
-	ID      string
-	Path    string
-	ModTime time.Time
-	Lang    string
+	ID          string
+	Path        string
+	ModTime     time.Time
+	Lang        string
+	TextLang    string
+	ContentHash string
 }
 
 type Chunk struct {
@@ -16,15 +18,72 @@ type Chunk struct {
 	EndLine   int
 	Tokens    []string
 	Text      string
+	Fields    map[string][]string
 }
 
+const (
+	FieldPath      = "path"
+	FieldSymbol    = "symbol"
+	FieldSignature = "signature"
+	FieldDoc       = "doc"
+	FieldBody      = "body"
+)
+
+var ChunkFields = []string{FieldPath, FieldSymbol, FieldSignature, FieldDoc}
+
 type Query struct {
 	Text string
 }
 
 type ScoredChunk struct {
-	Chunk Chunk
-	Score float64
+	Chunk       Chunk
+	Score       float64
+	Explanation *Explanation
+}
+
+type Explanation struct {
+	Terms          []TermContribution `json:"terms,omitempty"`
+	BM25           float64            `json:"bm25,omitempty"`
+	PathBoost      float64            `json:"path_boost,omitempty"`
+	Proximity      float64            `json:"proximity,omitempty"`
+	Span           int                `json:"span,omitempty"`
+	BM25Rank       int                `json:"bm25_rank,omitempty"`
+	BM25Normalized float64            `json:"bm25_normalized,omitempty"`
+	Vector         float64            `json:"vector,omitempty"`
+	VectorRank     int                `json:"vector_rank,omitempty"`
+	Fusion         string             `json:"fusion,omitempty"`
+	MMR            *MMRExplanation    `json:"mmr,omitempty"`
+}
+
+type TermContribution struct {
+	Term   string         `json:"term"`
+	TF     int            `json:"tf"`
+	IDF    float64        `json:"idf"`
+	Score  float64        `json:"score"`
+	Weight float64        `json:"weight,omitempty"`
+	Fields map[string]int `json:"fields,omitempty"`
+}
+
+type MMRExplanation struct {
+	Lambda        float64 `json:"lambda"`
+	Relevance     float64 `json:"relevance"`
+	MaxSimilarity float64 `json:"max_similarity"`
+	Penalty       float64 `json:"penalty"`
+	Score         float64 `json:"score"`
+	RankBefore    int     `json:"rank_before"`
+	RankAfter     int     `json:"rank_after"`
+}
+
+func (e *Explanation) Clone() *Explanation {
+	if e == nil {
+		return &Explanation{}
+	}
+	clone := *e
+	if e.MMR != nil {
+		mmr := *e.MMR
+		clone.MMR = &mmr
+	}
+	return &clone
 }
 
 type PackedContext struct {
@@ -44,14 +103,22 @@ type Snippet struct {
 }
 
 type Posting struct {
-	ChunkID string
-	TF      int
+	ChunkID   string
+	TF        int
+	Positions []int
+}
+
+type ChunkNorm struct {
+	DocID        string
+	Length       int
+	FieldLengths map[string]int
 }
 
 type Stats struct {
 	TotalDocs   int
 	TotalChunks int
 	AvgChunkLen float64
+	AvgFieldLen map[string]float64
 }
 
 type Symbol struct {
@@ -68,6 +135,7 @@ type CallGraphEntry struct {
 	CallerID string `json:"caller_id"`
 	CalleeID string `json:"callee_id"`
 	Line     int    `json:"line"`
+	External bool   `json:"external,omitempty"`
 }
 
 type ChunkMetadata struct {
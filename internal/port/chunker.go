@@ -5,3 +5,17 @@ import "rag/internal/domain"
 type Chunker interface {
 	Chunk(doc domain.Document, content string) ([]domain.Chunk, error)
 }
+
+type FieldExtractor interface {
+	ExtractFields(doc domain.Document, content string, chunks []domain.Chunk)
+}
+
+type SymbolExtractor interface {
+	ExtractSymbols(docID, content, lang string) ([]domain.Symbol, error)
+	ExtractCalls(docID, content, lang string, symbols []domain.Symbol) ([]domain.CallGraphEntry, error)
+}
+
+// CallGraphAnalyzer re-resolves the Go calls affected by changed or deleted paths.
+type CallGraphAnalyzer interface {
+	AnalyzeCalls(docIDs map[string]string, changed []string) (map[string][]domain.CallGraphEntry, error)
+}
@@ -23,8 +23,12 @@ type IndexStore interface {
 
 	GetPostings(term string) ([]domain.Posting, error)
 
+	GetFieldPostings(field, term string) ([]domain.Posting, error)
+
 	DeletePostings(chunkID string, terms []string) error
 
+	GetChunkNorms(chunkIDs []string) (map[string]domain.ChunkNorm, error)
+
 	GetStats() (domain.Stats, error)
 
 	UpdateStats(stats domain.Stats) error
@@ -35,7 +39,28 @@ type IndexStore interface {
 }
 
 type IndexedFile struct {
-	Doc      domain.Document
-	Chunks   []domain.Chunk
-	Postings map[string]map[string]int
+	Doc       domain.Document
+	Chunks    []domain.Chunk
+	Postings  map[string]map[string]int
+	Positions map[string]map[string][]int
+	Removed   []domain.Chunk
+	Kept      map[string]struct{}
+	Symbols   []domain.Symbol
+	Calls     []domain.CallGraphEntry
+	Metadata  map[string]domain.ChunkMetadata
+}
+
+// SymbolStore is implemented by index stores that persist symbols and call graphs.
+type SymbolStore interface {
+	GetAllSymbols() ([]domain.Symbol, error)
+
+	SearchSymbols(query string) ([]domain.Symbol, error)
+
+	DeleteSymbolsByDoc(docID string) error
+
+	GetAllCallGraph() ([]domain.CallGraphEntry, error)
+
+	PutCallGraphs(entries map[string][]domain.CallGraphEntry) error
+
+	DeleteCallGraph(docID string) error
 }
@@ -5,3 +5,7 @@ type Tokenizer interface {
 
 	CountTokens(text string) int
 }
+
+type LanguageDetector interface {
+	DocumentLanguage(lang, content string) string
+}
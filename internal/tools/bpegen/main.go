@@ -0,0 +1,256 @@
+// Command bpegen trains the approx-* vocabularies embedded in the analyzer package.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"container/heap"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"rag/internal/adapter/analyzer"
+)
+
+var extensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".ts": true, ".java": true, ".c": true, ".h": true,
+	".cpp": true, ".rs": true, ".rb": true, ".php": true, ".md": true, ".txt": true, ".json": true,
+	".yaml": true, ".yml": true, ".html": true, ".css": true, ".sql": true, ".sh": true, ".s": true,
+	".rst": true, "": true,
+}
+
+type pair [2]int
+
+type word struct {
+	syms  []int
+	count int
+}
+
+type entry struct {
+	p     pair
+	count int
+}
+
+type pairHeap []entry
+
+func (h pairHeap) Len() int { return len(h) }
+func (h pairHeap) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count > h[j].count
+	}
+	if h[i].p[0] != h[j].p[0] {
+		return h[i].p[0] < h[j].p[0]
+	}
+	return h[i].p[1] < h[j].p[1]
+}
+func (h pairHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *pairHeap) Push(x any)   { *h = append(*h, x.(entry)) }
+func (h *pairHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+func main() {
+	corpus := flag.String("corpus", "", "comma-separated corpus directories (required)")
+	out := flag.String("out", filepath.Join("internal", "adapter", "analyzer", "vocab"), "output directory")
+	limit := flag.Int64("limit", 64<<20, "maximum corpus bytes")
+	flag.Parse()
+
+	if *corpus == "" {
+		fmt.Fprintln(os.Stderr, "Error: -corpus is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	files := collect(strings.Split(*corpus, ","), *limit)
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no corpus files found")
+		os.Exit(1)
+	}
+
+	for _, spec := range []struct {
+		encoding string
+		size     int
+	}{
+		{analyzer.EncodingCL100K, 50000},
+		{analyzer.EncodingO200K, 80000},
+	} {
+		ranks := train(spec.encoding, files, spec.size)
+		path := filepath.Join(*out, "approx-"+spec.encoding+".tiktoken.gz")
+		if err := write(path, ranks); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: %d tokens\n", path, len(ranks))
+	}
+}
+
+func collect(roots []string, limit int64) []string {
+	var files []string
+	for _, root := range roots {
+		var total int64
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !extensions[filepath.Ext(path)] || total >= limit/int64(len(roots)) {
+				return nil
+			}
+			if info, err := d.Info(); err == nil && info.Size() < 1<<20 {
+				files = append(files, path)
+				total += info.Size()
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+func train(encoding string, files []string, size int) [][]byte {
+	counts := make(map[string]int)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil || !utf8.Valid(data) {
+			continue
+		}
+		for _, piece := range analyzer.PreTokenize(encoding, string(data)) {
+			counts[piece]++
+		}
+	}
+
+	pieces := make([]string, 0, len(counts))
+	for piece := range counts {
+		pieces = append(pieces, piece)
+	}
+	sort.Strings(pieces)
+
+	tokens := make([][]byte, 256)
+	byBytes := make(map[string]int, size)
+	for i := range tokens {
+		tokens[i] = []byte{byte(i)}
+		byBytes[string(tokens[i])] = i
+	}
+
+	words := make([]word, len(pieces))
+	pairCounts := make(map[pair]int)
+	pairWords := make(map[pair][]int)
+	for i, piece := range pieces {
+		syms := make([]int, len(piece))
+		for j := 0; j < len(piece); j++ {
+			syms[j] = int(piece[j])
+		}
+		words[i] = word{syms: syms, count: counts[piece]}
+		for j := 0; j+1 < len(syms); j++ {
+			p := pair{syms[j], syms[j+1]}
+			pairCounts[p] += words[i].count
+			pairWords[p] = append(pairWords[p], i)
+		}
+	}
+
+	h := &pairHeap{}
+	for p, count := range pairCounts {
+		*h = append(*h, entry{p, count})
+	}
+	heap.Init(h)
+
+	for len(tokens) < size && h.Len() > 0 {
+		top := heap.Pop(h).(entry)
+		if pairCounts[top.p] != top.count {
+			continue
+		}
+		if top.count < 2 {
+			break
+		}
+
+		merged := append(append([]byte{}, tokens[top.p[0]]...), tokens[top.p[1]]...)
+		id, exists := byBytes[string(merged)]
+		if !exists {
+			id = len(tokens)
+			tokens = append(tokens, merged)
+			byBytes[string(merged)] = id
+		}
+
+		changed := make(map[pair]struct{})
+		seen := make(map[int]struct{})
+		for _, w := range pairWords[top.p] {
+			if _, done := seen[w]; done {
+				continue
+			}
+			seen[w] = struct{}{}
+			syms, count := words[w].syms, words[w].count
+			if !containsPair(syms, top.p) {
+				continue
+			}
+			for j := 0; j+1 < len(syms); j++ {
+				p := pair{syms[j], syms[j+1]}
+				pairCounts[p] -= count
+				changed[p] = struct{}{}
+			}
+			out := syms[:0]
+			for j := 0; j < len(syms); j++ {
+				if j+1 < len(syms) && syms[j] == top.p[0] && syms[j+1] == top.p[1] {
+					out = append(out, id)
+					j++
+					continue
+				}
+				out = append(out, syms[j])
+			}
+			words[w].syms = out
+			for j := 0; j+1 < len(out); j++ {
+				p := pair{out[j], out[j+1]}
+				pairCounts[p] += count
+				pairWords[p] = append(pairWords[p], w)
+				changed[p] = struct{}{}
+			}
+		}
+		delete(pairWords, top.p)
+
+		for p := range changed {
+			if count := pairCounts[p]; count > 0 {
+				heap.Push(h, entry{p, count})
+			} else {
+				delete(pairCounts, p)
+				delete(pairWords, p)
+			}
+		}
+	}
+	return tokens
+}
+
+func containsPair(syms []int, p pair) bool {
+	for j := 0; j+1 < len(syms); j++ {
+		if syms[j] == p[0] && syms[j+1] == p[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func write(path string, tokens [][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewWriterLevel(f, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(gz)
+	for rank, token := range tokens {
+		fmt.Fprintf(w, "%s %d\n", base64.StdEncoding.EncodeToString(token), rank)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
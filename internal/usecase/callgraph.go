@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+const externalPrefix = "external:"
+
+type CallGraphUseCase struct {
+	store   port.IndexStore
+	symbols port.SymbolStore
+}
+
+func NewCallGraphUseCase(store port.IndexStore, symbols port.SymbolStore) *CallGraphUseCase {
+	return &CallGraphUseCase{
+		store:   store,
+		symbols: symbols,
+	}
+}
+
+type CallTreeOptions struct {
+	// Depth limits how many call levels are followed; 0 means no limit.
+	Depth int
+	// External includes callees that do not resolve to an indexed symbol.
+	External bool
+	Lang     string
+}
+
+type CallNode struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind,omitempty"`
+	Signature string      `json:"signature,omitempty"`
+	Path      string      `json:"path,omitempty"`
+	Line      int         `json:"line,omitempty"`
+	CallPath  string      `json:"call_path,omitempty"`
+	CallLine  int         `json:"call_line,omitempty"`
+	Repeated  bool        `json:"repeated,omitempty"`
+	Children  []*CallNode `json:"children,omitempty"`
+}
+
+// External reports whether the node is a callee outside the index.
+func (n *CallNode) External() bool {
+	return strings.HasPrefix(n.ID, externalPrefix)
+}
+
+// Citation formats the node's definition as path:line.
+func (n *CallNode) Citation() string {
+	if n.Path == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", n.Path, n.Line)
+}
+
+func (u *CallGraphUseCase) Callers(name string, opts CallTreeOptions) ([]*CallNode, error) {
+	return u.trees(name, false, opts)
+}
+
+func (u *CallGraphUseCase) Callees(name string, opts CallTreeOptions) ([]*CallNode, error) {
+	return u.trees(name, true, opts)
+}
+
+func (u *CallGraphUseCase) trees(name string, forward bool, opts CallTreeOptions) ([]*CallNode, error) {
+	g, err := loadCallGraph(u.store, u.symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []*CallNode
+	for _, sym := range g.functions {
+		if !symbolNameMatches(sym.Name, name) {
+			continue
+		}
+		if opts.Lang != "" && !strings.EqualFold(g.docs[sym.DocID].Lang, opts.Lang) {
+			continue
+		}
+		root := g.node(sym.ID)
+		g.expand(root, forward, 0, opts, make(map[string]bool))
+		roots = append(roots, root)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		if roots[i].Path != roots[j].Path {
+			return roots[i].Path < roots[j].Path
+		}
+		return roots[i].Line < roots[j].Line
+	})
+	return roots, nil
+}
+
+type callEdge struct {
+	caller string
+	callee string
+	docID  string
+	line   int
+}
+
+type callGraph struct {
+	docs      map[string]domain.Document
+	symbols   map[string]domain.Symbol
+	functions []domain.Symbol
+	byName    map[string][]domain.Symbol
+	byMethod  map[string][]domain.Symbol
+	byChunk   map[string][]domain.Symbol
+	callers   map[string][]callEdge
+	callees   map[string][]callEdge
+}
+
+func loadCallGraph(store port.IndexStore, symbolStore port.SymbolStore) (*callGraph, error) {
+	docs, err := store.ListDocs()
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := symbolStore.GetAllSymbols()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := symbolStore.GetAllCallGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &callGraph{
+		docs:     make(map[string]domain.Document, len(docs)),
+		symbols:  make(map[string]domain.Symbol, len(symbols)),
+		byName:   make(map[string][]domain.Symbol),
+		byMethod: make(map[string][]domain.Symbol),
+		byChunk:  make(map[string][]domain.Symbol),
+		callers:  make(map[string][]callEdge),
+		callees:  make(map[string][]callEdge),
+	}
+	for _, doc := range docs {
+		g.docs[doc.ID] = doc
+	}
+	for _, sym := range symbols {
+		g.symbols[sym.ID] = sym
+		if sym.ChunkID != "" {
+			g.byChunk[sym.ChunkID] = append(g.byChunk[sym.ChunkID], sym)
+		}
+		switch sym.Type {
+		case "function":
+			g.functions = append(g.functions, sym)
+			g.byName[sym.Name] = append(g.byName[sym.Name], sym)
+		case "method":
+			g.functions = append(g.functions, sym)
+			bare := sym.Name[strings.LastIndex(sym.Name, ".")+1:]
+			g.byMethod[bare] = append(g.byMethod[bare], sym)
+		}
+	}
+
+	for _, entry := range entries {
+		caller, exists := g.symbols[entry.CallerID]
+		if !exists {
+			continue
+		}
+		callee := entry.CalleeID
+		if _, exists := g.symbols[callee]; !exists {
+			callee = externalPrefix + callee
+			if !entry.External {
+				if sym, ok := g.resolve(entry.CalleeID, caller.DocID); ok {
+					callee = sym.ID
+				}
+			}
+		}
+		edge := callEdge{caller: caller.ID, callee: callee, docID: caller.DocID, line: entry.Line}
+		g.callees[edge.caller] = append(g.callees[edge.caller], edge)
+		g.callers[edge.callee] = append(g.callers[edge.callee], edge)
+	}
+
+	for _, chunkSymbols := range g.byChunk {
+		sort.Slice(chunkSymbols, func(i, j int) bool {
+			return chunkSymbols[i].Line < chunkSymbols[j].Line
+		})
+	}
+	for _, edges := range []map[string][]callEdge{g.callers, g.callees} {
+		for _, list := range edges {
+			sort.SliceStable(list, func(i, j int) bool {
+				pi, pj := g.docs[list[i].docID].Path, g.docs[list[j].docID].Path
+				if pi != pj {
+					return pi < pj
+				}
+				return list[i].line < list[j].line
+			})
+		}
+	}
+	return g, nil
+}
+
+// Names link only when unique in the caller's directory or else the index, so Close stays unresolved.
+func (g *callGraph) resolve(callee, callerDocID string) (domain.Symbol, bool) {
+	var candidates []domain.Symbol
+	if i := strings.LastIndex(callee, "."); i >= 0 {
+		qualifier, name := callee[:i], callee[i+1:]
+		for _, sym := range g.byName[name] {
+			if filepath.Base(filepath.Dir(g.docs[sym.DocID].Path)) == qualifier {
+				candidates = append(candidates, sym)
+			}
+		}
+		if len(candidates) == 0 {
+			candidates = g.byMethod[name]
+		}
+	} else {
+		candidates = g.byName[callee]
+	}
+
+	dir := filepath.Dir(g.docs[callerDocID].Path)
+	var local []domain.Symbol
+	for _, sym := range candidates {
+		if filepath.Dir(g.docs[sym.DocID].Path) == dir {
+			local = append(local, sym)
+		}
+	}
+	switch {
+	case len(local) == 1:
+		return local[0], true
+	case len(local) == 0 && len(candidates) == 1:
+		return candidates[0], true
+	}
+	return domain.Symbol{}, false
+}
+
+func (g *callGraph) node(key string) *CallNode {
+	sym, exists := g.symbols[key]
+	if !exists {
+		return &CallNode{ID: key, Name: strings.TrimPrefix(key, externalPrefix)}
+	}
+	return &CallNode{
+		ID:        sym.ID,
+		Name:      sym.Name,
+		Kind:      sym.Type,
+		Signature: sym.Signature,
+		Path:      g.docs[sym.DocID].Path,
+		Line:      sym.Line,
+	}
+}
+
+// Each function is expanded once per tree; later occurrences are marked Repeated.
+func (g *callGraph) expand(node *CallNode, forward bool, level int, opts CallTreeOptions, expanded map[string]bool) {
+	if opts.Depth > 0 && level >= opts.Depth {
+		return
+	}
+	expanded[node.ID] = true
+
+	edges := g.callers[node.ID]
+	if forward {
+		edges = g.callees[node.ID]
+	}
+
+	seen := make(map[string]bool)
+	for _, edge := range edges {
+		next := edge.caller
+		if forward {
+			next = edge.callee
+		}
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+
+		child := g.node(next)
+		if child.External() && !opts.External {
+			continue
+		}
+		child.CallPath = g.docs[edge.docID].Path
+		child.CallLine = edge.line
+		node.Children = append(node.Children, child)
+
+		if child.External() {
+			continue
+		}
+		if expanded[next] {
+			child.Repeated = g.hasEdges(next, forward)
+			continue
+		}
+		g.expand(child, forward, level+1, opts, expanded)
+	}
+}
+
+func (g *callGraph) hasEdges(key string, forward bool) bool {
+	if forward {
+		return len(g.callees[key]) > 0
+	}
+	return len(g.callers[key]) > 0
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func childNames(node *CallNode) []string {
+	names := make([]string, len(node.Children))
+	for i, child := range node.Children {
+		names[i] = child.Name
+	}
+	return names
+}
+
+func TestCallGraphUseCase(t *testing.T) {
+	st := newTestSymbolIndex(t, map[string]string{
+		"server.go": "package server\n\ntype Server struct{}\n\nfunc (s *Server) Start() error {\n\tlisten()\n\ts.Close()\n\treturn nil\n}\n\nfunc (s *Server) Close() error { return nil }\n\nfunc Run() {\n\tvar s Server\n\ts.Start()\n}\n",
+		"listen.go": "package server\n\nimport \"fmt\"\n\nfunc listen() {\n\tfmt.Println(\"listening\")\n\tretry()\n}\n\nfunc retry() {\n\tlisten()\n}\n",
+		"file.go":   "package server\n\ntype File struct{}\n\nfunc (f *File) Close() error { return nil }\n",
+	})
+	calls := NewCallGraphUseCase(st, st)
+
+	trees, err := calls.Callees("Server.Start", CallTreeOptions{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trees) != 1 {
+		t.Fatalf("expected one root, got %d", len(trees))
+	}
+	if names := childNames(trees[0]); !reflect.DeepEqual(names, []string{"listen"}) {
+		t.Errorf("expected the cross-file call and no ambiguous Close, got %v", names)
+	}
+	if child := trees[0].Children[0]; child.Line != 5 || child.CallLine != 6 || len(child.Children) != 0 {
+		t.Errorf("expected listen defined at line 5, called at line 6 and not expanded, got %+v", child)
+	}
+
+	trees, _ = calls.Callees("Server.Start", CallTreeOptions{Depth: 0, External: true})
+	if names := childNames(trees[0]); !reflect.DeepEqual(names, []string{"listen", "s.Close"}) {
+		t.Errorf("expected unresolved callees with External, got %v", names)
+	}
+	listen := trees[0].Children[0]
+	if names := childNames(listen); !reflect.DeepEqual(names, []string{"fmt.Println", "retry"}) {
+		t.Fatalf("expected transitive callees of listen, got %v", names)
+	}
+	if cycle := listen.Children[1].Children[0]; cycle.Name != "listen" || !cycle.Repeated || len(cycle.Children) != 0 {
+		t.Errorf("expected the recursive call to be marked repeated, got %+v", cycle)
+	}
+
+	trees, _ = calls.Callers("listen", CallTreeOptions{Depth: 2})
+	if names := childNames(trees[0]); !reflect.DeepEqual(names, []string{"retry", "*Server.Start"}) {
+		t.Errorf("expected callers ordered by call site, got %v", names)
+	}
+	if names := childNames(trees[0].Children[1]); !reflect.DeepEqual(names, []string{"Run"}) {
+		t.Errorf("expected Run as the caller of Start, got %v", names)
+	}
+
+	trees, _ = calls.Callers("Close", CallTreeOptions{})
+	if len(trees) != 2 || len(trees[0].Children) != 0 || len(trees[1].Children) != 0 {
+		t.Errorf("expected both Close methods without resolved callers, got %+v", trees)
+	}
+}
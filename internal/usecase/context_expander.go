@@ -1,7 +1,10 @@
 package usecase
 
 import (
+	"fmt"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"rag/internal/adapter/store"
@@ -13,15 +16,27 @@ type ContextExpander struct {
 	includeImports    bool
 	includeTests      bool
 	includeInterfaces bool
+	includeCallees    bool
+	includeCallers    bool
 	maxExpansion      int
 }
 
-func NewContextExpander(store *store.BoltStore, includeImports, includeTests, includeInterfaces bool) *ContextExpander {
+type ContextExpanderOptions struct {
+	Imports    bool
+	Tests      bool
+	Interfaces bool
+	Callees    bool
+	Callers    bool
+}
+
+func NewContextExpander(store *store.BoltStore, opts ContextExpanderOptions) *ContextExpander {
 	return &ContextExpander{
 		store:             store,
-		includeImports:    includeImports,
-		includeTests:      includeTests,
-		includeInterfaces: includeInterfaces,
+		includeImports:    opts.Imports,
+		includeTests:      opts.Tests,
+		includeInterfaces: opts.Interfaces,
+		includeCallees:    opts.Callees,
+		includeCallers:    opts.Callers,
 		maxExpansion:      3,
 	}
 }
@@ -244,31 +259,34 @@ func extractImports(chunks []domain.ScoredChunk) []string {
 	seen := make(map[string]bool)
 
 	for _, c := range chunks {
+		imports = appendImports(imports, seen, c.Chunk.Text)
+	}
 
-		lines := strings.Split(c.Chunk.Text, "\n")
-		inImportBlock := false
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
+	return imports
+}
 
-			if strings.HasPrefix(line, "import (") {
-				inImportBlock = true
-				continue
-			}
-			if inImportBlock && line == ")" {
-				inImportBlock = false
-				continue
-			}
-			if inImportBlock || strings.HasPrefix(line, "import ") {
+func appendImports(imports []string, seen map[string]bool, text string) []string {
+	inImportBlock := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
 
-				imp := extractImportPath(line)
-				if imp != "" && !seen[imp] {
-					imports = append(imports, imp)
-					seen[imp] = true
-				}
+		if strings.HasPrefix(line, "import (") {
+			inImportBlock = true
+			continue
+		}
+		if inImportBlock && line == ")" {
+			inImportBlock = false
+			continue
+		}
+		if inImportBlock || strings.HasPrefix(line, "import ") {
+
+			imp := extractImportPath(line)
+			if imp != "" && !seen[imp] {
+				imports = append(imports, imp)
+				seen[imp] = true
 			}
 		}
 	}
-
 	return imports
 }
 
@@ -305,3 +323,290 @@ func matchesImport(filePath, importPath string) bool {
 	return strings.HasSuffix(filePath, importPath) ||
 		strings.Contains(filePath, importPath+"/")
 }
+
+// RelatedChunk is a chunk pulled into a pack; Why names the relation.
+type RelatedChunk struct {
+	Chunk domain.Chunk
+	Why   string
+}
+
+var (
+	interfaceMethodPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	qualifiedIdentPattern  = regexp.MustCompile(`\b([a-z][A-Za-z0-9_]*)\.([A-Z][A-Za-z0-9_]*)`)
+)
+
+// ExpandGraph adds up to maxExpansion chunks per relation for each result.
+func (e *ContextExpander) ExpandGraph(results []domain.ScoredChunk) ([]RelatedChunk, error) {
+	if len(results) == 0 {
+		return nil, nil
+	}
+	g, err := loadCallGraph(e.store, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[string]bool)
+	for _, r := range results {
+		included[r.Chunk.ID] = true
+	}
+
+	var related []RelatedChunk
+	add := func(chunkID, why string) bool {
+		if chunkID == "" || included[chunkID] {
+			return false
+		}
+		chunk, err := e.store.GetChunk(chunkID)
+		if err != nil {
+			return false
+		}
+		included[chunkID] = true
+		related = append(related, RelatedChunk{Chunk: chunk, Why: why})
+		return true
+	}
+
+	interfaces := make(map[string][]string)
+	for _, r := range results {
+		meta := e.chunkMetadata(g, r.Chunk)
+		name := meta.Name
+		if name == "" {
+			name = fmt.Sprintf("%s:L%d-%d", filepath.Base(g.docs[r.Chunk.DocID].Path), r.Chunk.StartLine, r.Chunk.EndLine)
+		}
+
+		if e.includeCallees {
+			added := 0
+			for _, id := range meta.Calls {
+				if added >= e.maxExpansion {
+					break
+				}
+				callee := g.symbols[id]
+				if add(callee.ChunkID, fmt.Sprintf("callee: %s, called by %s", callee.Name, name)) {
+					added++
+				}
+			}
+		}
+
+		if e.includeInterfaces {
+			added := 0
+			for _, iface := range e.implementedInterfaces(g, r.Chunk, interfaces) {
+				if added >= e.maxExpansion {
+					break
+				}
+				if add(iface.ChunkID, fmt.Sprintf("interface: %s, implemented by %s", iface.Name, name)) {
+					added++
+				}
+			}
+		}
+
+		if e.includeCallers {
+			added := 0
+			for _, id := range meta.CalledBy {
+				if added >= e.maxExpansion {
+					break
+				}
+				caller := g.symbols[id]
+				if add(caller.ChunkID, fmt.Sprintf("caller: %s, calls %s", caller.Name, name)) {
+					added++
+				}
+			}
+		}
+
+		if e.includeImports {
+			added := 0
+			for _, sym := range importedDefinitions(g, r.Chunk, meta.Imports) {
+				if added >= e.maxExpansion {
+					break
+				}
+				pkg := filepath.Base(filepath.Dir(g.docs[sym.DocID].Path))
+				if add(sym.ChunkID, fmt.Sprintf("import: %s.%s, used by %s", pkg, sym.Name, name)) {
+					added++
+				}
+			}
+		}
+	}
+
+	return related, nil
+}
+
+// chunkMetadata adds the current call edges to the metadata stored at index time.
+func (e *ContextExpander) chunkMetadata(g *callGraph, chunk domain.Chunk) domain.ChunkMetadata {
+	meta, _ := e.store.GetChunkMetadata(chunk.ID)
+
+	calls := make(map[string]bool)
+	callers := make(map[string]bool)
+	for _, id := range meta.Symbols {
+		for _, edge := range g.callees[id] {
+			callee, exists := g.symbols[edge.callee]
+			if !exists || callee.ChunkID == chunk.ID || calls[edge.callee] {
+				continue
+			}
+			calls[edge.callee] = true
+			meta.Calls = append(meta.Calls, edge.callee)
+		}
+		for _, edge := range g.callers[id] {
+			caller := g.symbols[edge.caller]
+			if caller.ChunkID == chunk.ID || callers[edge.caller] {
+				continue
+			}
+			callers[edge.caller] = true
+			meta.CalledBy = append(meta.CalledBy, edge.caller)
+		}
+	}
+
+	return meta
+}
+
+// Types are matched by name within their package directory.
+func (e *ContextExpander) implementedInterfaces(g *callGraph, chunk domain.Chunk, interfaces map[string][]string) []domain.Symbol {
+	dir := filepath.Dir(g.docs[chunk.DocID].Path)
+	types := make(map[string]bool)
+	for _, sym := range g.byChunk[chunk.ID] {
+		switch sym.Type {
+		case "struct", "type":
+			types[sym.Name] = true
+		case "method":
+			if i := strings.LastIndex(sym.Name, "."); i > 0 {
+				types[strings.TrimPrefix(sym.Name[:i], "*")] = true
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	methodSets := make(map[string]map[string]bool)
+	for _, methods := range g.byMethod {
+		for _, m := range methods {
+			i := strings.LastIndex(m.Name, ".")
+			recv := strings.TrimPrefix(m.Name[:i], "*")
+			if !types[recv] || filepath.Dir(g.docs[m.DocID].Path) != dir {
+				continue
+			}
+			if methodSets[recv] == nil {
+				methodSets[recv] = make(map[string]bool)
+			}
+			methodSets[recv][m.Name[i+1:]] = true
+		}
+	}
+
+	var matches []domain.Symbol
+	for _, sym := range g.symbols {
+		if sym.Type != "interface" || sym.ChunkID == "" || sym.ChunkID == chunk.ID {
+			continue
+		}
+		methods, cached := interfaces[sym.ID]
+		if !cached {
+			if c, err := e.store.GetChunk(sym.ChunkID); err == nil {
+				methods = interfaceMethods(c.Text, sym.Name)
+			}
+			interfaces[sym.ID] = methods
+		}
+		if len(methods) == 0 {
+			continue
+		}
+		for _, set := range methodSets {
+			if containsAll(set, methods) {
+				matches = append(matches, sym)
+				break
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		pi, pj := g.docs[matches[i].DocID].Path, g.docs[matches[j].DocID].Path
+		if pi != pj {
+			return pi < pj
+		}
+		return matches[i].Line < matches[j].Line
+	})
+	return matches
+}
+
+// Embedded interfaces are not followed.
+func interfaceMethods(text, name string) []string {
+	var methods []string
+	inBody := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !inBody {
+			inBody = strings.Contains(line, name+" interface {")
+			continue
+		}
+		if line == "}" {
+			break
+		}
+		if m := interfaceMethodPattern.FindStringSubmatch(line); m != nil {
+			methods = append(methods, m[1])
+		}
+	}
+	return methods
+}
+
+func containsAll(set map[string]bool, names []string) bool {
+	for _, name := range names {
+		if !set[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// An import maps to the indexed directory matching its longest unambiguous suffix.
+func importedDefinitions(g *callGraph, chunk domain.Chunk, imports []string) []domain.Symbol {
+	if len(imports) == 0 {
+		return nil
+	}
+
+	dirs := make(map[string]bool)
+	for _, doc := range g.docs {
+		dirs[filepath.ToSlash(filepath.Dir(doc.Path))] = true
+	}
+
+	packages := make(map[string]string)
+	for _, imp := range imports {
+		segments := strings.Split(imp, "/")
+		for k := len(segments); k >= 1; k-- {
+			suffix := "/" + strings.Join(segments[len(segments)-k:], "/")
+			var match string
+			count := 0
+			for dir := range dirs {
+				if strings.HasSuffix(dir, suffix) {
+					match = dir
+					count++
+				}
+			}
+			if count == 1 {
+				packages[segments[len(segments)-1]] = match
+				break
+			}
+			if count > 1 {
+				break
+			}
+		}
+	}
+	if len(packages) == 0 {
+		return nil
+	}
+
+	definitions := make(map[string]domain.Symbol)
+	for _, sym := range g.symbols {
+		if sym.Type == "method" || sym.Type == "variable" || sym.ChunkID == "" {
+			continue
+		}
+		definitions[filepath.ToSlash(filepath.Dir(g.docs[sym.DocID].Path))+"\x00"+sym.Name] = sym
+	}
+
+	var used []domain.Symbol
+	seen := make(map[string]bool)
+	for _, m := range qualifiedIdentPattern.FindAllStringSubmatch(chunk.Text, -1) {
+		dir, imported := packages[m[1]]
+		if !imported {
+			continue
+		}
+		key := dir + "\x00" + m[2]
+		if sym, exists := definitions[key]; exists && !seen[key] {
+			seen[key] = true
+			used = append(used, sym)
+		}
+	}
+	return used
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/adapter/store"
+	"rag/internal/domain"
+)
+
+func findChunk(t *testing.T, st *store.BoltStore, base, marker string) domain.Chunk {
+	t.Helper()
+	docs, err := st.ListDocs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs {
+		if filepath.Base(doc.Path) != base {
+			continue
+		}
+		chunks, err := st.GetChunksByDoc(doc.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range chunks {
+			if strings.Contains(c.Text, marker) {
+				return c
+			}
+		}
+	}
+	t.Fatalf("no chunk of %s contains %q", base, marker)
+	return domain.Chunk{}
+}
+
+func relatedWhy(related []RelatedChunk) []string {
+	why := make([]string, len(related))
+	for i, rc := range related {
+		why[i] = rc.Why
+	}
+	return why
+}
+
+func TestContextExpander_ExpandGraph(t *testing.T) {
+	st := newTestSymbolIndex(t, map[string]string{
+		"server/server.go":  "package server\n\nimport \"example.com/app/store\"\n\ntype Server struct{}\n\nfunc (s *Server) Start(cfg store.Config) error {\n\tlisten()\n\treturn nil\n}\n\nfunc (s *Server) Stop() error { return nil }\n\nfunc Run() {\n\tvar s Server\n\ts.Start(store.Config{})\n}\n",
+		"server/listen.go":  "package server\n\nfunc listen() {}\n",
+		"server/service.go": "package server\n\ntype Service interface {\n\tStart() error\n\tStop() error\n}\n",
+		"store/store.go":    "package store\n\ntype Config struct{}\n",
+	})
+	start := findChunk(t, st, "server.go", "func (s *Server) Start")
+
+	expander := NewContextExpander(st, ContextExpanderOptions{Imports: true, Interfaces: true, Callees: true, Callers: true})
+	related, err := expander.ExpandGraph([]domain.ScoredChunk{{Chunk: start, Score: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"callee: listen, called by *Server.Start",
+		"interface: Service, implemented by *Server.Start",
+		"caller: Run, calls *Server.Start",
+		"import: store.Config, used by *Server.Start",
+	}
+	if why := relatedWhy(related); !reflect.DeepEqual(why, want) {
+		t.Errorf("ExpandGraph = %v, want %v", why, want)
+	}
+
+	only := NewContextExpander(st, ContextExpanderOptions{Callees: true})
+	listen := findChunk(t, st, "listen.go", "func listen")
+	related, _ = only.ExpandGraph([]domain.ScoredChunk{{Chunk: start, Score: 1}, {Chunk: listen, Score: 0.5}})
+	if len(related) != 0 {
+		t.Errorf("expected callees already in results to be skipped, got %v", relatedWhy(related))
+	}
+}
+
+func TestPackGraphExpansion(t *testing.T) {
+	st := newTestSymbolIndex(t, map[string]string{
+		"server.go": "package server\n\nfunc Start() error {\n\tlisten()\n\treturn nil\n}\n",
+		"listen.go": "package server\n\nfunc listen() {\n\tbind(\"0.0.0.0\", 8080)\n}\n",
+	})
+	start := findChunk(t, st, "server.go", "func Start")
+	tokenizer := analyzer.NewTokenizer(true)
+	chunks := []domain.ScoredChunk{{Chunk: start, Score: 1}}
+
+	expander := NewContextExpander(st, ContextExpanderOptions{Callees: true})
+	budget := 2 * (tokenizer.CountTokens(start.Text) + tokenizer.CountTokens(findChunk(t, st, "listen.go", "func listen").Text))
+	result, err := NewPackUseCase(st, tokenizer, 0, expander, 0.5).Pack("start", chunks, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Snippets) != 2 || result.Snippets[1].Why != "callee: listen, called by Start" {
+		t.Fatalf("expected the callee appended after the retrieved chunk, got %+v", result.Snippets)
+	}
+	if result.UsedTokens > budget {
+		t.Errorf("used %d tokens over a budget of %d", result.UsedTokens, budget)
+	}
+
+	result, _ = NewPackUseCase(st, tokenizer, 0, expander, 0.5).Pack("start", chunks, tokenizer.CountTokens(start.Text))
+	if len(result.Snippets) != 1 {
+		t.Errorf("expected unused expansion budget to go to retrieved chunks, got %+v", result.Snippets)
+	}
+
+	listen := findChunk(t, st, "listen.go", "func listen")
+	retrieved := append(chunks, domain.ScoredChunk{Chunk: listen, Score: 0.01})
+	budget = tokenizer.CountTokens(start.Text) + 2*tokenizer.CountTokens(listen.Text)
+	result, _ = NewPackUseCase(st, tokenizer, 0, expander, 0.5).Pack("start", retrieved, budget)
+	if len(result.Snippets) != 2 {
+		t.Errorf("expected a related chunk not to be packed again as retrieved, got %+v", result.Snippets)
+	}
+}
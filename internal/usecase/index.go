@@ -5,7 +5,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
-	"path/filepath"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -20,6 +19,11 @@ type IndexUseCase struct {
 	walker    port.FileWalker
 	chunkSvc  port.Chunker
 	tokenizer port.Tokenizer
+	vectors   port.VectorStore
+	positions bool
+	fields    port.FieldExtractor
+	symbols   port.SymbolExtractor
+	calls     port.CallGraphAnalyzer
 	workers   int
 }
 
@@ -28,6 +32,11 @@ func NewIndexUseCase(
 	walker port.FileWalker,
 	chunkSvc port.Chunker,
 	tokenizer port.Tokenizer,
+	vectors port.VectorStore,
+	positions bool,
+	fields port.FieldExtractor,
+	symbols port.SymbolExtractor,
+	calls port.CallGraphAnalyzer,
 ) *IndexUseCase {
 	workers := runtime.NumCPU()
 	if workers < 2 {
@@ -38,16 +47,38 @@ func NewIndexUseCase(
 		walker:    walker,
 		chunkSvc:  chunkSvc,
 		tokenizer: tokenizer,
+		vectors:   vectors,
+		positions: positions,
+		fields:    fields,
+		symbols:   symbols,
+		calls:     calls,
 		workers:   workers,
 	}
 }
 
 type IndexResult struct {
-	FilesIndexed  int
-	FilesSkipped  int
-	FilesDeleted  int
-	ChunksCreated int
-	Errors        []string
+	FilesIndexed   int
+	FilesSkipped   int
+	FilesTouched   int
+	FilesDeleted   int
+	ChunksCreated  int
+	ChunksAdded    int
+	ChunksRemoved  int
+	ChunksKept     int
+	SymbolsIndexed int
+	CallEdges      int
+	VectorsPruned  int
+	FileChanges    []FileChange
+	Errors         []string
+}
+
+type FileChange struct {
+	Path    string
+	Added   int
+	Removed int
+	Kept    int
+	Symbols int
+	Calls   int
 }
 
 type ProgressCallback func(processed, total int, currentFile string)
@@ -73,23 +104,36 @@ func (u *IndexUseCase) Index(root string, progress ProgressCallback) (*IndexResu
 	seenPaths := make(map[string]bool)
 
 	var filesToIndex []port.FileInfo
+	var changedGo []string
 	var skippedDocs []domain.Document
 
 	for _, file := range files {
 		seenPaths[file.Path] = true
 
 		if existing, ok := existingMap[file.Path]; ok {
-			if existing.ModTime.Unix() >= file.ModTime {
+			if existing.ModTime.Unix() == file.ModTime {
 				result.FilesSkipped++
 				skippedDocs = append(skippedDocs, existing)
 				continue
 			}
 
-			if err := u.deleteDocument(existing.ID); err != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("failed to delete old data for %s: %v", file.Path, err))
+			if existing.ContentHash != "" {
+				hash, err := hashFile(file.Path)
+				if err == nil && hash == existing.ContentHash {
+					existing.ModTime = time.Unix(file.ModTime, 0)
+					if err := u.store.PutDoc(existing); err != nil {
+						result.Errors = append(result.Errors, fmt.Sprintf("failed to update mtime for %s: %v", file.Path, err))
+					}
+					result.FilesTouched++
+					skippedDocs = append(skippedDocs, existing)
+					continue
+				}
 			}
 		}
 		filesToIndex = append(filesToIndex, file)
+		if domain.DetectLanguage(file.Path) == "go" {
+			changedGo = append(changedGo, file.Path)
+		}
 	}
 
 	for path, doc := range existingMap {
@@ -98,38 +142,73 @@ func (u *IndexUseCase) Index(root string, progress ProgressCallback) (*IndexResu
 				result.Errors = append(result.Errors, fmt.Sprintf("failed to delete %s: %v", path, err))
 			} else {
 				result.FilesDeleted++
+				if doc.Lang == "go" {
+					changedGo = append(changedGo, path)
+				}
 			}
 		}
 	}
 
 	var existingChunkLen int64
 	var existingChunkCount int64
+	existingFieldLen := make(map[string]int)
 	for _, doc := range skippedDocs {
 		chunks, _ := u.store.GetChunksByDoc(doc.ID)
 		for _, c := range chunks {
 			atomic.AddInt64(&existingChunkCount, 1)
 			atomic.AddInt64(&existingChunkLen, int64(len(c.Tokens)))
+			addFieldLengths(existingFieldLen, c)
 		}
 	}
 
 	if len(filesToIndex) > 0 {
-		indexed, chunkCount, chunkLen, errors := u.indexFilesParallel(filesToIndex, progress)
+		indexed, chunkCount, chunkLen, fieldLen, changes, errors := u.indexFilesParallel(filesToIndex, progress)
 		result.FilesIndexed = indexed
 		result.Errors = append(result.Errors, errors...)
 		existingChunkCount += int64(chunkCount)
 		existingChunkLen += int64(chunkLen)
+		for field, length := range fieldLen {
+			existingFieldLen[field] += length
+		}
+
+		for _, change := range changes {
+			result.ChunksAdded += change.Added
+			result.ChunksRemoved += change.Removed
+			result.ChunksKept += change.Kept
+			result.SymbolsIndexed += change.Symbols
+			result.CallEdges += change.Calls
+			if _, existed := existingMap[change.Path]; existed {
+				result.FileChanges = append(result.FileChanges, change)
+			}
+		}
+	}
+
+	if u.calls != nil && len(changedGo) > 0 {
+		edges, err := u.analyzeCalls(changedGo)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("call graph analysis failed: %v", err))
+		}
+		result.CallEdges += edges
 	}
 
 	totalChunks := int(existingChunkCount)
 	avgChunkLen := 0.0
+	var avgFieldLen map[string]float64
 	if totalChunks > 0 {
 		avgChunkLen = float64(existingChunkLen) / float64(totalChunks)
+		for field, length := range existingFieldLen {
+			if avgFieldLen == nil {
+				avgFieldLen = make(map[string]float64)
+			}
+			avgFieldLen[field] = float64(length) / float64(totalChunks)
+		}
 	}
 
 	stats := domain.Stats{
-		TotalDocs:   result.FilesIndexed + result.FilesSkipped,
+		TotalDocs:   result.FilesIndexed + result.FilesSkipped + result.FilesTouched,
 		TotalChunks: totalChunks,
 		AvgChunkLen: avgChunkLen,
+		AvgFieldLen: avgFieldLen,
 	}
 	if err := u.store.UpdateStats(stats); err != nil {
 		return nil, fmt.Errorf("failed to update stats: %w", err)
@@ -137,17 +216,87 @@ func (u *IndexUseCase) Index(root string, progress ProgressCallback) (*IndexResu
 
 	result.ChunksCreated = totalChunks
 
+	pruned, err := u.SweepOrphanedVectors()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to sweep orphaned vectors: %v", err))
+	}
+	result.VectorsPruned += pruned
+
 	return result, nil
 }
 
+func (u *IndexUseCase) SweepOrphanedVectors() (int, error) {
+	if u.vectors == nil {
+		return 0, nil
+	}
+
+	ids, err := u.vectors.IDs()
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	live, err := u.store.GetChunkNorms(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	var orphaned []string
+	for _, id := range ids {
+		if _, exists := live[id]; !exists {
+			orphaned = append(orphaned, id)
+		}
+	}
+	if len(orphaned) == 0 {
+		return 0, nil
+	}
+
+	if err := u.vectors.Delete(orphaned); err != nil {
+		return 0, err
+	}
+	return len(orphaned), nil
+}
+
+func (u *IndexUseCase) deleteVectors(chunkIDs []string) error {
+	if u.vectors == nil || len(chunkIDs) == 0 {
+		return nil
+	}
+	return u.vectors.Delete(chunkIDs)
+}
+
 type processedFile struct {
 	file     port.IndexedFile
 	err      error
 	path     string
 	chunkLen int
+	change   FileChange
 }
 
-func (u *IndexUseCase) indexFilesParallel(files []port.FileInfo, progress ProgressCallback) (indexed, chunkCount, chunkLen int, errors []string) {
+func addFieldLengths(totals map[string]int, chunk domain.Chunk) {
+	for field, tokens := range chunk.Fields {
+		totals[field] += len(tokens)
+	}
+}
+
+func sameFields(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for field, tokens := range a {
+		other, exists := b[field]
+		if !exists || len(other) != len(tokens) {
+			return false
+		}
+		for i := range tokens {
+			if tokens[i] != other[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (u *IndexUseCase) indexFilesParallel(files []port.FileInfo, progress ProgressCallback) (indexed, chunkCount, chunkLen int, fieldLen map[string]int, changes []FileChange, errors []string) {
+	fieldLen = make(map[string]int)
 	totalFiles := len(files)
 	var processed int64
 
@@ -196,24 +345,41 @@ func (u *IndexUseCase) indexFilesParallel(files []port.FileInfo, progress Progre
 		indexed++
 		chunkCount += len(result.file.Chunks)
 		chunkLen += result.chunkLen
+		for _, chunk := range result.file.Chunks {
+			addFieldLengths(fieldLen, chunk)
+		}
+		changes = append(changes, result.change)
 
 		if len(batch) >= batchSize {
-			if err := u.store.BatchIndex(batch); err != nil {
-				errors = append(errors, fmt.Sprintf("batch write failed: %v", err))
-			}
+			errors = append(errors, u.writeBatch(batch)...)
 			batch = batch[:0]
 		}
 	}
 
 	if len(batch) > 0 {
-		if err := u.store.BatchIndex(batch); err != nil {
-			errors = append(errors, fmt.Sprintf("batch write failed: %v", err))
-		}
+		errors = append(errors, u.writeBatch(batch)...)
 	}
 
 	return
 }
 
+func (u *IndexUseCase) writeBatch(batch []port.IndexedFile) []string {
+	if err := u.store.BatchIndex(batch); err != nil {
+		return []string{fmt.Sprintf("batch write failed: %v", err)}
+	}
+
+	var removedIDs []string
+	for _, file := range batch {
+		for _, chunk := range file.Removed {
+			removedIDs = append(removedIDs, chunk.ID)
+		}
+	}
+	if err := u.deleteVectors(removedIDs); err != nil {
+		return []string{fmt.Sprintf("failed to delete stale vectors: %v", err)}
+	}
+	return nil
+}
+
 func (u *IndexUseCase) processFile(file port.FileInfo) processedFile {
 	result := processedFile{path: file.Path}
 
@@ -226,10 +392,14 @@ func (u *IndexUseCase) processFile(file port.FileInfo) processedFile {
 
 	docID := generateDocID(file.Path)
 	doc := domain.Document{
-		ID:      docID,
-		Path:    file.Path,
-		ModTime: time.Unix(file.ModTime, 0),
-		Lang:    detectLanguage(file.Path),
+		ID:          docID,
+		Path:        file.Path,
+		ModTime:     time.Unix(file.ModTime, 0),
+		Lang:        domain.DetectLanguage(file.Path),
+		ContentHash: HashContent(data),
+	}
+	if detector, ok := u.tokenizer.(port.LanguageDetector); ok {
+		doc.TextLang = detector.DocumentLanguage(doc.Lang, content)
 	}
 
 	chunks, err := u.chunkSvc.Chunk(doc, content)
@@ -237,14 +407,45 @@ func (u *IndexUseCase) processFile(file port.FileInfo) processedFile {
 		result.err = fmt.Errorf("failed to chunk content: %w", err)
 		return result
 	}
+	if u.fields != nil {
+		u.fields.ExtractFields(doc, content, chunks)
+	}
+
+	stored, err := u.store.GetChunksByDoc(docID)
+	if err != nil {
+		result.err = fmt.Errorf("failed to load stored chunks: %w", err)
+		return result
+	}
+	storedByID := make(map[string]domain.Chunk, len(stored))
+	for _, c := range stored {
+		storedByID[c.ID] = c
+	}
 
 	postings := make(map[string]map[string]int)
+	var positions map[string]map[string][]int
+	if u.positions {
+		positions = make(map[string]map[string][]int)
+	}
+	kept := make(map[string]struct{})
 	chunkLen := 0
 
 	for _, chunk := range chunks {
+		chunkLen += len(chunk.Tokens)
+		// IDs hash normalized content, so a whitespace-only edit keeps the chunk.
+		if old, exists := storedByID[chunk.ID]; exists && sameFields(old.Fields, chunk.Fields) {
+			kept[chunk.ID] = struct{}{}
+			continue
+		}
+
 		tf := make(map[string]int)
-		for _, token := range chunk.Tokens {
+		for i, token := range chunk.Tokens {
 			tf[token]++
+			if positions != nil {
+				if positions[token] == nil {
+					positions[token] = make(map[string][]int)
+				}
+				positions[token][chunk.ID] = append(positions[token][chunk.ID], i)
+			}
 		}
 		for term, count := range tf {
 			if postings[term] == nil {
@@ -252,19 +453,131 @@ func (u *IndexUseCase) processFile(file port.FileInfo) processedFile {
 			}
 			postings[term][chunk.ID] = count
 		}
-		chunkLen += len(chunk.Tokens)
 	}
 
+	var removed []domain.Chunk
+	for _, old := range stored {
+		if _, isKept := kept[old.ID]; !isKept {
+			removed = append(removed, old)
+		}
+	}
+
+	symbols, calls := u.extractSymbols(doc, content, chunks)
+
 	result.file = port.IndexedFile{
-		Doc:      doc,
-		Chunks:   chunks,
-		Postings: postings,
+		Doc:       doc,
+		Chunks:    chunks,
+		Postings:  postings,
+		Positions: positions,
+		Removed:   removed,
+		Kept:      kept,
+		Symbols:   symbols,
+		Calls:     calls,
+		Metadata:  buildChunkMetadata(content, chunks, symbols),
 	}
 	result.chunkLen = chunkLen
+	result.change = FileChange{
+		Path:    file.Path,
+		Added:   len(chunks) - len(kept),
+		Removed: len(removed),
+		Kept:    len(kept),
+		Symbols: len(symbols),
+		Calls:   len(calls),
+	}
 
 	return result
 }
 
+// Symbols link to the smallest chunk containing their line.
+func (u *IndexUseCase) extractSymbols(doc domain.Document, content string, chunks []domain.Chunk) ([]domain.Symbol, []domain.CallGraphEntry) {
+	if u.symbols == nil {
+		return nil, nil
+	}
+	symbols, err := u.symbols.ExtractSymbols(doc.ID, content, doc.Lang)
+	if err != nil || len(symbols) == 0 {
+		return nil, nil
+	}
+
+	for i := range symbols {
+		span := -1
+		for _, chunk := range chunks {
+			if symbols[i].Line < chunk.StartLine || symbols[i].Line > chunk.EndLine {
+				continue
+			}
+			if span < 0 || chunk.EndLine-chunk.StartLine < span {
+				span = chunk.EndLine - chunk.StartLine
+				symbols[i].ChunkID = chunk.ID
+			}
+		}
+	}
+
+	if u.calls != nil && doc.Lang == "go" {
+		return symbols, nil
+	}
+	calls, err := u.symbols.ExtractCalls(doc.ID, content, doc.Lang, symbols)
+	if err != nil {
+		return symbols, nil
+	}
+	return symbols, calls
+}
+
+// Call edges are left out: they change when other files are re-indexed.
+func buildChunkMetadata(content string, chunks []domain.Chunk, symbols []domain.Symbol) map[string]domain.ChunkMetadata {
+	imports := appendImports(nil, make(map[string]bool), content)
+	metadata := make(map[string]domain.ChunkMetadata)
+	for _, chunk := range chunks {
+		meta := domain.ChunkMetadata{Imports: imports}
+		for _, sym := range symbols {
+			if sym.ChunkID != chunk.ID {
+				continue
+			}
+			meta.Symbols = append(meta.Symbols, sym.ID)
+			if meta.Name == "" && sym.Type != "variable" {
+				meta.Name, meta.Type, meta.Signature = sym.Name, sym.Type, sym.Signature
+			}
+		}
+		if len(meta.Imports) > 0 || len(meta.Symbols) > 0 {
+			metadata[chunk.ID] = meta
+		}
+	}
+	return metadata
+}
+
+func (u *IndexUseCase) analyzeCalls(changed []string) (int, error) {
+	symbolStore, ok := u.store.(port.SymbolStore)
+	if u.symbols == nil || !ok {
+		return 0, nil
+	}
+
+	docs, err := u.store.ListDocs()
+	if err != nil {
+		return 0, err
+	}
+	docIDs := make(map[string]string)
+	for _, doc := range docs {
+		if doc.Lang == "go" {
+			docIDs[doc.Path] = doc.ID
+		}
+	}
+	if len(docIDs) == 0 {
+		return 0, nil
+	}
+
+	entries, err := u.calls.AnalyzeCalls(docIDs, changed)
+	if err != nil {
+		return 0, err
+	}
+	if err := symbolStore.PutCallGraphs(entries); err != nil {
+		return 0, err
+	}
+
+	edges := 0
+	for _, docEntries := range entries {
+		edges += len(docEntries)
+	}
+	return edges, nil
+}
+
 func (u *IndexUseCase) deleteDocument(docID string) error {
 
 	chunks, err := u.store.GetChunksByDoc(docID)
@@ -290,56 +603,40 @@ func (u *IndexUseCase) deleteDocument(docID string) error {
 		return err
 	}
 
+	chunkIDs := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		chunkIDs[i] = chunk.ID
+	}
+	if err := u.deleteVectors(chunkIDs); err != nil {
+		return err
+	}
+
+	if symbolStore, ok := u.store.(port.SymbolStore); ok {
+		if err := symbolStore.DeleteSymbolsByDoc(docID); err != nil {
+			return err
+		}
+		if err := symbolStore.DeleteCallGraph(docID); err != nil {
+			return err
+		}
+	}
+
 	return u.store.DeleteDoc(docID)
 }
 
-func generateDocID(path string) string {
-	hash := sha256.Sum256([]byte(path))
-	return hex.EncodeToString(hash[:8])
+func HashContent(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:16])
 }
 
-func detectLanguage(path string) string {
-	ext := filepath.Ext(path)
-	switch ext {
-	case ".go":
-		return "go"
-	case ".py":
-		return "python"
-	case ".js":
-		return "javascript"
-	case ".ts":
-		return "typescript"
-	case ".java":
-		return "java"
-	case ".c", ".h":
-		return "c"
-	case ".cpp", ".cc", ".hpp":
-		return "cpp"
-	case ".rs":
-		return "rust"
-	case ".rb":
-		return "ruby"
-	case ".php":
-		return "php"
-	case ".md":
-		return "markdown"
-	case ".txt":
-		return "text"
-	case ".json":
-		return "json"
-	case ".yaml", ".yml":
-		return "yaml"
-	case ".xml":
-		return "xml"
-	case ".html":
-		return "html"
-	case ".css":
-		return "css"
-	case ".sql":
-		return "sql"
-	case ".sh", ".bash":
-		return "shell"
-	default:
-		return "unknown"
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
 	}
+	return HashContent(data), nil
+}
+
+func generateDocID(path string) string {
+	hash := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(hash[:8])
 }
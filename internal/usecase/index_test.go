@@ -0,0 +1,382 @@
+package usecase
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/adapter/chunker"
+	"rag/internal/adapter/fs"
+	"rag/internal/adapter/store"
+	"rag/internal/port"
+)
+
+func newTestIndexer(t *testing.T) (*IndexUseCase, *store.BoltStore) {
+	t.Helper()
+
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	tokenizer := analyzer.NewTokenizer(true)
+	walker := fs.NewWalker([]string{"**/*.txt"}, nil)
+	chk := chunker.NewLineChunker(64, 0, tokenizer)
+
+	return NewIndexUseCase(st, walker, chk, tokenizer, nil, true, nil, nil, nil), st
+}
+
+func writeTestFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndex_ContentHashSkipsTouchedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeTestFile(t, path, "connection pool settings\nretry budget", base)
+
+	indexer, st := newTestIndexer(t)
+
+	result, err := indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FilesIndexed != 1 {
+		t.Fatalf("expected 1 file indexed, got %d", result.FilesIndexed)
+	}
+
+	writeTestFile(t, path, "connection pool settings\nretry budget", base.Add(time.Minute))
+
+	result, err = indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FilesIndexed != 0 || result.FilesTouched != 1 {
+		t.Errorf("expected touched file to be skipped, got indexed=%d touched=%d", result.FilesIndexed, result.FilesTouched)
+	}
+
+	docs, _ := st.ListDocs()
+	if len(docs) != 1 || docs[0].ModTime.Unix() != base.Add(time.Minute).Unix() {
+		t.Errorf("expected stored mtime to be refreshed, got %+v", docs)
+	}
+
+	result, err = indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FilesSkipped != 1 || result.FilesTouched != 0 {
+		t.Errorf("expected unchanged file to be skipped by mtime, got skipped=%d touched=%d", result.FilesSkipped, result.FilesTouched)
+	}
+}
+
+func TestIndex_ContentHashDetectsEditsWithOlderMtime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeTestFile(t, path, "original content", base)
+
+	indexer, _ := newTestIndexer(t)
+	if _, err := indexer.Index(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, path, "edited content", base.Add(-time.Minute))
+
+	result, err := indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FilesIndexed != 1 {
+		t.Errorf("expected edit with skewed mtime to be re-indexed, got indexed=%d", result.FilesIndexed)
+	}
+}
+
+func TestIndex_RegionLevelReindex(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "novel.txt")
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, fmt.Sprintf("chapter line %d with some narrative words", i))
+	}
+	original := strings.Join(append(lines, "the zebra escaped"), "\n")
+	writeTestFile(t, path, original, base)
+
+	indexer, st := newTestIndexer(t)
+	first, err := indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ChunksAdded < 3 {
+		t.Fatalf("expected several chunks, got %d", first.ChunksAdded)
+	}
+
+	edited := strings.Join(append(lines, "the giraffe escaped"), "\n")
+	writeTestFile(t, path, edited, base.Add(time.Minute))
+
+	result, err := indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.FileChanges) != 1 {
+		t.Fatalf("expected one modified file, got %+v", result.FileChanges)
+	}
+	change := result.FileChanges[0]
+	if change.Kept == 0 || change.Added == 0 || change.Removed == 0 {
+		t.Errorf("expected a partial update, got %+v", change)
+	}
+	if change.Kept+change.Added != first.ChunksAdded {
+		t.Errorf("expected chunk count to stay %d, got %+v", first.ChunksAdded, change)
+	}
+
+	if postings, _ := st.GetPostings("zebra"); len(postings) != 0 {
+		t.Errorf("expected postings for removed text to be deleted, got %+v", postings)
+	}
+	if postings, _ := st.GetPostings("giraff"); len(postings) != 1 {
+		t.Errorf("expected postings for new text, got %+v", postings)
+	}
+	if postings, _ := st.GetPostings("chapter"); len(postings) != first.ChunksAdded {
+		t.Errorf("expected postings for kept chunks to survive, got %d", len(postings))
+	}
+
+	docs, _ := st.ListDocs()
+	chunks, _ := st.GetChunksByDoc(docs[0].ID)
+	if len(chunks) != first.ChunksAdded {
+		t.Errorf("expected %d stored chunks, got %d", first.ChunksAdded, len(chunks))
+	}
+}
+
+func TestIndex_WhitespaceEditKeepsChunks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeTestFile(t, path, "connection pool settings\nretry budget", base)
+
+	indexer, st := newTestIndexer(t)
+	if _, err := indexer.Index(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, path, "connection pool settings  \r\nretry budget\t", base.Add(time.Minute))
+	result, err := indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.FileChanges) != 1 {
+		t.Fatalf("expected one modified file, got %+v", result.FileChanges)
+	}
+	if change := result.FileChanges[0]; change.Added != 0 || change.Removed != 0 || change.Kept == 0 {
+		t.Errorf("expected every chunk to be kept, got %+v", change)
+	}
+
+	docs, _ := st.ListDocs()
+	chunks, _ := st.GetChunksByDoc(docs[0].ID)
+	if len(chunks) != 1 || !strings.Contains(chunks[0].Text, "settings  \r\n") {
+		t.Errorf("expected the kept chunk to carry the new text, got %+v", chunks)
+	}
+}
+
+func TestIndex_RemovesVectorsForDeletedChunks(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.txt")
+	gone := filepath.Join(dir, "gone.txt")
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeTestFile(t, keep, "connection pool settings", base)
+	writeTestFile(t, gone, "retry budget and backoff", base)
+
+	indexer, st := newTestIndexer(t)
+	vectors, err := store.NewBoltVectorStore(st.DB(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexer.vectors = vectors
+
+	if _, err := indexer.Index(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := st.ListDocs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []port.VectorItem{{ID: "stale-chunk", Vector: []float32{1, 0}}}
+	for _, doc := range docs {
+		chunks, err := st.GetChunksByDoc(doc.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range chunks {
+			items = append(items, port.VectorItem{ID: c.ID, Vector: []float32{0, 1}})
+		}
+	}
+	if err := vectors.Upsert(items); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	result, err := indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.VectorsPruned != 1 {
+		t.Errorf("expected the stale vector to be pruned, got %d", result.VectorsPruned)
+	}
+
+	count, err := vectors.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected only the vector for keep.txt to remain, got %d", count)
+	}
+}
+
+func TestIndex_SymbolsAndCallGraph(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.go")
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeTestFile(t, path, "package server\n\nfunc Start() {\n\tlisten()\n}\n\nfunc listen() {}\n", base)
+
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	tokenizer := analyzer.NewTokenizer(true)
+	walker := fs.NewWalker([]string{"**/*.go"}, nil)
+	chk := chunker.NewLineChunker(64, 0, tokenizer)
+	indexer := NewIndexUseCase(st, walker, chk, tokenizer, nil, true, nil, analyzer.NewSymbolExtractor(), nil)
+
+	result, err := indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SymbolsIndexed != 2 || result.CallEdges != 1 {
+		t.Fatalf("expected 2 symbols and 1 call edge, got %d and %d", result.SymbolsIndexed, result.CallEdges)
+	}
+
+	docID := generateDocID(path)
+	symbols, err := st.GetSymbolsByDoc(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, _ := st.GetChunksByDoc(docID)
+	for _, sym := range symbols {
+		if sym.ChunkID != chunks[0].ID {
+			t.Errorf("expected %s to be linked to chunk %s, got %q", sym.Name, chunks[0].ID, sym.ChunkID)
+		}
+	}
+	calls, err := st.GetCallGraph(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0].Line != 4 {
+		t.Fatalf("expected Start -> listen at line 4, got %+v", calls)
+	}
+
+	writeTestFile(t, path, "package server\n\nfunc Start() {}\n", base.Add(time.Minute))
+	if _, err := indexer.Index(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	symbols, _ = st.GetSymbolsByDoc(docID)
+	calls, _ = st.GetCallGraph(docID)
+	if len(symbols) != 1 || symbols[0].Name != "Start" || len(calls) != 0 {
+		t.Errorf("expected re-indexing to replace symbols and calls, got %+v %+v", symbols, calls)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := indexer.Index(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	all, _ := st.GetAllSymbols()
+	calls, _ = st.GetCallGraph(docID)
+	if len(all) != 0 || len(calls) != 0 {
+		t.Errorf("expected deleted file to drop its symbols and calls, got %+v %+v", all, calls)
+	}
+}
+
+func TestIndex_TypedCallGraph(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeTestFile(t, filepath.Join(dir, "go.mod"), "module example.com/demo\n", base)
+	writeTestFile(t, filepath.Join(dir, "a.go"), "package demo\n\nfunc Start() {\n\tlisten()\n}\n", base)
+	writeTestFile(t, filepath.Join(dir, "b.go"), "package demo\n\nfunc listen() {}\n", base)
+	writeTestFile(t, filepath.Join(dir, "tools", "tools.go"), "package tools\n\nfunc Run() {\n\tstep()\n}\n\nfunc step() {}\n", base)
+
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	tokenizer := analyzer.NewTokenizer(true)
+	walker := fs.NewWalker([]string{"**/*.go"}, nil)
+	chk := chunker.NewLineChunker(64, 0, tokenizer)
+	indexer := NewIndexUseCase(st, walker, chk, tokenizer, nil, true, nil, analyzer.NewSymbolExtractor(), analyzer.NewGoCallGraph())
+
+	result, err := indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CallEdges != 2 {
+		t.Fatalf("expected 2 call edges, got %d (errors: %v)", result.CallEdges, result.Errors)
+	}
+
+	calls, _ := st.GetCallGraph(generateDocID(filepath.Join(dir, "a.go")))
+	listen, _ := st.GetSymbolsByDoc(generateDocID(filepath.Join(dir, "b.go")))
+	if len(calls) != 1 || len(listen) != 1 || calls[0].CalleeID != listen[0].ID {
+		t.Errorf("expected Start to call listen across files, got %+v and %+v", calls, listen)
+	}
+
+	writeTestFile(t, filepath.Join(dir, "b.go"), "package demo\n\n// listen waits.\nfunc listen() {}\n", base.Add(time.Minute))
+	result, err = indexer.Index(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CallEdges != 1 {
+		t.Errorf("expected only the changed package to be re-analyzed, got %d edges", result.CallEdges)
+	}
+	calls, _ = st.GetCallGraph(generateDocID(filepath.Join(dir, "a.go")))
+	listen, _ = st.GetSymbolsByDoc(generateDocID(filepath.Join(dir, "b.go")))
+	if len(calls) != 1 || len(listen) != 1 || calls[0].CalleeID != listen[0].ID {
+		t.Errorf("expected the edge to follow listen to its new line, got %+v and %+v", calls, listen)
+	}
+	if tools, _ := st.GetCallGraph(generateDocID(filepath.Join(dir, "tools", "tools.go"))); len(tools) != 1 {
+		t.Errorf("expected the untouched package to keep its call graph, got %+v", tools)
+	}
+}
+
+func TestIndex_PersistsChunkMetadata(t *testing.T) {
+	st := newTestSymbolIndex(t, map[string]string{
+		"server.go": "package server\n\nimport \"example.com/app/store\"\n\nfunc Start(cfg store.Config) error {\n\treturn nil\n}\n",
+	})
+	start := findChunk(t, st, "server.go", "func Start")
+
+	meta, err := st.GetChunkMetadata(start.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Name != "Start" || !reflect.DeepEqual(meta.Imports, []string{"example.com/app/store"}) {
+		t.Errorf("expected Start's metadata with its file's imports, got %+v", meta)
+	}
+}
@@ -2,25 +2,31 @@ package usecase
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"time"
 
-	"rag/internal/adapter/analyzer"
 	"rag/internal/adapter/store"
 	"rag/internal/domain"
+	"rag/internal/port"
 )
 
 type PackUseCase struct {
-	store        *store.BoltStore
-	tokenizer    *analyzer.Tokenizer
-	recencyBoost float64
+	store          *store.BoltStore
+	counter        port.Tokenizer
+	recencyBoost   float64
+	expander       *ContextExpander
+	expansionShare float64
 }
 
-func NewPackUseCase(store *store.BoltStore, tokenizer *analyzer.Tokenizer, recencyBoost float64) *PackUseCase {
+// expansionShare of the budget is reserved for graph-related chunks.
+func NewPackUseCase(store *store.BoltStore, counter port.Tokenizer, recencyBoost float64, expander *ContextExpander, expansionShare float64) *PackUseCase {
 	return &PackUseCase{
-		store:        store,
-		tokenizer:    tokenizer,
-		recencyBoost: recencyBoost,
+		store:          store,
+		counter:        counter,
+		recencyBoost:   recencyBoost,
+		expander:       expander,
+		expansionShare: expansionShare,
 	}
 }
 
@@ -59,7 +65,7 @@ func (u *PackUseCase) Pack(query string, chunks []domain.ScoredChunk, budget int
 
 	ranked := make([]rankedChunk, 0, len(chunks))
 	for _, c := range chunks {
-		tokens := u.tokenizer.CountTokens(c.Chunk.Text)
+		tokens := u.counter.CountTokens(c.Chunk.Text)
 		if tokens == 0 {
 			tokens = 1
 		}
@@ -93,17 +99,40 @@ func (u *PackUseCase) Pack(query string, chunks []domain.ScoredChunk, budget int
 		return ranked[i].utility > ranked[j].utility
 	})
 
+	reserved := 0
+	if u.expander != nil && u.expansionShare > 0 {
+		reserved = int(float64(budget) * math.Min(u.expansionShare, 1))
+	}
+
 	selected := make([]domain.ScoredChunk, 0)
+	taken := make([]bool, len(ranked))
 	usedTokens := 0
 
-	for _, rc := range ranked {
-		if usedTokens+rc.tokens > budget {
+	for i, rc := range ranked {
+		if usedTokens+rc.tokens > budget-reserved {
 			continue
 		}
 		selected = append(selected, rc.chunk)
+		taken[i] = true
 		usedTokens += rc.tokens
 	}
 
+	var related []RelatedChunk
+	if reserved > 0 {
+		related, usedTokens = u.selectRelated(selected, budget, usedTokens)
+		relatedIDs := make(map[string]bool, len(related))
+		for _, rc := range related {
+			relatedIDs[rc.Chunk.ID] = true
+		}
+		for i, rc := range ranked {
+			if taken[i] || relatedIDs[rc.chunk.Chunk.ID] || usedTokens+rc.tokens > budget {
+				continue
+			}
+			selected = append(selected, rc.chunk)
+			usedTokens += rc.tokens
+		}
+	}
+
 	merged := u.mergeAdjacentChunks(selected)
 
 	sort.Slice(merged, func(i, j int) bool {
@@ -124,10 +153,22 @@ func (u *PackUseCase) Pack(query string, chunks []domain.ScoredChunk, budget int
 		}
 		snippets = append(snippets, snippet)
 	}
+	for _, rc := range related {
+		doc, err := u.store.GetDoc(rc.Chunk.DocID)
+		if err != nil {
+			continue
+		}
+		snippets = append(snippets, domain.Snippet{
+			Path:  doc.Path,
+			Range: fmt.Sprintf("L%d-%d", rc.Chunk.StartLine, rc.Chunk.EndLine),
+			Why:   rc.Why,
+			Text:  rc.Chunk.Text,
+		})
+	}
 
 	usedTokens = 0
 	for _, s := range snippets {
-		usedTokens += u.tokenizer.CountTokens(s.Text)
+		usedTokens += u.counter.CountTokens(s.Text)
 	}
 
 	return domain.PackedContext{
@@ -138,6 +179,30 @@ func (u *PackUseCase) Pack(query string, chunks []domain.ScoredChunk, budget int
 	}, nil
 }
 
+// selectRelated keeps the related chunks that fit, expanding the strongest seeds first.
+func (u *PackUseCase) selectRelated(selected []domain.ScoredChunk, budget, usedTokens int) ([]RelatedChunk, int) {
+	seeds := append([]domain.ScoredChunk(nil), selected...)
+	sort.SliceStable(seeds, func(i, j int) bool {
+		return seeds[i].Score > seeds[j].Score
+	})
+
+	candidates, err := u.expander.ExpandGraph(seeds)
+	if err != nil {
+		return nil, usedTokens
+	}
+
+	var related []RelatedChunk
+	for _, rc := range candidates {
+		tokens := u.counter.CountTokens(rc.Chunk.Text)
+		if usedTokens+tokens > budget {
+			continue
+		}
+		related = append(related, rc)
+		usedTokens += tokens
+	}
+	return related, usedTokens
+}
+
 func (u *PackUseCase) mergeAdjacentChunks(chunks []domain.ScoredChunk) []domain.ScoredChunk {
 	if len(chunks) <= 1 {
 		return chunks
@@ -34,7 +34,7 @@ func TestPackBudget(t *testing.T) {
 	}
 
 	tokenizer := analyzer.NewTokenizer(true)
-	packUC := NewPackUseCase(st, tokenizer, 0)
+	packUC := NewPackUseCase(st, tokenizer, 0, nil, 0)
 
 	chunks := []domain.ScoredChunk{
 		{
@@ -118,7 +118,7 @@ func TestPackEmptyChunks(t *testing.T) {
 	defer st.Close()
 
 	tokenizer := analyzer.NewTokenizer(true)
-	packUC := NewPackUseCase(st, tokenizer, 0)
+	packUC := NewPackUseCase(st, tokenizer, 0, nil, 0)
 
 	packed, err := packUC.Pack("test query", nil, 1000)
 	if err != nil {
@@ -156,7 +156,7 @@ func TestPackMergeAdjacent(t *testing.T) {
 	st.PutDoc(doc)
 
 	tokenizer := analyzer.NewTokenizer(true)
-	packUC := NewPackUseCase(st, tokenizer, 0)
+	packUC := NewPackUseCase(st, tokenizer, 0, nil, 0)
 
 	chunks := []domain.ScoredChunk{
 		{
@@ -226,7 +226,7 @@ func TestPackUtilityRanking(t *testing.T) {
 	st.PutDoc(doc)
 
 	tokenizer := analyzer.NewTokenizer(true)
-	packUC := NewPackUseCase(st, tokenizer, 0)
+	packUC := NewPackUseCase(st, tokenizer, 0, nil, 0)
 
 	chunks := []domain.ScoredChunk{
 		{
@@ -58,9 +58,10 @@ func (u *RetrieveUseCase) RetrieveWithoutMMR(query string, topK int) ([]domain.S
 }
 
 type ScoredChunkResult struct {
-	Path      string  `json:"path"`
-	StartLine int     `json:"start_line"`
-	EndLine   int     `json:"end_line"`
-	Score     float64 `json:"score"`
-	Text      string  `json:"text"`
+	Path        string              `json:"path"`
+	StartLine   int                 `json:"start_line"`
+	EndLine     int                 `json:"end_line"`
+	Score       float64             `json:"score"`
+	Text        string              `json:"text"`
+	Explanation *domain.Explanation `json:"explanation,omitempty"`
 }
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"rag/internal/domain"
+	"rag/internal/port"
+)
+
+type SymbolUseCase struct {
+	store   port.IndexStore
+	symbols port.SymbolStore
+}
+
+func NewSymbolUseCase(store port.IndexStore, symbols port.SymbolStore) *SymbolUseCase {
+	return &SymbolUseCase{
+		store:   store,
+		symbols: symbols,
+	}
+}
+
+type SymbolFilter struct {
+	Kind  string
+	Lang  string
+	Limit int
+}
+
+type SymbolResult struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Signature string `json:"signature,omitempty"`
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Lang      string `json:"lang,omitempty"`
+}
+
+type DefinitionResult struct {
+	SymbolResult
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
+// Glob patterns match the whole name; variables are listed only when filter.Kind asks.
+func (u *SymbolUseCase) Search(pattern string, filter SymbolFilter) ([]SymbolResult, error) {
+	var candidates []domain.Symbol
+	var err error
+	if strings.ContainsAny(pattern, "*?[") {
+		candidates, err = u.symbols.GetAllSymbols()
+		if err != nil {
+			return nil, err
+		}
+		glob := strings.ToLower(pattern)
+		matched := candidates[:0]
+		for _, sym := range candidates {
+			if ok, _ := path.Match(glob, strings.ToLower(sym.Name)); ok {
+				matched = append(matched, sym)
+			}
+		}
+		candidates = matched
+	} else {
+		candidates, err = u.symbols.SearchSymbols(pattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if filter.Kind == "" {
+		declared := candidates[:0]
+		for _, sym := range candidates {
+			if sym.Type != "variable" {
+				declared = append(declared, sym)
+			}
+		}
+		candidates = declared
+	}
+
+	symbols := u.resolve(candidates, filter)
+	if filter.Limit > 0 && len(symbols) > filter.Limit {
+		symbols = symbols[:filter.Limit]
+	}
+
+	results := make([]SymbolResult, len(symbols))
+	for i, sym := range symbols {
+		results[i] = sym.SymbolResult
+	}
+	return results, nil
+}
+
+// Methods match as "Start", "Server.Start" or "*Server.Start".
+func (u *SymbolUseCase) Define(name string, filter SymbolFilter) ([]DefinitionResult, error) {
+	all, err := u.symbols.GetAllSymbols()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []domain.Symbol
+	for _, sym := range all {
+		if symbolNameMatches(sym.Name, name) {
+			candidates = append(candidates, sym)
+		}
+	}
+
+	symbols := u.resolve(candidates, filter)
+	if filter.Limit > 0 && len(symbols) > filter.Limit {
+		symbols = symbols[:filter.Limit]
+	}
+
+	results := make([]DefinitionResult, 0, len(symbols))
+	for _, sym := range symbols {
+		def := DefinitionResult{SymbolResult: sym.SymbolResult}
+		if sym.chunkID != "" {
+			if chunk, err := u.store.GetChunk(sym.chunkID); err == nil {
+				def.StartLine = chunk.StartLine
+				def.EndLine = chunk.EndLine
+				def.Text = chunk.Text
+			}
+		}
+		results = append(results, def)
+	}
+	return results, nil
+}
+
+type resolvedSymbol struct {
+	SymbolResult
+	chunkID string
+}
+
+func (u *SymbolUseCase) resolve(symbols []domain.Symbol, filter SymbolFilter) []resolvedSymbol {
+	docs := make(map[string]*domain.Document)
+	results := make([]resolvedSymbol, 0, len(symbols))
+	for _, sym := range symbols {
+		if filter.Kind != "" && !strings.EqualFold(sym.Type, filter.Kind) {
+			continue
+		}
+
+		doc, cached := docs[sym.DocID]
+		if !cached {
+			if d, err := u.store.GetDoc(sym.DocID); err == nil {
+				doc = &d
+			}
+			docs[sym.DocID] = doc
+		}
+		if doc == nil {
+			continue
+		}
+		if filter.Lang != "" && !strings.EqualFold(doc.Lang, filter.Lang) {
+			continue
+		}
+
+		results = append(results, resolvedSymbol{
+			SymbolResult: SymbolResult{
+				Name:      sym.Name,
+				Kind:      sym.Type,
+				Signature: sym.Signature,
+				Path:      doc.Path,
+				Line:      sym.Line,
+				Lang:      doc.Lang,
+			},
+			chunkID: sym.ChunkID,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].Line < results[j].Line
+	})
+	return results
+}
+
+func symbolNameMatches(symbol, name string) bool {
+	symbol = strings.TrimPrefix(symbol, "*")
+	name = strings.TrimPrefix(name, "*")
+	return symbol == name || strings.HasSuffix(symbol, "."+name)
+}
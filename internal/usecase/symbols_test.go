@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"rag/internal/adapter/analyzer"
+	"rag/internal/adapter/chunker"
+	"rag/internal/adapter/fs"
+	"rag/internal/adapter/store"
+)
+
+func newTestSymbolIndex(t *testing.T, files map[string]string) *store.BoltStore {
+	t.Helper()
+
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour)
+	for name, content := range files {
+		writeTestFile(t, filepath.Join(dir, name), content, modTime)
+	}
+
+	st, err := store.NewBoltStore(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	tokenizer := analyzer.NewTokenizer(true)
+	walker := fs.NewWalker([]string{"**/*.go", "**/*.py"}, nil)
+	chk := chunker.NewCompositeChunker(256, 0, tokenizer, true)
+	indexer := NewIndexUseCase(st, walker, chk, tokenizer, nil, true, nil, analyzer.NewSymbolExtractor(), nil)
+	if _, err := indexer.Index(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func symbolNames(results []SymbolResult) []string {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func TestSymbolUseCase_Search(t *testing.T) {
+	st := newTestSymbolIndex(t, map[string]string{
+		"server.go": "package server\n\nvar serverCount int\n\ntype Server struct{}\n\nfunc NewServer() *Server {\n\treturn &Server{}\n}\n\nfunc (s *Server) Start() error {\n\treturn nil\n}\n",
+		"client.py": "class ServerClient:\n    def connect(self):\n        pass\n",
+	})
+	symbols := NewSymbolUseCase(st, st)
+
+	results, err := symbols.Search("server", SymbolFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ServerClient", "Server", "NewServer", "*Server.Start"}
+	if names := symbolNames(results); !reflect.DeepEqual(names, want) {
+		t.Errorf("Search(server) = %v, want %v", names, want)
+	}
+
+	results, _ = symbols.Search("server", SymbolFilter{Kind: "variable"})
+	if names := symbolNames(results); !reflect.DeepEqual(names, []string{"serverCount"}) {
+		t.Errorf("expected variables only with --kind variable, got %v", names)
+	}
+
+	results, _ = symbols.Search("New*", SymbolFilter{Lang: "go"})
+	if names := symbolNames(results); !reflect.DeepEqual(names, []string{"NewServer"}) {
+		t.Errorf("expected glob to match the whole name, got %v", names)
+	}
+
+	results, _ = symbols.Search("server", SymbolFilter{Lang: "python"})
+	if len(results) != 1 || results[0].Kind != "class" || results[0].Line != 1 {
+		t.Errorf("expected the python class only, got %+v", results)
+	}
+}
+
+func TestSymbolUseCase_Define(t *testing.T) {
+	st := newTestSymbolIndex(t, map[string]string{
+		"server.go": "package server\n\ntype Server struct{}\n\nfunc (s *Server) Start() error {\n\treturn nil\n}\n\nfunc Start() {}\n",
+	})
+	symbols := NewSymbolUseCase(st, st)
+
+	for _, name := range []string{"Server.Start", "*Server.Start"} {
+		defs, err := symbols.Define(name, SymbolFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(defs) != 1 || defs[0].Name != "*Server.Start" {
+			t.Fatalf("Define(%s) = %+v, want the method", name, defs)
+		}
+		if defs[0].StartLine > 5 || defs[0].EndLine < 7 || defs[0].Text == "" {
+			t.Errorf("expected the defining chunk around lines 5-7, got %+v", defs[0])
+		}
+	}
+
+	defs, _ := symbols.Define("Start", SymbolFilter{})
+	if len(defs) != 2 {
+		t.Errorf("expected the bare name to match the method and the function, got %+v", defs)
+	}
+	defs, _ = symbols.Define("Start", SymbolFilter{Kind: "function"})
+	if len(defs) != 1 || defs[0].Line != 9 {
+		t.Errorf("expected the kind filter to keep the function, got %+v", defs)
+	}
+}